@@ -0,0 +1,272 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package bridge converts messages between the protobuf binary wire format
+// and polyglot bytes for the same schema.
+//
+// Polyglot has no notion of field presence, so fields that are unset in a
+// protobuf message are written as zero values, and zero values decoded from
+// polyglot are left unset in the protobuf message. Enum values are written as
+// the index of their declaration, like the constants of generated enums, so
+// numbers that the schema does not declare cannot be converted.
+package bridge
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/dynamic"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrUndeclaredEnum = errors.New("undeclared enum value")
+)
+
+// Encode writes a protobuf message to b as polyglot bytes.
+func Encode(b *polyglot.Buffer, m proto.Message) error {
+	d, err := ToDynamic(m.ProtoReflect())
+	if err != nil {
+		return err
+	}
+	d.Encode(b)
+	return nil
+}
+
+// Decode reads polyglot bytes into a protobuf message.
+func Decode(data []byte, m proto.Message) error {
+	d := dynamic.NewMessage(m.ProtoReflect().Descriptor())
+	if err := d.Decode(data); err != nil {
+		return err
+	}
+	return FromDynamic(d, m.ProtoReflect())
+}
+
+// ProtobufToPolyglot re-encodes protobuf binary data of the given message type as polyglot bytes.
+func ProtobufToPolyglot(desc protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+	m := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	if err := Encode(b, m); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), b.Bytes()...), nil
+}
+
+// PolyglotToProtobuf re-encodes polyglot bytes of the given message type as protobuf binary data.
+func PolyglotToProtobuf(desc protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+	m := dynamicpb.NewMessage(desc)
+	if err := Decode(data, m); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+// ToDynamic copies a protobuf message into a dynamic polyglot message.
+func ToDynamic(m protoreflect.Message) (*dynamic.Message, error) {
+	desc := m.Descriptor()
+	if err := dynamic.Supported(desc); err != nil {
+		return nil, err
+	}
+	d := dynamic.NewMessage(desc)
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if !m.Has(field) {
+			continue
+		}
+		value, err := toGo(field, m.Get(field))
+		if err != nil {
+			return nil, err
+		}
+		if err = d.Set(string(field.Name()), value); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func toGo(field protoreflect.FieldDescriptor, value protoreflect.Value) (interface{}, error) {
+	switch {
+	case field.IsMap():
+		t, err := dynamic.GoType(field)
+		if err != nil {
+			return nil, err
+		}
+		out := reflect.MakeMapWithSize(t, value.Map().Len())
+		var rangeErr error
+		value.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			key, err := toGoScalar(field.MapKey(), k.Value())
+			if err != nil {
+				rangeErr = err
+				return false
+			}
+			val, err := toGoScalar(field.MapValue(), v)
+			if err != nil {
+				rangeErr = err
+				return false
+			}
+			out.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(val))
+			return true
+		})
+		return out.Interface(), rangeErr
+	case field.IsList():
+		t, err := dynamic.GoType(field)
+		if err != nil {
+			return nil, err
+		}
+		list := value.List()
+		out := reflect.MakeSlice(t, list.Len(), list.Len())
+		for i := 0; i < list.Len(); i++ {
+			val, err := toGoScalar(field, list.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			out.Index(i).Set(reflect.ValueOf(val))
+		}
+		return out.Interface(), nil
+	default:
+		return toGoScalar(field, value)
+	}
+}
+
+func toGoScalar(field protoreflect.FieldDescriptor, value protoreflect.Value) (interface{}, error) {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return value.Bool(), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return int32(value.Int()), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return uint32(value.Uint()), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return value.Int(), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return value.Uint(), nil
+	case protoreflect.FloatKind:
+		return float32(value.Float()), nil
+	case protoreflect.DoubleKind:
+		return value.Float(), nil
+	case protoreflect.StringKind:
+		return value.String(), nil
+	case protoreflect.BytesKind:
+		return value.Bytes(), nil
+	case protoreflect.EnumKind:
+		index, ok := dynamic.EnumIndex(field.Enum(), value.Enum())
+		if !ok {
+			return nil, fmt.Errorf("%w %s(%d)", ErrUndeclaredEnum, field.Enum().FullName(), value.Enum())
+		}
+		return index, nil
+	case protoreflect.MessageKind:
+		return ToDynamic(value.Message())
+	default:
+		return nil, dynamic.ErrUnsupportedField
+	}
+}
+
+// FromDynamic copies a dynamic polyglot message into a protobuf message of the same type.
+func FromDynamic(d *dynamic.Message, m protoreflect.Message) error {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		value, err := d.Get(string(field.Name()))
+		if err != nil {
+			return err
+		}
+		v := reflect.ValueOf(value)
+		switch {
+		case field.IsMap():
+			if v.Len() == 0 {
+				continue
+			}
+			out := m.Mutable(field).Map()
+			iter := v.MapRange()
+			for iter.Next() {
+				key, err := fromGoScalar(m, field.MapKey(), iter.Key().Interface())
+				if err != nil {
+					return err
+				}
+				val, err := fromGoScalar(out, field.MapValue(), iter.Value().Interface())
+				if err != nil {
+					return err
+				}
+				out.Set(key.MapKey(), val)
+			}
+		case field.IsList():
+			if v.Len() == 0 {
+				continue
+			}
+			out := m.Mutable(field).List()
+			for j := 0; j < v.Len(); j++ {
+				val, err := fromGoScalar(out, field, v.Index(j).Interface())
+				if err != nil {
+					return err
+				}
+				out.Append(val)
+			}
+		default:
+			if v.IsZero() {
+				continue
+			}
+			val, err := fromGoScalar(m, field, value)
+			if err != nil {
+				return err
+			}
+			m.Set(field, val)
+		}
+	}
+	return nil
+}
+
+// fromGoScalar converts a single value, allocating nested messages from
+// parent, which is the protoreflect.Message, List or Map holding the value.
+func fromGoScalar(parent interface{}, field protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	switch v := value.(type) {
+	case *dynamic.Message:
+		var nested protoreflect.Message
+		switch p := parent.(type) {
+		case protoreflect.Message:
+			nested = p.NewField(field).Message()
+		case protoreflect.List:
+			nested = p.NewElement().Message()
+		case protoreflect.Map:
+			nested = p.NewValue().Message()
+		}
+		if v != nil {
+			if err := FromDynamic(v, nested); err != nil {
+				return protoreflect.Value{}, err
+			}
+		}
+		return protoreflect.ValueOfMessage(nested), nil
+	case uint32:
+		if field.Kind() == protoreflect.EnumKind {
+			number, ok := dynamic.EnumNumber(field.Enum(), v)
+			if !ok {
+				return protoreflect.Value{}, fmt.Errorf("%w %s at index %d", ErrUndeclaredEnum, field.Enum().FullName(), v)
+			}
+			return protoreflect.ValueOfEnum(number), nil
+		}
+		return protoreflect.ValueOfUint32(v), nil
+	default:
+		return protoreflect.ValueOf(v), nil
+	}
+}
@@ -0,0 +1,247 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package bridge
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/dynamic"
+	"github.com/loopholelabs/polyglot/v2/internal/fixture"
+	"github.com/loopholelabs/polyglot/v2/internal/fixture/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"testing"
+)
+
+func testMessage() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("bridge_test.proto"),
+		Package:    proto.String("bridge"),
+		Dependency: []string{"a.proto", "b.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Test"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:   proto.String("value"),
+						Number: proto.Int32(1),
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+						Label:  descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					},
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			},
+		},
+		Syntax: proto.String("proto3"),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	m := testMessage()
+	b := polyglot.NewBuffer()
+	require.NoError(t, Encode(b, m))
+
+	decoded := new(descriptorpb.FileDescriptorProto)
+	require.NoError(t, Decode(b.Bytes(), decoded))
+	assert.True(t, proto.Equal(m, decoded))
+}
+
+func TestPolyglotLayout(t *testing.T) {
+	t.Parallel()
+
+	m := testMessage()
+	b := polyglot.NewBuffer()
+	require.NoError(t, Encode(b, m))
+
+	d := dynamic.NewMessage(m.ProtoReflect().Descriptor())
+	require.NoError(t, d.Decode(b.Bytes()))
+
+	name, err := d.Get("name")
+	require.NoError(t, err)
+	assert.Equal(t, "bridge_test.proto", name)
+
+	dependencies, err := d.Get("dependency")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.proto", "b.proto"}, dependencies)
+
+	messages, err := d.Get("message_type")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	fields, err := messages.([]*dynamic.Message)[0].Get("field")
+	require.NoError(t, err)
+	label, err := fields.([]*dynamic.Message)[0].Get("label")
+	require.NoError(t, err)
+	// LABEL_REPEATED is 3, but it is the second value declared.
+	assert.Equal(t, uint32(1), label)
+}
+
+func TestBytesConversion(t *testing.T) {
+	t.Parallel()
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("bridge_map_test.proto"),
+		Package: proto.String("bridge"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Counts"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("counts"),
+				JsonName: proto.String("counts"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				TypeName: proto.String(".bridge.Counts.CountsEntry"),
+			}},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("CountsEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("key"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+					{Name: proto.String("value"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_SINT64.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	desc := file.Messages().ByName("Counts")
+
+	m := dynamicpb.NewMessage(desc)
+	counts := m.Mutable(desc.Fields().ByName("counts")).Map()
+	counts.Set(protoreflect.ValueOfString("a").MapKey(), protoreflect.ValueOfInt64(-1))
+	counts.Set(protoreflect.ValueOfString("b").MapKey(), protoreflect.ValueOfInt64(2))
+	data, err := proto.Marshal(m)
+	require.NoError(t, err)
+
+	polyglotData, err := ProtobufToPolyglot(desc, data)
+	require.NoError(t, err)
+
+	protobufData, err := PolyglotToProtobuf(desc, polyglotData)
+	require.NoError(t, err)
+
+	decoded := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(protobufData, decoded))
+	assert.True(t, proto.Equal(m, decoded))
+
+	_, err = PolyglotToProtobuf(desc, []byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestGeneratedFixture(t *testing.T) {
+	t.Parallel()
+
+	file, err := schema.File()
+	require.NoError(t, err)
+	desc := file.Messages().ByName("User")
+	fields := desc.Fields()
+
+	m := dynamicpb.NewMessage(desc)
+	m.Set(fields.ByName("id"), protoreflect.ValueOfString("u1"))
+	m.Set(fields.ByName("status"), protoreflect.ValueOfEnum(10))
+	history := m.Mutable(fields.ByName("history")).List()
+	history.Append(protoreflect.ValueOfEnum(5))
+	history.Append(protoreflect.ValueOfEnum(10))
+	m.Mutable(fields.ByName("statuses")).Map().Set(protoreflect.ValueOfString("last").MapKey(), protoreflect.ValueOfEnum(5))
+	data, err := proto.Marshal(m)
+	require.NoError(t, err)
+
+	encoded, err := ProtobufToPolyglot(desc, data)
+	require.NoError(t, err)
+	user := new(fixture.FixtureUser)
+	require.NoError(t, user.Decode(encoded))
+	assert.Equal(t, "u1", user.UserID)
+	assert.Equal(t, fixture.FixtureSTATUS_DELETED, user.Status)
+	assert.Equal(t, []fixture.FixtureStatus{fixture.FixtureSTATUS_ACTIVE, fixture.FixtureSTATUS_DELETED}, user.History)
+	assert.Equal(t, fixture.FixtureUserStatusesMap{"last": fixture.FixtureSTATUS_ACTIVE}, user.Statuses)
+
+	b := polyglot.NewBuffer()
+	user.Encode(b)
+	data, err = PolyglotToProtobuf(desc, b.Bytes())
+	require.NoError(t, err)
+	decoded := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(data, decoded))
+	assert.True(t, proto.Equal(m, decoded))
+
+	m.Set(fields.ByName("status"), protoreflect.ValueOfEnum(3))
+	data, err = proto.Marshal(m)
+	require.NoError(t, err)
+	_, err = ProtobufToPolyglot(desc, data)
+	assert.ErrorIs(t, err, ErrUndeclaredEnum)
+
+	user.Status = 3
+	b.Reset()
+	user.Encode(b)
+	_, err = PolyglotToProtobuf(desc, b.Bytes())
+	assert.ErrorIs(t, err, ErrUndeclaredEnum)
+}
+
+func TestGroupField(t *testing.T) {
+	t.Parallel()
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("group_test.proto"),
+		Package: proto.String("bridge"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Test"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:   proto.String("value"),
+						Number: proto.Int32(1),
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+						Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+					{
+						Name:     proto.String("item"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_GROUP.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						TypeName: proto.String(".bridge.Test.Item"),
+					},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{Name: proto.String("Item")},
+				},
+			},
+		},
+		Syntax: proto.String("proto2"),
+	}, nil)
+	require.NoError(t, err)
+	desc := file.Messages().ByName("Test")
+
+	m := dynamicpb.NewMessage(desc)
+	m.Set(desc.Fields().ByName("value"), protoreflect.ValueOfInt64(1))
+	data, err := proto.Marshal(m)
+	require.NoError(t, err)
+
+	_, err = ProtobufToPolyglot(desc, data)
+	assert.ErrorIs(t, err, dynamic.ErrUnsupportedField)
+	assert.ErrorIs(t, Encode(polyglot.NewBuffer(), m), dynamic.ErrUnsupportedField)
+
+	// Dynamic messages of the same type are written as nil rather than
+	// failing to encode.
+	b := polyglot.NewBuffer()
+	dynamic.NewMessage(desc).Encode(b)
+	assert.Equal(t, []byte{polyglot.NilRawKind}, b.Bytes())
+	assert.ErrorIs(t, dynamic.Supported(desc), dynamic.ErrUnsupportedField)
+}
@@ -0,0 +1,78 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+	"github.com/loopholelabs/polyglot/v2/generator/golang"
+
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrIncompatible = errors.New("schemas are incompatible")
+)
+
+func compat(args []string) error {
+	flags := flag.NewFlagSet("compat", flag.ContinueOnError)
+	var imports importPaths
+	flags.Var(&imports, "I", "Directory to search for imports (may be repeated)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: polyglot compat [flags] old.proto new.proto\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected 2 files, got %d", flags.NArg())
+	}
+
+	// The files are compiled separately because both versions usually
+	// declare the same package.
+	from, err := registryFromSource(imports, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := registryFromSource(imports, flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	incompatibilities := descriptor.Compare(from, to)
+	for _, incompatibility := range incompatibilities {
+		fmt.Fprintln(os.Stdout, incompatibility)
+	}
+	if len(incompatibilities) > 0 {
+		return fmt.Errorf("%w: %d breaking changes", ErrIncompatible, len(incompatibilities))
+	}
+	return nil
+}
+
+// registryFromSource compiles a .proto file and registers the descriptors
+// of the file and everything it imports.
+func registryFromSource(imports []string, file string) (*descriptor.Registry, error) {
+	compiled, err := compile(imports, []string{file})
+	if err != nil {
+		return nil, err
+	}
+	return golang.NewRegistry(compiled...)
+}
@@ -0,0 +1,219 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/bufbuild/protocompile"
+	"github.com/loopholelabs/polyglot/v2/generator/golang"
+	"github.com/loopholelabs/polyglot/v2/generator/rust"
+	"github.com/loopholelabs/polyglot/v2/generator/typescript"
+	"github.com/loopholelabs/polyglot/v2/options"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoInputFiles = errors.New("no input files")
+)
+
+type generator interface {
+	Generate(req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error)
+}
+
+var (
+	generators = map[string]func() generator{
+		"go":         func() generator { return golang.New() },
+		"rust":       func() generator { return rust.New() },
+		"typescript": func() generator { return typescript.New() },
+	}
+)
+
+// importPaths collects repeated -I flags.
+type importPaths []string
+
+func (p *importPaths) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *importPaths) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func generate(args []string) error {
+	languages := make([]string, 0, len(generators))
+	for name := range generators {
+		languages = append(languages, name)
+	}
+	sort.Strings(languages)
+
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	lang := flags.String("lang", "go", fmt.Sprintf("Language to generate (%s)", strings.Join(languages, ", ")))
+	out := flags.String("out", ".", "Directory to write generated files to")
+	opt := flags.String("opt", "", "Comma separated generator parameters, as passed to --<plugin>_opt")
+	descriptorSet := flags.String("descriptor_set", "", "Read a binary FileDescriptorSet instead of parsing .proto files")
+	var imports importPaths
+	flags.Var(&imports, "I", "Directory to search for imports (may be repeated)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: polyglot generate [flags] [files...]\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	newGenerator, ok := generators[*lang]
+	if !ok {
+		return fmt.Errorf("unsupported language %q", *lang)
+	}
+
+	var req *pluginpb.CodeGeneratorRequest
+	var err error
+	if *descriptorSet != "" {
+		req, err = requestFromDescriptorSet(*descriptorSet, flags.Args())
+	} else {
+		req, err = requestFromSources(imports, flags.Args())
+	}
+	if err != nil {
+		return err
+	}
+	if *opt != "" {
+		req.Parameter = opt
+	}
+
+	res, err := newGenerator().Generate(req)
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return errors.New(res.GetError())
+	}
+
+	for _, f := range res.File {
+		path := filepath.Join(*out, filepath.FromSlash(f.GetName()))
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err = os.WriteFile(path, []byte(f.GetContent()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestFromSources parses the given .proto files, along with everything
+// they import, and builds the request protoc would send to a plugin.
+func requestFromSources(imports []string, files []string) (*pluginpb.CodeGeneratorRequest, error) {
+	compiled, err := compile(imports, files)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: files,
+	}
+	seen := make(map[string]struct{})
+	var add func(file protoreflect.FileDescriptor)
+	add = func(file protoreflect.FileDescriptor) {
+		if _, ok := seen[file.Path()]; ok {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		for i := 0; i < file.Imports().Len(); i++ {
+			add(file.Imports().Get(i).FileDescriptor)
+		}
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(file))
+	}
+	for _, file := range compiled {
+		add(file)
+	}
+	return req, nil
+}
+
+// compile parses the given .proto files, resolving imports from the import
+// paths and the polyglot options.
+func compile(imports []string, files []string) ([]protoreflect.FileDescriptor, error) {
+	if len(files) == 0 {
+		return nil, ErrNoInputFiles
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(protocompile.CompositeResolver{
+			&protocompile.SourceResolver{
+				ImportPaths: imports,
+			},
+			// polyglot/options.proto resolves without an import path, like
+			// the well-known types.
+			protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+				if path == options.File_polyglot_options_proto.Path() {
+					return protocompile.SearchResult{Desc: options.File_polyglot_options_proto}, nil
+				}
+				return protocompile.SearchResult{}, os.ErrNotExist
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	compiled, err := compiler.Compile(context.Background(), files...)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptors := make([]protoreflect.FileDescriptor, 0, len(compiled))
+	for _, file := range compiled {
+		descriptors = append(descriptors, file)
+	}
+	return descriptors, nil
+}
+
+// requestFromDescriptorSet builds a request from a FileDescriptorSet, such as
+// the one written by protoc --descriptor_set_out --include_imports. Every file
+// in the set is generated if no files are given.
+func requestFromDescriptorSet(path string, files []string) (*pluginpb.CodeGeneratorRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := new(descriptorpb.FileDescriptorSet)
+	if err = proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+	if len(set.File) == 0 {
+		return nil, ErrNoInputFiles
+	}
+
+	if len(files) == 0 {
+		for _, file := range set.File {
+			files = append(files, file.GetName())
+		}
+	}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: files,
+		ProtoFile:      set.File,
+	}, nil
+}
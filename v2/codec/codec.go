@@ -0,0 +1,107 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package codec lets polyglot messages travel over gRPC. Importing it
+// registers a google.golang.org/grpc/encoding.Codec named "polyglot", which
+// clients select with grpc.CallContentSubtype(codec.Name).
+package codec
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	"errors"
+	"fmt"
+)
+
+// Name is the name the codec is registered under, and the gRPC content
+// subtype of polyglot payloads.
+const Name = "polyglot"
+
+var (
+	ErrUnsupportedType = errors.New("type does not implement Encode and Decode")
+)
+
+func init() {
+	encoding.RegisterCodec(Codec{})
+}
+
+// Codec marshals values implementing the Encode and Decode methods of
+// generated messages.
+type Codec struct{}
+
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(rpc.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	m.Encode(b)
+	data := make([]byte, b.Len())
+	copy(data, b.Bytes())
+	return data, nil
+}
+
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(rpc.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return m.Decode(data)
+}
+
+func (Codec) Name() string {
+	return Name
+}
+
+// ServerStream adapts a gRPC server stream for the stream handles generated
+// for streaming methods.
+func ServerStream(stream grpc.ServerStream) rpc.ServerStream {
+	return &serverStream{stream}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+}
+
+func (s *serverStream) SendMsg(m rpc.Message) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *serverStream) RecvMsg(m rpc.Message) error {
+	return s.ServerStream.RecvMsg(m)
+}
+
+// ClientStream adapts a gRPC client stream for the stream handles generated
+// for streaming methods.
+func ClientStream(stream grpc.ClientStream) rpc.ClientStream {
+	return &clientStream{stream}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) SendMsg(m rpc.Message) error {
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *clientStream) RecvMsg(m rpc.Message) error {
+	return s.ClientStream.RecvMsg(m)
+}
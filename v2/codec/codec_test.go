@@ -0,0 +1,167 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package codec
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+type testMessage struct {
+	Value string
+}
+
+func (x *testMessage) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(x.Value)
+}
+
+func (x *testMessage) Decode(b []byte) (err error) {
+	x.Value, err = polyglot.Decoder(b).String()
+	return
+}
+
+type testService interface {
+	Upper(ctx context.Context, req *testMessage) (*testMessage, error)
+	Echo(stream rpc.ServerStream) error
+}
+
+type testServer struct{}
+
+func (testServer) Upper(_ context.Context, req *testMessage) (*testMessage, error) {
+	if req.Value == "" {
+		return nil, status.Error(codes.InvalidArgument, "value is required")
+	}
+	return &testMessage{Value: strings.ToUpper(req.Value)}, nil
+}
+
+func (testServer) Echo(stream rpc.ServerStream) error {
+	for {
+		req := new(testMessage)
+		if err := stream.RecvMsg(req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := stream.SendMsg(req); err != nil {
+			return err
+		}
+	}
+}
+
+var testServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Strings",
+	HandlerType: (*testService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Upper",
+			Handler: func(impl interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(testMessage)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return impl.(testService).Upper(ctx, req)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Echo",
+			Handler: func(impl interface{}, stream grpc.ServerStream) error {
+				return impl.(testService).Echo(ServerStream(stream))
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+func TestCodec(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Codec{}, encoding.GetCodec(Name))
+
+	data, err := Codec{}.Marshal(&testMessage{Value: "polyglot"})
+	require.NoError(t, err)
+	expected := polyglot.NewBuffer()
+	(&testMessage{Value: "polyglot"}).Encode(expected)
+	assert.Equal(t, expected.Bytes(), data)
+
+	m := new(testMessage)
+	require.NoError(t, Codec{}.Unmarshal(data, m))
+	assert.Equal(t, "polyglot", m.Value)
+
+	_, err = Codec{}.Marshal("polyglot")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.ErrorIs(t, Codec{}.Unmarshal(data, new(string)), ErrUnsupportedType)
+}
+
+func TestBufconn(t *testing.T) {
+	t.Parallel()
+
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	s.RegisterService(&testServiceDesc, testServer{})
+	go func() {
+		_ = s.Serve(l)
+	}()
+	defer s.Stop()
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(Name)),
+	)
+	require.NoError(t, err)
+	defer cc.Close()
+	ctx := context.Background()
+
+	res := new(testMessage)
+	require.NoError(t, cc.Invoke(ctx, "/test.Strings/Upper", &testMessage{Value: "polyglot"}, res))
+	assert.Equal(t, "POLYGLOT", res.Value)
+
+	err = cc.Invoke(ctx, "/test.Strings/Upper", &testMessage{}, res)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	grpcStream, err := cc.NewStream(ctx, &testServiceDesc.Streams[0], "/test.Strings/Echo")
+	require.NoError(t, err)
+	stream := ClientStream(grpcStream)
+	for _, v := range []string{"ping", "pong"} {
+		require.NoError(t, stream.SendMsg(&testMessage{Value: v}))
+		require.NoError(t, stream.RecvMsg(res))
+		assert.Equal(t, v, res.Value)
+	}
+	require.NoError(t, stream.CloseSend())
+	assert.ErrorIs(t, stream.RecvMsg(res), io.EOF)
+}
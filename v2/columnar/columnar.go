@@ -0,0 +1,342 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package columnar encodes the values of one field across a batch of
+// messages as a single column, which the code generated with the columnar
+// option uses to encode slices of messages field by field.
+//
+// Every column is written as one polyglot Bytes value, so a decoder can skip
+// columns it does not need. Booleans are packed into bitmaps, integers into
+// varints (zigzag encoded when signed), floats into fixed-size little endian
+// values, and strings into a dictionary of distinct values followed by the
+// index of each value. Other values are written one after another with
+// their regular polyglot encoding.
+package columnar
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var (
+	ErrInvalidColumn = errors.New("invalid column")
+)
+
+type Unsigned interface {
+	~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+type Signed interface {
+	~int8 | ~int16 | ~int32 | ~int64
+}
+
+// column returns a pooled buffer to build a column in.
+func column() *polyglot.Buffer {
+	return polyglot.GetBuffer()
+}
+
+// flush writes a column built in c to b as a Bytes value.
+func flush(b *polyglot.Buffer, c *polyglot.Buffer) {
+	polyglot.Encoder(b).Bytes(c.Bytes())
+	polyglot.PutBuffer(c)
+}
+
+func read(d *polyglot.BufferDecoder) ([]byte, error) {
+	return d.Bytes(nil)
+}
+
+func appendUvarint(c *polyglot.Buffer, v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	c.Write(binary.AppendUvarint(scratch[:0], v))
+}
+
+// Any reports whether any value of a bitmap column is true.
+func Any(values []bool) bool {
+	for _, v := range values {
+		if v {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeBools writes the n values returned by get as a bitmap.
+func EncodeBools[T ~bool](b *polyglot.Buffer, n int, get func(i int) T) {
+	c := column()
+	bitmap := make([]byte, (n+7)/8)
+	for i := 0; i < n; i++ {
+		if get(i) {
+			bitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+	c.Write(bitmap)
+	flush(b, c)
+}
+
+func DecodeBools[T ~bool](d *polyglot.BufferDecoder, n int) ([]T, error) {
+	data, err := read(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != (n+7)/8 {
+		return nil, ErrInvalidColumn
+	}
+	values := make([]T, n)
+	for i := range values {
+		values[i] = data[i/8]&(1<<(i%8)) != 0
+	}
+	return values, nil
+}
+
+// EncodeUints writes the n values returned by get as varints.
+func EncodeUints[T Unsigned](b *polyglot.Buffer, n int, get func(i int) T) {
+	c := column()
+	for i := 0; i < n; i++ {
+		appendUvarint(c, uint64(get(i)))
+	}
+	flush(b, c)
+}
+
+func DecodeUints[T Unsigned](d *polyglot.BufferDecoder, n int) ([]T, error) {
+	data, err := read(d)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]T, n)
+	for i := range values {
+		v, size := binary.Uvarint(data)
+		if size <= 0 || v != uint64(T(v)) {
+			return nil, ErrInvalidColumn
+		}
+		values[i], data = T(v), data[size:]
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidColumn
+	}
+	return values, nil
+}
+
+// EncodeInts writes the n values returned by get as zigzag encoded varints.
+func EncodeInts[T Signed](b *polyglot.Buffer, n int, get func(i int) T) {
+	c := column()
+	for i := 0; i < n; i++ {
+		v := int64(get(i))
+		appendUvarint(c, uint64(v<<1)^uint64(v>>63))
+	}
+	flush(b, c)
+}
+
+func DecodeInts[T Signed](d *polyglot.BufferDecoder, n int) ([]T, error) {
+	data, err := read(d)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]T, n)
+	for i := range values {
+		u, size := binary.Uvarint(data)
+		if size <= 0 {
+			return nil, ErrInvalidColumn
+		}
+		v := int64(u>>1) ^ -int64(u&1)
+		if v != int64(T(v)) {
+			return nil, ErrInvalidColumn
+		}
+		values[i], data = T(v), data[size:]
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidColumn
+	}
+	return values, nil
+}
+
+// EncodeFloat32s writes the n values returned by get as little endian
+// IEEE 754 values.
+func EncodeFloat32s[T ~float32](b *polyglot.Buffer, n int, get func(i int) T) {
+	c := column()
+	var scratch [4]byte
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint32(scratch[:], math.Float32bits(float32(get(i))))
+		c.Write(scratch[:])
+	}
+	flush(b, c)
+}
+
+func DecodeFloat32s[T ~float32](d *polyglot.BufferDecoder, n int) ([]T, error) {
+	data, err := read(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 4*n {
+		return nil, ErrInvalidColumn
+	}
+	values := make([]T, n)
+	for i := range values {
+		values[i] = T(math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:])))
+	}
+	return values, nil
+}
+
+// EncodeFloat64s writes the n values returned by get as little endian
+// IEEE 754 values.
+func EncodeFloat64s[T ~float64](b *polyglot.Buffer, n int, get func(i int) T) {
+	c := column()
+	var scratch [8]byte
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(float64(get(i))))
+		c.Write(scratch[:])
+	}
+	flush(b, c)
+}
+
+func DecodeFloat64s[T ~float64](d *polyglot.BufferDecoder, n int) ([]T, error) {
+	data, err := read(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 8*n {
+		return nil, ErrInvalidColumn
+	}
+	values := make([]T, n)
+	for i := range values {
+		values[i] = T(math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:])))
+	}
+	return values, nil
+}
+
+// EncodeStrings writes the n values returned by get as a dictionary of their
+// distinct values, in order of first use, followed by the index of each
+// value in the dictionary.
+func EncodeStrings[T ~string](b *polyglot.Buffer, n int, get func(i int) T) {
+	dictionary := make(map[T]uint64)
+	var distinct []T
+	indexes := make([]uint64, n)
+	for i := range indexes {
+		v := get(i)
+		index, ok := dictionary[v]
+		if !ok {
+			index = uint64(len(distinct))
+			dictionary[v] = index
+			distinct = append(distinct, v)
+		}
+		indexes[i] = index
+	}
+
+	c := column()
+	appendUvarint(c, uint64(len(distinct)))
+	for _, v := range distinct {
+		appendUvarint(c, uint64(len(v)))
+		c.Write([]byte(v))
+	}
+	for _, index := range indexes {
+		appendUvarint(c, index)
+	}
+	flush(b, c)
+}
+
+func DecodeStrings[T ~string](d *polyglot.BufferDecoder, n int) ([]T, error) {
+	data, err := read(d)
+	if err != nil {
+		return nil, err
+	}
+	size, read := binary.Uvarint(data)
+	if read <= 0 || size > uint64(len(data)) {
+		return nil, ErrInvalidColumn
+	}
+	data = data[read:]
+	distinct := make([]T, size)
+	for i := range distinct {
+		length, read := binary.Uvarint(data)
+		if read <= 0 || length > uint64(len(data)-read) {
+			return nil, ErrInvalidColumn
+		}
+		distinct[i] = T(data[read : read+int(length)])
+		data = data[read+int(length):]
+	}
+	values := make([]T, n)
+	for i := range values {
+		index, read := binary.Uvarint(data)
+		if read <= 0 || index >= uint64(len(distinct)) {
+			return nil, ErrInvalidColumn
+		}
+		values[i], data = distinct[index], data[read:]
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidColumn
+	}
+	return values, nil
+}
+
+// EncodeBytes writes the n values returned by get, each prefixed with its
+// length as a varint.
+func EncodeBytes[T ~[]byte](b *polyglot.Buffer, n int, get func(i int) T) {
+	c := column()
+	for i := 0; i < n; i++ {
+		v := get(i)
+		appendUvarint(c, uint64(len(v)))
+		c.Write(v)
+	}
+	flush(b, c)
+}
+
+func DecodeBytes[T ~[]byte](d *polyglot.BufferDecoder, n int) ([]T, error) {
+	data, err := read(d)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]T, n)
+	for i := range values {
+		length, read := binary.Uvarint(data)
+		if read <= 0 || length > uint64(len(data)-read) {
+			return nil, ErrInvalidColumn
+		}
+		end := read + int(length)
+		values[i], data = T(data[read:end:end]), data[end:]
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidColumn
+	}
+	return values, nil
+}
+
+// EncodeValues writes the n values written by encode one after another,
+// for values that have no packed form such as repeated and map fields.
+func EncodeValues(b *polyglot.Buffer, n int, encode func(i int, b *polyglot.Buffer)) {
+	c := column()
+	for i := 0; i < n; i++ {
+		encode(i, c)
+	}
+	flush(b, c)
+}
+
+func DecodeValues(d *polyglot.BufferDecoder, n int, decode func(i int, d *polyglot.BufferDecoder) error) error {
+	data, err := read(d)
+	if err != nil {
+		return err
+	}
+	values := polyglot.Decoder(data)
+	for i := 0; i < n; i++ {
+		if err = decode(i, values); err != nil {
+			return err
+		}
+	}
+	if len(*values) != 0 {
+		return ErrInvalidColumn
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package columnar encodes the values of one field across a batch of
+package columnar
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"math"
+	"testing"
+)
+
+type label string
+
+func TestScalars(t *testing.T) {
+	t.Parallel()
+
+	bools := []bool{true, false, false, true, true, false, true, false, true}
+	uints := []uint64{0, 1, 127, 128, math.MaxUint64}
+	ints := []int32{0, -1, 1, math.MinInt32, math.MaxInt32}
+	f32s := []float32{0, -1.5, float32(math.Inf(1))}
+	f64s := []float64{0, math.Pi, -math.MaxFloat64}
+	labels := []label{"a", "b", "a", "", "a", "b"}
+	data := [][]byte{nil, []byte("x"), []byte("yz")}
+
+	b := polyglot.NewBuffer()
+	EncodeBools(b, len(bools), func(i int) bool { return bools[i] })
+	EncodeUints(b, len(uints), func(i int) uint64 { return uints[i] })
+	EncodeInts(b, len(ints), func(i int) int32 { return ints[i] })
+	EncodeFloat32s(b, len(f32s), func(i int) float32 { return f32s[i] })
+	EncodeFloat64s(b, len(f64s), func(i int) float64 { return f64s[i] })
+	EncodeStrings(b, len(labels), func(i int) label { return labels[i] })
+	EncodeBytes(b, len(data), func(i int) []byte { return data[i] })
+
+	d := polyglot.Decoder(b.Bytes())
+	decodedBools, err := DecodeBools[bool](d, len(bools))
+	require.NoError(t, err)
+	assert.Equal(t, bools, decodedBools)
+	decodedUints, err := DecodeUints[uint64](d, len(uints))
+	require.NoError(t, err)
+	assert.Equal(t, uints, decodedUints)
+	decodedInts, err := DecodeInts[int32](d, len(ints))
+	require.NoError(t, err)
+	assert.Equal(t, ints, decodedInts)
+	decodedF32s, err := DecodeFloat32s[float32](d, len(f32s))
+	require.NoError(t, err)
+	assert.Equal(t, f32s, decodedF32s)
+	decodedF64s, err := DecodeFloat64s[float64](d, len(f64s))
+	require.NoError(t, err)
+	assert.Equal(t, f64s, decodedF64s)
+	decodedLabels, err := DecodeStrings[label](d, len(labels))
+	require.NoError(t, err)
+	assert.Equal(t, labels, decodedLabels)
+	decodedData, err := DecodeBytes[[]byte](d, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{}, decodedData[0])
+	assert.Equal(t, data[1:], decodedData[1:])
+	assert.Empty(t, *d)
+}
+
+func TestStringDictionary(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	EncodeStrings(b, 1000, func(i int) string { return "repeated value" })
+	assert.Less(t, b.Len(), 1100)
+}
+
+func TestValues(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]string{{"a"}, nil, {"b", "c"}}
+	b := polyglot.NewBuffer()
+	EncodeValues(b, len(rows), func(i int, b *polyglot.Buffer) {
+		polyglot.Encoder(b).Slice(uint32(len(rows[i])), polyglot.StringKind)
+		for _, v := range rows[i] {
+			polyglot.Encoder(b).String(v)
+		}
+	})
+
+	decoded := make([][]string, len(rows))
+	err := DecodeValues(polyglot.Decoder(b.Bytes()), len(rows), func(i int, d *polyglot.BufferDecoder) error {
+		size, err := d.Slice(polyglot.StringKind)
+		if err != nil {
+			return err
+		}
+		for j := uint32(0); j < size; j++ {
+			v, err := d.String()
+			if err != nil {
+				return err
+			}
+			decoded[i] = append(decoded[i], v)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rows, decoded)
+
+	err = DecodeValues(polyglot.Decoder(b.Bytes()), 2, func(i int, d *polyglot.BufferDecoder) error {
+		return d.Skip()
+	})
+	assert.ErrorIs(t, err, ErrInvalidColumn)
+}
+
+func TestInvalid(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	EncodeUints(b, 3, func(i int) uint32 { return math.MaxUint32 })
+	_, err := DecodeUints[uint32](polyglot.Decoder(b.Bytes()), 2)
+	assert.ErrorIs(t, err, ErrInvalidColumn)
+	_, err = DecodeUints[uint32](polyglot.Decoder(b.Bytes()), 4)
+	assert.ErrorIs(t, err, ErrInvalidColumn)
+	_, err = DecodeUints[uint16](polyglot.Decoder(b.Bytes()), 3)
+	assert.ErrorIs(t, err, ErrInvalidColumn)
+
+	b.Reset()
+	EncodeBools(b, 9, func(i int) bool { return true })
+	_, err = DecodeBools[bool](polyglot.Decoder(b.Bytes()), 17)
+	assert.ErrorIs(t, err, ErrInvalidColumn)
+
+	b.Reset()
+	EncodeStrings(b, 2, func(i int) string { return "a" })
+	data := b.Bytes()
+	data[len(data)-1] = 5
+	_, err = DecodeStrings[string](polyglot.Decoder(data), 2)
+	assert.ErrorIs(t, err, ErrInvalidColumn)
+
+	_, err = DecodeFloat64s[float64](polyglot.Decoder(nil), 1)
+	assert.Error(t, err)
+
+	assert.False(t, Any(nil))
+	assert.True(t, Any([]bool{false, true}))
+}
@@ -0,0 +1,192 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package compression wraps encoded polyglot buffers in an envelope that
+// records how they were compressed, so they can be decompressed without any
+// other context.
+//
+// An envelope is a kind byte naming the compression algorithm, the size of
+// the uncompressed data as an unsigned varint, and the compressed data.
+// Data smaller than the threshold of an Envelope, or that does not shrink
+// when compressed, is stored with the None kind.
+package compression
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrUnknownKind     = errors.New("unknown compression kind")
+	ErrDuplicateKind   = errors.New("compression kind is already registered")
+	ErrReservedKind    = errors.New("compression kind is reserved")
+	ErrInvalidEnvelope = errors.New("invalid compression envelope")
+	ErrTooLarge        = errors.New("uncompressed size exceeds the limit")
+	ErrCorrupt         = errors.New("corrupt compressed data")
+)
+
+const (
+	// DefaultThreshold is the size below which NewEnvelope stores data
+	// uncompressed.
+	DefaultThreshold = 256
+
+	// DefaultMaxSize is the largest uncompressed size Open accepts.
+	DefaultMaxSize = 64 << 20
+)
+
+// Kind identifies a compression algorithm in an envelope. Kinds below 64 are
+// reserved for this package, and Register rejects them.
+type Kind uint8
+
+const (
+	reservedKinds = 64
+)
+
+const (
+	None Kind = iota
+	Flate
+	Gzip
+	Zlib
+)
+
+// Codec compresses and decompresses data for a single Kind. Codecs must be
+// safe for concurrent use.
+type Codec interface {
+	Kind() Kind
+
+	// Compress appends the compressed form of src to dst.
+	Compress(dst []byte, src []byte) ([]byte, error)
+
+	// Decompress appends the decompressed form of src to dst. size is the
+	// size of the decompressed data, and any other size is ErrCorrupt.
+	Decompress(dst []byte, src []byte, size int) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[Kind]Codec)
+)
+
+func init() {
+	for _, c := range []Codec{newFlate(defaultLevel), newGzip(defaultLevel), newZlib(defaultLevel)} {
+		if err := register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register makes a codec available to Open and Lookup, for example to add
+// zstd or snappy under a Kind of 64 or above. Kinds below 64 return
+// ErrReservedKind, so the codecs of this package cannot be replaced.
+func Register(c Codec) error {
+	if c.Kind() < reservedKinds {
+		return ErrReservedKind
+	}
+	return register(c)
+}
+
+func register(c Codec) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[c.Kind()]; ok || c.Kind() == None {
+		return ErrDuplicateKind
+	}
+	codecs[c.Kind()] = c
+	return nil
+}
+
+// Lookup returns the registered codec for a kind.
+func Lookup(kind Kind) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[kind]
+	return c, ok
+}
+
+// Envelope seals data with a codec.
+type Envelope struct {
+	codec     Codec
+	threshold int
+}
+
+// NewEnvelope returns an Envelope that compresses data of at least threshold
+// bytes with codec. A nil codec stores all data uncompressed.
+func NewEnvelope(codec Codec, threshold int) *Envelope {
+	return &Envelope{
+		codec:     codec,
+		threshold: threshold,
+	}
+}
+
+// Seal appends the envelope of src to dst.
+func (e *Envelope) Seal(dst []byte, src []byte) ([]byte, error) {
+	if e.codec == nil || len(src) < e.threshold {
+		return seal(dst, None, src), nil
+	}
+	start := len(dst)
+	dst = append(dst, byte(e.codec.Kind()))
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	header := len(dst)
+	dst, err := e.codec.Compress(dst, src)
+	if err != nil {
+		return dst[:start], err
+	}
+	if len(dst)-header >= len(src) {
+		return seal(dst[:start], None, src), nil
+	}
+	return dst, nil
+}
+
+func seal(dst []byte, kind Kind, src []byte) []byte {
+	dst = append(dst, byte(kind))
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	return append(dst, src...)
+}
+
+// Open appends the data sealed in src to dst, accepting at most
+// DefaultMaxSize bytes of uncompressed data.
+func Open(dst []byte, src []byte) ([]byte, error) {
+	return OpenMax(dst, src, DefaultMaxSize)
+}
+
+// OpenMax is like Open, but accepts at most maxSize bytes of uncompressed
+// data.
+func OpenMax(dst []byte, src []byte, maxSize int) ([]byte, error) {
+	if len(src) == 0 {
+		return dst, ErrInvalidEnvelope
+	}
+	kind := Kind(src[0])
+	size, n := binary.Uvarint(src[1:])
+	if n <= 0 {
+		return dst, ErrInvalidEnvelope
+	}
+	if size > uint64(maxSize) {
+		return dst, ErrTooLarge
+	}
+	payload := src[1+n:]
+	if kind == None {
+		if uint64(len(payload)) != size {
+			return dst, ErrCorrupt
+		}
+		return append(dst, payload...), nil
+	}
+	c, ok := Lookup(kind)
+	if !ok {
+		return dst, ErrUnknownKind
+	}
+	return c.Decompress(dst, payload, int(size))
+}
@@ -0,0 +1,205 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package compression
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// reverse is a trivial codec used to test registering codecs.
+type reverse struct{}
+
+func (reverse) Kind() Kind {
+	return 64
+}
+
+func (reverse) Compress(dst []byte, src []byte) ([]byte, error) {
+	for i := len(src) - 1; i >= 0; i-- {
+		if i > 0 && src[i] == src[i-1] {
+			continue
+		}
+		dst = append(dst, src[i])
+	}
+	return dst, nil
+}
+
+func (reverse) Decompress(dst []byte, src []byte, size int) ([]byte, error) {
+	if len(src) != 1 || size < 1 {
+		return dst, ErrCorrupt
+	}
+	return append(dst, bytes.Repeat(src, size)...), nil
+}
+
+// reserved is a codec with a kind reserved for this package.
+type reserved struct {
+	reverse
+}
+
+func (reserved) Kind() Kind {
+	return 10
+}
+
+func testData() []byte {
+	b := polyglot.NewBuffer()
+	for i := 0; i < 100; i++ {
+		polyglot.Encoder(b).String(strings.Repeat("polyglot ", 10)).Uint32(uint32(i))
+	}
+	return b.Bytes()
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	data := testData()
+	for _, kind := range []Kind{Flate, Gzip, Zlib} {
+		c, ok := Lookup(kind)
+		require.True(t, ok)
+
+		sealed, err := NewEnvelope(c, DefaultThreshold).Seal([]byte("prefix"), data)
+		require.NoError(t, err)
+		require.Equal(t, []byte("prefix"), sealed[:6])
+		sealed = sealed[6:]
+		assert.Equal(t, byte(kind), sealed[0])
+		assert.Less(t, len(sealed), len(data)/5)
+
+		opened, err := Open(nil, sealed)
+		require.NoError(t, err)
+		assert.Equal(t, data, opened)
+
+		_, err = Open(nil, sealed[:len(sealed)-4])
+		assert.ErrorIs(t, err, ErrCorrupt)
+		_, err = OpenMax(nil, sealed, len(data)-1)
+		assert.ErrorIs(t, err, ErrTooLarge)
+	}
+
+	levels := []func(int) (Codec, error){NewFlate, NewGzip, NewZlib}
+	for _, level := range levels {
+		c, err := level(flate.BestSpeed)
+		require.NoError(t, err)
+		sealed, err := NewEnvelope(c, 0).Seal(nil, data)
+		require.NoError(t, err)
+		opened, err := Open(nil, sealed)
+		require.NoError(t, err)
+		assert.Equal(t, data, opened)
+
+		_, err = level(42)
+		assert.Error(t, err)
+	}
+}
+
+func TestEnvelopeUncompressed(t *testing.T) {
+	t.Parallel()
+
+	c, _ := Lookup(Gzip)
+	small := []byte("small")
+	sealed, err := NewEnvelope(c, DefaultThreshold).Seal(nil, small)
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{byte(None), 5}, small...), sealed)
+
+	random := make([]byte, 1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	sealed, err = NewEnvelope(c, 0).Seal(nil, random)
+	require.NoError(t, err)
+	assert.Equal(t, byte(None), sealed[0])
+	opened, err := Open(nil, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, random, opened)
+
+	sealed, err = NewEnvelope(nil, 0).Seal(nil, testData())
+	require.NoError(t, err)
+	assert.Equal(t, byte(None), sealed[0])
+
+	for _, invalid := range [][]byte{nil, {byte(None)}, {byte(None), 0x80}} {
+		_, err = Open(nil, invalid)
+		assert.ErrorIs(t, err, ErrInvalidEnvelope)
+	}
+	_, err = Open(nil, []byte{byte(None), 2, 1})
+	assert.ErrorIs(t, err, ErrCorrupt)
+	_, err = Open(nil, []byte{200, 1, 1})
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, Register(reverse{}))
+	assert.ErrorIs(t, Register(reverse{}), ErrDuplicateKind)
+	c, _ := Lookup(Flate)
+	assert.ErrorIs(t, Register(c), ErrReservedKind)
+	assert.ErrorIs(t, Register(reserved{}), ErrReservedKind)
+	_, ok := Lookup(reserved{}.Kind())
+	assert.False(t, ok)
+
+	data := bytes.Repeat([]byte{'a'}, 512)
+	sealed, err := NewEnvelope(reverse{}, DefaultThreshold).Seal(nil, data)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{64, 0x80, 0x04, 'a'}, sealed)
+	opened, err := Open(nil, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, data, opened)
+}
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+
+	data := testData()
+	c, _ := Lookup(Zlib)
+	e := NewEnvelope(c, DefaultThreshold)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				sealed, err := e.Seal(nil, data)
+				assert.NoError(t, err)
+				opened, err := Open(nil, sealed)
+				assert.NoError(t, err)
+				assert.Equal(t, data, opened)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestOpenClaimedSize is not parallel, so that the memory it allocates can be
+// measured.
+func TestOpenClaimedSize(t *testing.T) {
+	c, _ := Lookup(Flate)
+	compressed, err := c.Compress(nil, []byte("polyglot"))
+	require.NoError(t, err)
+	sealed := append([]byte{byte(Flate)}, binary.AppendUvarint(nil, DefaultMaxSize)...)
+	sealed = append(sealed, compressed...)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = Open(nil, sealed)
+	runtime.ReadMemStats(&after)
+	assert.ErrorIs(t, err, ErrCorrupt)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+}
@@ -0,0 +1,202 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"slices"
+	"sync"
+)
+
+const (
+	defaultLevel = flate.DefaultCompression
+
+	// expansionGuess is the ratio of decompressed to compressed size that
+	// Decompress allocates for up front.
+	expansionGuess = 4
+)
+
+// writer is implemented by the flate, gzip and zlib writers.
+type writer interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// appender is an io.Writer that appends to a byte slice.
+type appender struct {
+	b []byte
+}
+
+func (a *appender) Write(p []byte) (int, error) {
+	a.b = append(a.b, p...)
+	return len(p), nil
+}
+
+// stream is a Codec backed by the streaming compressors of the standard
+// library, whose writers and readers are pooled.
+type stream struct {
+	kind      Kind
+	newWriter func(w io.Writer) (writer, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+	reset     func(rc io.ReadCloser, r io.Reader) error
+
+	writers sync.Pool
+	readers sync.Pool
+}
+
+// NewFlate returns a raw DEFLATE codec that compresses at the given level.
+func NewFlate(level int) (Codec, error) {
+	if _, err := flate.NewWriter(nil, level); err != nil {
+		return nil, err
+	}
+	return newFlate(level), nil
+}
+
+// NewGzip returns a gzip codec that compresses at the given level.
+func NewGzip(level int) (Codec, error) {
+	if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+		return nil, err
+	}
+	return newGzip(level), nil
+}
+
+// NewZlib returns a zlib codec that compresses at the given level.
+func NewZlib(level int) (Codec, error) {
+	if _, err := zlib.NewWriterLevel(nil, level); err != nil {
+		return nil, err
+	}
+	return newZlib(level), nil
+}
+
+func newFlate(level int) *stream {
+	return &stream{
+		kind: Flate,
+		newWriter: func(w io.Writer) (writer, error) {
+			return flate.NewWriter(w, level)
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return flate.NewReader(r), nil
+		},
+		reset: func(rc io.ReadCloser, r io.Reader) error {
+			return rc.(flate.Resetter).Reset(r, nil)
+		},
+	}
+}
+
+func newGzip(level int) *stream {
+	return &stream{
+		kind: Gzip,
+		newWriter: func(w io.Writer) (writer, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		reset: func(rc io.ReadCloser, r io.Reader) error {
+			return rc.(*gzip.Reader).Reset(r)
+		},
+	}
+}
+
+func newZlib(level int) *stream {
+	return &stream{
+		kind: Zlib,
+		newWriter: func(w io.Writer) (writer, error) {
+			return zlib.NewWriterLevel(w, level)
+		},
+		newReader: zlib.NewReader,
+		reset: func(rc io.ReadCloser, r io.Reader) error {
+			return rc.(zlib.Resetter).Reset(r, nil)
+		},
+	}
+}
+
+func (s *stream) Kind() Kind {
+	return s.kind
+}
+
+func (s *stream) Compress(dst []byte, src []byte) ([]byte, error) {
+	a := &appender{b: dst}
+	var w writer
+	if v := s.writers.Get(); v != nil {
+		w = v.(writer)
+		w.Reset(a)
+	} else {
+		var err error
+		if w, err = s.newWriter(a); err != nil {
+			return dst, err
+		}
+	}
+	if _, err := w.Write(src); err != nil {
+		return dst, err
+	}
+	if err := w.Close(); err != nil {
+		return dst, err
+	}
+	w.Reset(nil)
+	s.writers.Put(w)
+	return a.b, nil
+}
+
+func (s *stream) Decompress(dst []byte, src []byte, size int) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	if v := s.readers.Get(); v != nil {
+		r = v.(io.ReadCloser)
+		err = s.reset(r, bytes.NewReader(src))
+	} else {
+		r, err = s.newReader(bytes.NewReader(src))
+	}
+	if err != nil {
+		return dst, ErrCorrupt
+	}
+
+	// The size comes from the envelope, so it only limits how much is read.
+	// dst grows as data is decompressed, starting from a guess based on the
+	// compressed size, so a small envelope claiming a large size cannot
+	// force a large allocation.
+	start := len(dst)
+	dst = slices.Grow(dst, min(size, len(src)*expansionGuess))
+	for len(dst)-start < size {
+		if len(dst) == cap(dst) {
+			dst = append(dst, 0)[:len(dst)]
+		}
+		var n int
+		n, err = r.Read(dst[len(dst):min(cap(dst), start+size)])
+		dst = dst[:len(dst)+n]
+		if err == io.EOF && len(dst)-start < size {
+			return dst[:start], ErrCorrupt
+		}
+		if err != nil && err != io.EOF {
+			return dst[:start], ErrCorrupt
+		}
+	}
+	// The data must end exactly at the recorded size.
+	var extra [1]byte
+	if n, err := r.Read(extra[:]); n != 0 || err != io.EOF {
+		return dst[:start], ErrCorrupt
+	}
+	if err = r.Close(); err != nil {
+		return dst[:start], ErrCorrupt
+	}
+	s.readers.Put(r)
+	return dst, nil
+}
@@ -97,3 +97,10 @@ func (d *BufferDecoder) Float64() (value float64, err error) {
 	*d, value, err = decodeFloat64(*d)
 	return
 }
+
+// Skip moves past the next value without decoding it. Like Extent, it
+// cannot skip messages or slices and maps of messages.
+func (d *BufferDecoder) Skip() (err error) {
+	*d, err = skip(*d)
+	return
+}
@@ -0,0 +1,190 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"errors"
+)
+
+var (
+	ErrDecodeNil = errors.New("cannot decode into a nil descriptor")
+)
+
+// Encode writes the descriptor using polyglot, which is the compact form
+// embedded in generated code.
+func (x *File) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(x.Path).String(x.Package).Slice(uint32(len(x.Messages)), polyglot.AnyKind)
+	for _, m := range x.Messages {
+		m.encode(b)
+	}
+	polyglot.Encoder(b).Slice(uint32(len(x.Enums)), polyglot.AnyKind)
+	for _, e := range x.Enums {
+		e.encode(b)
+	}
+}
+
+func (x *File) Decode(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	d := polyglot.Decoder(b)
+
+	var err error
+	x.Path, err = d.String()
+	if err != nil {
+		return err
+	}
+	x.Package, err = d.String()
+	if err != nil {
+		return err
+	}
+
+	size, err := d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	x.Messages = make([]*Message, size)
+	for i := range x.Messages {
+		x.Messages[i] = new(Message)
+		if err = x.Messages[i].decode(d); err != nil {
+			return err
+		}
+	}
+
+	size, err = d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	x.Enums = make([]*Enum, size)
+	for i := range x.Enums {
+		x.Enums[i] = new(Enum)
+		if err = x.Enums[i].decode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *Message) encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(x.FullName).Slice(uint32(len(x.Fields)), polyglot.AnyKind)
+	for _, f := range x.Fields {
+		f.encode(b)
+	}
+}
+
+func (x *Message) decode(d *polyglot.BufferDecoder) error {
+	var err error
+	x.FullName, err = d.String()
+	if err != nil {
+		return err
+	}
+	size, err := d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	x.Fields = make([]*Field, size)
+	for i := range x.Fields {
+		x.Fields[i] = new(Field)
+		if err = x.Fields[i].decode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *Field) encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(x.Name).String(x.JSONName).Int32(x.Number).Uint8(uint8(x.Kind))
+	x.Key.encode(b)
+	x.Value.encode(b)
+}
+
+func (x *Field) decode(d *polyglot.BufferDecoder) error {
+	var err error
+	x.Name, err = d.String()
+	if err != nil {
+		return err
+	}
+	x.JSONName, err = d.String()
+	if err != nil {
+		return err
+	}
+	x.Number, err = d.Int32()
+	if err != nil {
+		return err
+	}
+	kind, err := d.Uint8()
+	if err != nil {
+		return err
+	}
+	x.Kind = polyglot.Kind(kind)
+	if err = x.Key.decode(d); err != nil {
+		return err
+	}
+	return x.Value.decode(d)
+}
+
+func (x *Type) encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).Uint8(uint8(x.Kind)).String(x.Message).String(x.Enum)
+}
+
+func (x *Type) decode(d *polyglot.BufferDecoder) error {
+	kind, err := d.Uint8()
+	if err != nil {
+		return err
+	}
+	x.Kind = polyglot.Kind(kind)
+	x.Message, err = d.String()
+	if err != nil {
+		return err
+	}
+	x.Enum, err = d.String()
+	return err
+}
+
+func (x *Enum) encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(x.FullName).Slice(uint32(len(x.Values)), polyglot.AnyKind)
+	for _, v := range x.Values {
+		polyglot.Encoder(b).String(v.Name).Int32(v.Number)
+	}
+}
+
+func (x *Enum) decode(d *polyglot.BufferDecoder) error {
+	var err error
+	x.FullName, err = d.String()
+	if err != nil {
+		return err
+	}
+	size, err := d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	x.Values = make([]*EnumValue, size)
+	for i := range x.Values {
+		x.Values[i] = new(EnumValue)
+		x.Values[i].Name, err = d.String()
+		if err != nil {
+			return err
+		}
+		x.Values[i].Number, err = d.Int32()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"fmt"
+	"sort"
+)
+
+var (
+	kindNames = map[polyglot.Kind]string{
+		polyglot.NilKind:     "nil",
+		polyglot.SliceKind:   "slice",
+		polyglot.MapKind:     "map",
+		polyglot.AnyKind:     "any",
+		polyglot.BytesKind:   "bytes",
+		polyglot.StringKind:  "string",
+		polyglot.BoolKind:    "bool",
+		polyglot.Uint8Kind:   "uint8",
+		polyglot.Uint16Kind:  "uint16",
+		polyglot.Uint32Kind:  "uint32",
+		polyglot.Uint64Kind:  "uint64",
+		polyglot.Int32Kind:   "int32",
+		polyglot.Int64Kind:   "int64",
+		polyglot.Float32Kind: "float32",
+		polyglot.Float64Kind: "float64",
+	}
+)
+
+// Incompatibility is a difference between two versions of a schema that
+// stops data encoded with one version from decoding correctly with the
+// other.
+type Incompatibility struct {
+	Name   string
+	Reason string
+}
+
+func (i Incompatibility) String() string {
+	return fmt.Sprintf("%s: %s", i.Name, i.Reason)
+}
+
+func (r *Registry) messageNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.messages))
+	for name := range r.messages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) enumNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.enums))
+	for name := range r.enums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compare returns the incompatibilities between the messages and enums of
+// from and the ones with the same names in to, sorted by name.
+//
+// Fields are encoded by position, so a message is incompatible as soon as
+// the field at some position of its encoding order changes type, and when
+// fields are added or removed. Renaming or renumbering fields is compatible.
+// Only the first difference of each message is reported, because every
+// field after it is read from the wrong position. Enum values are encoded as
+// the position of their declaration, so removing a value or moving it to
+// another position, for example by inserting a value before it, is
+// incompatible, while renumbering values and appending new ones is not.
+func Compare(from *Registry, to *Registry) []Incompatibility {
+	var incompatibilities []Incompatibility
+	report := func(name string, reason string, args ...interface{}) {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			Name:   name,
+			Reason: fmt.Sprintf(reason, args...),
+		})
+	}
+
+	for _, name := range from.messageNames() {
+		old, _ := from.FindMessage(name)
+		updated, ok := to.FindMessage(name)
+		if !ok {
+			report(name, "message was removed")
+			continue
+		}
+		oldFields, updatedFields := old.EncodingOrder(), updated.EncodingOrder()
+		i := 0
+		for i < len(oldFields) && i < len(updatedFields) && sameType(from, oldFields[i], to, updatedFields[i]) {
+			i++
+		}
+		switch {
+		case i < len(oldFields) && i < len(updatedFields):
+			report(fieldName(old, oldFields[i]), "field at position %d changed from %s to %s", i, typeName(oldFields[i]), typeName(updatedFields[i]))
+		case i < len(oldFields):
+			report(fieldName(old, oldFields[i]), "field at position %d was removed", i)
+		case i < len(updatedFields):
+			report(fieldName(updated, updatedFields[i]), "field was added at position %d", i)
+		}
+	}
+
+	for _, name := range from.enumNames() {
+		old, _ := from.FindEnum(name)
+		updated, ok := to.FindEnum(name)
+		if !ok {
+			report(name, "enum was removed")
+			continue
+		}
+		positions := make(map[string]int, len(updated.Values))
+		for i, value := range updated.Values {
+			positions[value.Name] = i
+		}
+		for i, value := range old.Values {
+			position, ok := positions[value.Name]
+			switch {
+			case !ok:
+				report(name, "value %s was removed", value.Name)
+			case position != i:
+				report(name, "value %s moved from position %d to %d", value.Name, i, position)
+			}
+		}
+	}
+
+	sort.SliceStable(incompatibilities, func(i, j int) bool {
+		return incompatibilities[i].Name < incompatibilities[j].Name
+	})
+	return incompatibilities
+}
+
+func fieldName(m *Message, f *Field) string {
+	return fmt.Sprintf("%s.%s", m.FullName, f.Name)
+}
+
+// sameType reports whether two fields have the same wire type. Nested
+// messages with the same name are compared on their own, and nested
+// messages with different names must have the same fingerprint.
+func sameType(from *Registry, old *Field, to *Registry, updated *Field) bool {
+	if old.Kind != updated.Kind {
+		return false
+	}
+	if old.IsMap() && !sameValueType(from, old.Key, to, updated.Key) {
+		return false
+	}
+	return sameValueType(from, old.Value, to, updated.Value)
+}
+
+func sameValueType(from *Registry, old Type, to *Registry, updated Type) bool {
+	if old.Kind != updated.Kind || old.IsMessage() != updated.IsMessage() {
+		return false
+	}
+	if !old.IsMessage() || old.Message == updated.Message {
+		return true
+	}
+	oldFingerprint, err := from.Fingerprint(old.Message)
+	if err != nil {
+		return false
+	}
+	updatedFingerprint, err := to.Fingerprint(updated.Message)
+	return err == nil && oldFingerprint == updatedFingerprint
+}
+
+func typeName(f *Field) string {
+	switch {
+	case f.IsMap():
+		return fmt.Sprintf("map<%s, %s>", valueTypeName(f.Key), valueTypeName(f.Value))
+	case f.IsRepeated():
+		return fmt.Sprintf("repeated %s", valueTypeName(f.Value))
+	default:
+		return valueTypeName(f.Value)
+	}
+}
+
+func valueTypeName(t Type) string {
+	switch {
+	case t.IsMessage():
+		return t.Message
+	case t.IsEnum():
+		return t.Enum
+	default:
+		return kindNames[t.Kind]
+	}
+}
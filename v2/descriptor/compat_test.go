@@ -0,0 +1,100 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func compare(t *testing.T, from *File, to *File) []string {
+	fromRegistry, toRegistry := NewRegistry(), NewRegistry()
+	require.NoError(t, fromRegistry.Register(from))
+	require.NoError(t, toRegistry.Register(to))
+	var incompatibilities []string
+	for _, incompatibility := range Compare(fromRegistry, toRegistry) {
+		incompatibilities = append(incompatibilities, incompatibility.String())
+	}
+	return incompatibilities
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	from := testFile("test.proto", "test")
+	assert.Empty(t, compare(t, from, testFile("test.proto", "test")))
+
+	renamed := testFile("test.proto", "test")
+	renamed.Messages[0].Fields[1].Name = "labels"
+	renamed.Messages[0].Fields[1].Number = 9
+	assert.Empty(t, compare(t, from, renamed))
+
+	// Enum values are encoded by position, so renumbering them and
+	// appending new ones is compatible.
+	renumbered := testFile("test.proto", "test")
+	renumbered.Enums[0].Values = []*EnumValue{{Name: "STATUS_UNKNOWN", Number: 0}, {Name: "STATUS_OK", Number: 1}, {Name: "STATUS_NEW", Number: 2}}
+	assert.Empty(t, compare(t, from, renumbered))
+
+	to := testFile("test.proto", "test")
+	to.Messages[0].Fields[0].Kind = polyglot.Int32Kind
+	to.Messages[0].Fields[0].Value.Kind = polyglot.Int32Kind
+	to.Messages[1].Fields = []*Field{{Name: "name", Kind: polyglot.StringKind, Value: Type{Kind: polyglot.StringKind}}}
+	// Inserting a value moves every value declared after it, even though
+	// their numbers stay the same.
+	to.Enums[0].Values = []*EnumValue{{Name: "STATUS_UNKNOWN", Number: 0}, {Name: "STATUS_NEW", Number: 1}, {Name: "STATUS_OK", Number: 2}}
+	assert.Equal(t, []string{
+		"test.Label.name: field was added at position 0",
+		"test.Request.user_id: field at position 0 changed from int64 to int32",
+		"test.Status: value STATUS_OK moved from position 1 to 2",
+	}, compare(t, from, to))
+
+	assert.Equal(t, []string{
+		"test.Label.name: field at position 0 was removed",
+		"test.Request.user_id: field at position 0 changed from int32 to int64",
+		"test.Status: value STATUS_NEW was removed",
+		"test.Status: value STATUS_OK moved from position 2 to 1",
+	}, compare(t, to, from))
+
+	removed := testFile("test.proto", "test")
+	removed.Messages = removed.Messages[:1]
+	removed.Messages[0].Fields = removed.Messages[0].Fields[:3]
+	removed.Enums = nil
+	assert.Equal(t, []string{
+		"test.Label: message was removed",
+		"test.Request.labels: field at position 3 was removed",
+		"test.Status: enum was removed",
+	}, compare(t, from, removed))
+}
+
+func TestCompareRenamedMessage(t *testing.T) {
+	t.Parallel()
+
+	from := testFile("test.proto", "test")
+	to := testFile("test.proto", "test")
+	to.Messages[1].FullName = "test.Tag"
+	to.Messages[0].Fields[3].Value.Message = "test.Tag"
+	assert.Equal(t, []string{"test.Label: message was removed"}, compare(t, from, to))
+
+	to.Messages[1].Fields = []*Field{{Name: "name", Kind: polyglot.StringKind, Value: Type{Kind: polyglot.StringKind}}}
+	assert.Equal(t, []string{
+		"test.Label: message was removed",
+		"test.Request.labels: field at position 3 changed from map<string, test.Label> to map<string, test.Tag>",
+	}, compare(t, from, to))
+}
@@ -0,0 +1,150 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package descriptor contains the runtime schema information embedded in
+// generated polyglot code, and a global registry to look it up by full name.
+package descriptor
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+)
+
+// File describes a single generated .proto file.
+type File struct {
+	Path     string
+	Package  string
+	Messages []*Message
+	Enums    []*Enum
+}
+
+// Message describes a generated message. Nested messages are listed
+// separately in their File, and Fields are in declaration order.
+type Message struct {
+	FullName string
+	Fields   []*Field
+}
+
+// Field describes a single field of a message.
+//
+// Kind is polyglot.SliceKind for repeated fields, polyglot.MapKind for map
+// fields and Value.Kind otherwise. Value describes the element type of
+// repeated fields and the value type of maps, and Key is only set for maps.
+type Field struct {
+	Name     string
+	JSONName string
+	Number   int32
+	Kind     polyglot.Kind
+	Key      Type
+	Value    Type
+}
+
+// Type describes the type of a value. Messages have polyglot.AnyKind and
+// a Message name, and enums have polyglot.Uint32Kind and an Enum name.
+type Type struct {
+	Kind    polyglot.Kind
+	Message string
+	Enum    string
+}
+
+// Enum describes a generated enum.
+type Enum struct {
+	FullName string
+	Values   []*EnumValue
+}
+
+type EnumValue struct {
+	Name   string
+	Number int32
+}
+
+func (f *Field) IsRepeated() bool {
+	return f.Kind == polyglot.SliceKind
+}
+
+func (f *Field) IsMap() bool {
+	return f.Kind == polyglot.MapKind
+}
+
+func (t Type) IsMessage() bool {
+	return t.Message != ""
+}
+
+func (t Type) IsEnum() bool {
+	return t.Enum != ""
+}
+
+// FieldByName returns the field with the given proto or JSON name, or nil.
+func (m *Message) FieldByName(name string) *Field {
+	for _, f := range m.Fields {
+		if f.Name == name || f.JSONName == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// EncodingOrder returns the fields in the order the generated code writes
+// them: scalar and enum fields first, then repeated fields, and finally
+// message and map fields, each group in declaration order.
+func (m *Message) EncodingOrder() []*Field {
+	fields := make([]*Field, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		if !f.IsRepeated() && !f.IsMap() && !f.Value.IsMessage() {
+			fields = append(fields, f)
+		}
+	}
+	for _, f := range m.Fields {
+		if f.IsRepeated() {
+			fields = append(fields, f)
+		}
+	}
+	for _, f := range m.Fields {
+		if f.IsMap() || (!f.IsRepeated() && f.Value.IsMessage()) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// FieldByNumber returns the field with the given number, or nil.
+func (m *Message) FieldByNumber(number int32) *Field {
+	for _, f := range m.Fields {
+		if f.Number == number {
+			return f
+		}
+	}
+	return nil
+}
+
+// ValueByName returns the enum value with the given name, or nil.
+func (e *Enum) ValueByName(name string) *EnumValue {
+	for _, v := range e.Values {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// ValueByNumber returns the first enum value with the given number, or nil.
+func (e *Enum) ValueByNumber(number int32) *EnumValue {
+	for _, v := range e.Values {
+		if v.Number == number {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func testFile(path string, prefix string) *File {
+	return &File{
+		Path:    path,
+		Package: prefix,
+		Messages: []*Message{
+			{
+				FullName: prefix + ".Request",
+				Fields: []*Field{
+					{Name: "user_id", JSONName: "userId", Number: 1, Kind: polyglot.Int64Kind, Value: Type{Kind: polyglot.Int64Kind}},
+					{Name: "tags", JSONName: "tags", Number: 2, Kind: polyglot.SliceKind, Value: Type{Kind: polyglot.StringKind}},
+					{Name: "status", JSONName: "status", Number: 3, Kind: polyglot.Uint32Kind, Value: Type{Kind: polyglot.Uint32Kind, Enum: prefix + ".Status"}},
+					{Name: "labels", JSONName: "labels", Number: 4, Kind: polyglot.MapKind, Key: Type{Kind: polyglot.StringKind}, Value: Type{Kind: polyglot.AnyKind, Message: prefix + ".Label"}},
+				},
+			},
+			{
+				FullName: prefix + ".Label",
+				Fields:   []*Field{},
+			},
+		},
+		Enums: []*Enum{
+			{
+				FullName: prefix + ".Status",
+				Values:   []*EnumValue{{Name: "STATUS_UNKNOWN", Number: 0}, {Name: "STATUS_OK", Number: 2}},
+			},
+		},
+	}
+}
+
+func TestFileCodec(t *testing.T) {
+	t.Parallel()
+
+	f := testFile("test.proto", "test")
+	b := polyglot.NewBuffer()
+	f.Encode(b)
+
+	decoded := new(File)
+	require.NoError(t, decoded.Decode(b.Bytes()))
+	assert.Equal(t, f, decoded)
+
+	assert.Error(t, decoded.Decode(b.Bytes()[:b.Len()-1]))
+	assert.ErrorIs(t, (*File)(nil).Decode(b.Bytes()), ErrDecodeNil)
+}
+
+func TestLookups(t *testing.T) {
+	t.Parallel()
+
+	f := testFile("test.proto", "test")
+	m := f.Messages[0]
+
+	assert.Same(t, m.Fields[0], m.FieldByName("user_id"))
+	assert.Same(t, m.Fields[0], m.FieldByName("userId"))
+	assert.Same(t, m.Fields[3], m.FieldByNumber(4))
+	assert.Nil(t, m.FieldByName("missing"))
+	assert.Nil(t, m.FieldByNumber(5))
+
+	assert.True(t, m.Fields[1].IsRepeated())
+	assert.True(t, m.Fields[3].IsMap())
+	assert.True(t, m.Fields[3].Value.IsMessage())
+	assert.True(t, m.Fields[2].Value.IsEnum())
+	assert.False(t, m.Fields[0].Value.IsMessage())
+	assert.Equal(t, []*Field{m.Fields[0], m.Fields[2], m.Fields[1], m.Fields[3]}, m.EncodingOrder())
+
+	e := f.Enums[0]
+	assert.Equal(t, int32(2), e.ValueByName("STATUS_OK").Number)
+	assert.Equal(t, "STATUS_UNKNOWN", e.ValueByNumber(0).Name)
+	assert.Nil(t, e.ValueByNumber(1))
+}
@@ -0,0 +1,105 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+var (
+	ErrUnknownMessage = errors.New("unknown message")
+)
+
+// fingerprinter writes the canonical form of a message schema: the wire kind
+// of every field in encoding order, with nested messages written in place
+// the first time they are reached and as a back reference after that. Names
+// and field numbers are left out because the positional encoding does not
+// depend on them.
+type fingerprinter struct {
+	registry *Registry
+	seen     map[string]uint64
+	b        []byte
+}
+
+func (f *fingerprinter) message(fullName string) error {
+	if index, ok := f.seen[fullName]; ok {
+		f.b = append(f.b, 'r')
+		f.b = binary.AppendUvarint(f.b, index)
+		return nil
+	}
+	m, ok := f.registry.FindMessage(fullName)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownMessage, fullName)
+	}
+	f.seen[fullName] = uint64(len(f.seen))
+
+	fields := m.EncodingOrder()
+	f.b = append(f.b, 'm')
+	f.b = binary.AppendUvarint(f.b, uint64(len(fields)))
+	for _, field := range fields {
+		f.b = append(f.b, byte(field.Kind))
+		switch {
+		case field.IsMap():
+			if err := f.typ(field.Key); err != nil {
+				return err
+			}
+			if err := f.typ(field.Value); err != nil {
+				return err
+			}
+		case field.IsRepeated():
+			if err := f.typ(field.Value); err != nil {
+				return err
+			}
+		case field.Value.IsMessage():
+			if err := f.message(field.Value.Message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fingerprinter) typ(t Type) error {
+	f.b = append(f.b, byte(t.Kind))
+	if t.IsMessage() {
+		return f.message(t.Message)
+	}
+	return nil
+}
+
+// Fingerprint returns a hash of the wire layout of a message: the order and
+// kinds of its fields and, recursively, of the messages it contains. Two
+// messages with the same fingerprint can decode each other's encodings.
+func (r *Registry) Fingerprint(fullName string) (uint64, error) {
+	f := &fingerprinter{
+		registry: r,
+		seen:     make(map[string]uint64),
+	}
+	if err := f.message(fullName); err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(f.b)
+	return h.Sum64(), nil
+}
+
+func Fingerprint(fullName string) (uint64, error) {
+	return registry.Fingerprint(fullName)
+}
@@ -0,0 +1,65 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func fingerprint(t *testing.T, f *File, name string) uint64 {
+	r := NewRegistry()
+	require.NoError(t, r.Register(f))
+	fingerprint, err := r.Fingerprint(name)
+	require.NoError(t, err)
+	return fingerprint
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	base := fingerprint(t, testFile("test.proto", "test"), "test.Request")
+	assert.Equal(t, base, fingerprint(t, testFile("other.proto", "other"), "other.Request"))
+
+	renamed := testFile("test.proto", "test")
+	renamed.Messages[0].Fields[0].Name = "id"
+	renamed.Messages[0].Fields[0].Number = 7
+	assert.Equal(t, base, fingerprint(t, renamed, "test.Request"))
+
+	retyped := testFile("test.proto", "test")
+	retyped.Messages[0].Fields[0].Kind = polyglot.Uint64Kind
+	retyped.Messages[0].Fields[0].Value.Kind = polyglot.Uint64Kind
+	assert.NotEqual(t, base, fingerprint(t, retyped, "test.Request"))
+
+	nested := testFile("test.proto", "test")
+	nested.Messages[1].Fields = []*Field{{Name: "name", Kind: polyglot.StringKind, Value: Type{Kind: polyglot.StringKind}}}
+	assert.NotEqual(t, base, fingerprint(t, nested, "test.Request"))
+
+	recursive := testFile("test.proto", "test")
+	recursive.Messages[1].Fields = []*Field{{Name: "parent", Kind: polyglot.AnyKind, Value: Type{Kind: polyglot.AnyKind, Message: "test.Request"}}}
+	assert.NotEqual(t, base, fingerprint(t, recursive, "test.Request"))
+
+	missing := testFile("test.proto", "test")
+	missing.Messages = missing.Messages[:1]
+	r := NewRegistry()
+	require.NoError(t, r.Register(missing))
+	_, err := r.Fingerprint("test.Request")
+	assert.ErrorIs(t, err, ErrUnknownMessage)
+}
@@ -0,0 +1,151 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+var (
+	ErrDuplicateName = errors.New("descriptor is already registered")
+)
+
+var (
+	registry = NewRegistry()
+)
+
+// Registry indexes file, message and enum descriptors by name.
+type Registry struct {
+	mu       sync.RWMutex
+	files    map[string]*File
+	messages map[string]*Message
+	enums    map[string]*Enum
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		files:    make(map[string]*File),
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+	}
+}
+
+// Register adds the file and all of its messages and enums to the registry.
+// Nothing is registered if any of the names are already taken.
+func (r *Registry) Register(f *File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.files[f.Path]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateName, f.Path)
+	}
+	for _, m := range f.Messages {
+		if _, ok := r.messages[m.FullName]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateName, m.FullName)
+		}
+	}
+	for _, e := range f.Enums {
+		if _, ok := r.enums[e.FullName]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateName, e.FullName)
+		}
+	}
+
+	r.files[f.Path] = f
+	for _, m := range f.Messages {
+		r.messages[m.FullName] = m
+	}
+	for _, e := range f.Enums {
+		r.enums[e.FullName] = e
+	}
+	return nil
+}
+
+func (r *Registry) FindFile(path string) (*File, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.files[path]
+	return f, ok
+}
+
+func (r *Registry) FindMessage(fullName string) (*Message, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.messages[fullName]
+	return m, ok
+}
+
+func (r *Registry) FindEnum(fullName string) (*Enum, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.enums[fullName]
+	return e, ok
+}
+
+// RangeMessages calls fn for every registered message until fn returns false.
+func (r *Registry) RangeMessages(fn func(*Message) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.messages {
+		if !fn(m) {
+			return
+		}
+	}
+}
+
+// RegisterEmbedded decodes an embedded file descriptor and adds it to the
+// global registry. It is called from the init of generated code, and panics
+// only if the descriptor cannot be decoded, which means the generated code is
+// corrupt.
+//
+// Linking two packages generated from the same file, or from files that share
+// a path or names, must not stop the program from starting, so conflicts keep
+// the first registration and log a warning, as protobuf-go does. The decoded
+// file is returned either way, so the descriptors of the generated code that
+// lost the conflict still work.
+func RegisterEmbedded(b []byte) *File {
+	f := new(File)
+	if err := f.Decode(b); err != nil {
+		panic(fmt.Errorf("invalid embedded polyglot descriptor: %w", err))
+	}
+	if err := registry.Register(f); err != nil {
+		log.Printf("WARNING: polyglot: %v; keeping the first registration", err)
+	}
+	return f
+}
+
+func Register(f *File) error {
+	return registry.Register(f)
+}
+
+func FindFile(path string) (*File, bool) {
+	return registry.FindFile(path)
+}
+
+func FindMessage(fullName string) (*Message, bool) {
+	return registry.FindMessage(fullName)
+}
+
+func FindEnum(fullName string) (*Enum, bool) {
+	return registry.FindEnum(fullName)
+}
+
+func RangeMessages(fn func(*Message) bool) {
+	registry.RangeMessages(fn)
+}
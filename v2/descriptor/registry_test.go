@@ -0,0 +1,89 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package descriptor
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	f := testFile("test.proto", "test")
+	require.NoError(t, r.Register(f))
+
+	found, ok := r.FindFile("test.proto")
+	assert.True(t, ok)
+	assert.Same(t, f, found)
+
+	m, ok := r.FindMessage("test.Label")
+	assert.True(t, ok)
+	assert.Same(t, f.Messages[1], m)
+
+	e, ok := r.FindEnum("test.Status")
+	assert.True(t, ok)
+	assert.Same(t, f.Enums[0], e)
+
+	_, ok = r.FindMessage("test.Missing")
+	assert.False(t, ok)
+
+	count := 0
+	r.RangeMessages(func(*Message) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 2, count)
+
+	assert.ErrorIs(t, r.Register(testFile("other.proto", "test")), ErrDuplicateName)
+	_, ok = r.FindFile("other.proto")
+	assert.False(t, ok)
+}
+
+func TestRegisterEmbedded(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	testFile("register_embedded.proto", "registerembedded").Encode(b)
+
+	f := RegisterEmbedded(b.Bytes())
+	m, ok := FindMessage("registerembedded.Request")
+	assert.True(t, ok)
+	assert.Same(t, f.Messages[0], m)
+
+	// Registering the same file again keeps the first registration.
+	var again *File
+	assert.NotPanics(t, func() {
+		again = RegisterEmbedded(b.Bytes())
+	})
+	assert.NotSame(t, f, again)
+	assert.Equal(t, f, again)
+	found, ok := FindFile("register_embedded.proto")
+	assert.True(t, ok)
+	assert.Same(t, f, found)
+	m, ok = FindMessage("registerembedded.Request")
+	assert.True(t, ok)
+	assert.Same(t, f.Messages[0], m)
+
+	assert.Panics(t, func() {
+		RegisterEmbedded(b.Bytes()[1:])
+	})
+}
@@ -0,0 +1,281 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/options"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"reflect"
+	"sync"
+)
+
+var (
+	kindLUT = map[protoreflect.Kind]polyglot.Kind{
+		protoreflect.BoolKind:     polyglot.BoolKind,
+		protoreflect.Int32Kind:    polyglot.Int32Kind,
+		protoreflect.Sint32Kind:   polyglot.Int32Kind,
+		protoreflect.Uint32Kind:   polyglot.Uint32Kind,
+		protoreflect.Int64Kind:    polyglot.Int64Kind,
+		protoreflect.Sint64Kind:   polyglot.Int64Kind,
+		protoreflect.Uint64Kind:   polyglot.Uint64Kind,
+		protoreflect.Sfixed32Kind: polyglot.Int32Kind,
+		protoreflect.Sfixed64Kind: polyglot.Int64Kind,
+		protoreflect.Fixed32Kind:  polyglot.Uint32Kind,
+		protoreflect.Fixed64Kind:  polyglot.Uint64Kind,
+		protoreflect.StringKind:   polyglot.StringKind,
+		protoreflect.FloatKind:    polyglot.Float32Kind,
+		protoreflect.DoubleKind:   polyglot.Float64Kind,
+		protoreflect.BytesKind:    polyglot.BytesKind,
+		protoreflect.EnumKind:     polyglot.Uint32Kind,
+		protoreflect.MessageKind:  polyglot.AnyKind,
+	}
+
+	layouts sync.Map
+)
+
+// Layout groups the fields of a message in the order the generated code
+// writes them: scalar and enum fields first, then repeated fields, and
+// finally message and map fields, each group in declaration order. Fields
+// omitted with the (polyglot.field).omit option are not part of the layout.
+type Layout struct {
+	Values   []protoreflect.FieldDescriptor
+	Slices   []protoreflect.FieldDescriptor
+	Messages []protoreflect.FieldDescriptor
+}
+
+func NewLayout(desc protoreflect.MessageDescriptor) (*Layout, error) {
+	l := new(Layout)
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if options.Omitted(field) {
+			continue
+		}
+		if _, err := GoType(field); err != nil {
+			return nil, err
+		}
+		switch {
+		case field.Cardinality() == protoreflect.Repeated && !field.IsMap():
+			l.Slices = append(l.Slices, field)
+		case field.Kind() == protoreflect.MessageKind:
+			l.Messages = append(l.Messages, field)
+		default:
+			l.Values = append(l.Values, field)
+		}
+	}
+	return l, nil
+}
+
+// layoutOf returns the cached Layout of a message descriptor.
+func layoutOf(desc protoreflect.MessageDescriptor) (*Layout, error) {
+	if l, ok := layouts.Load(desc); ok {
+		return l.(*Layout), nil
+	}
+	l, err := NewLayout(desc)
+	if err != nil {
+		return nil, err
+	}
+	layouts.Store(desc, l)
+	return l, nil
+}
+
+// Supported returns an error wrapping ErrUnsupportedField if desc has a field
+// that polyglot cannot encode, such as a proto2 group.
+func Supported(desc protoreflect.MessageDescriptor) error {
+	_, err := layoutOf(desc)
+	return err
+}
+
+// Kind returns the polyglot kind used to encode values of the given proto kind.
+func Kind(kind protoreflect.Kind) polyglot.Kind {
+	return kindLUT[kind]
+}
+
+func (m *Message) Encode(b *polyglot.Buffer) {
+	if m == nil {
+		polyglot.Encoder(b).Nil()
+		return
+	}
+	// Encode cannot return an error, so messages whose descriptors have
+	// unsupported fields are written as nil. Check descriptors with
+	// Supported before encoding them.
+	l, err := layoutOf(m.desc)
+	if err != nil {
+		polyglot.Encoder(b).Nil()
+		return
+	}
+	for _, field := range l.Values {
+		value, _ := m.get(field)
+		encodeValue(b, value)
+	}
+	for _, field := range l.Slices {
+		value, _ := m.get(field)
+		v := reflect.ValueOf(value)
+		polyglot.Encoder(b).Slice(uint32(v.Len()), Kind(field.Kind()))
+		for i := 0; i < v.Len(); i++ {
+			encodeValue(b, v.Index(i).Interface())
+		}
+	}
+	for _, field := range l.Messages {
+		value, _ := m.get(field)
+		if !field.IsMap() {
+			value.(*Message).Encode(b)
+			continue
+		}
+		v := reflect.ValueOf(value)
+		polyglot.Encoder(b).Map(uint32(v.Len()), Kind(field.MapKey().Kind()), Kind(field.MapValue().Kind()))
+		iter := v.MapRange()
+		for iter.Next() {
+			encodeValue(b, iter.Key().Interface())
+			encodeValue(b, iter.Value().Interface())
+		}
+	}
+}
+
+func encodeValue(b *polyglot.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case bool:
+		polyglot.Encoder(b).Bool(v)
+	case int32:
+		polyglot.Encoder(b).Int32(v)
+	case uint32:
+		polyglot.Encoder(b).Uint32(v)
+	case int64:
+		polyglot.Encoder(b).Int64(v)
+	case uint64:
+		polyglot.Encoder(b).Uint64(v)
+	case float32:
+		polyglot.Encoder(b).Float32(v)
+	case float64:
+		polyglot.Encoder(b).Float64(v)
+	case string:
+		polyglot.Encoder(b).String(v)
+	case []byte:
+		polyglot.Encoder(b).Bytes(v)
+	case *Message:
+		v.Encode(b)
+	}
+}
+
+// Decode reads a message written by Encode or by the generated Encode
+// method of the same schema.
+func (m *Message) Decode(b []byte) error {
+	if m == nil {
+		return ErrDecodeNil
+	}
+	return m.decode(polyglot.Decoder(b))
+}
+
+func (m *Message) decode(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+	l, err := layoutOf(m.desc)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range l.Values {
+		value, err := decodeValue(d, field)
+		if err != nil {
+			return err
+		}
+		m.values[field.Index()] = value
+	}
+
+	for _, field := range l.Slices {
+		size, err := d.Slice(Kind(field.Kind()))
+		if err != nil {
+			return err
+		}
+		t, _ := GoType(field)
+		slice := reflect.MakeSlice(t, int(size), int(size))
+		for i := 0; i < int(size); i++ {
+			value, err := decodeValue(d, field)
+			if err != nil {
+				return err
+			}
+			slice.Index(i).Set(reflect.ValueOf(value))
+		}
+		m.values[field.Index()] = slice.Interface()
+	}
+
+	for _, field := range l.Messages {
+		if d.Nil() {
+			m.values[field.Index()] = nil
+			continue
+		}
+		if !field.IsMap() {
+			value := NewMessage(field.Message())
+			if err = value.decode(d); err != nil {
+				return err
+			}
+			m.values[field.Index()] = value
+			continue
+		}
+		size, err := d.Map(Kind(field.MapKey().Kind()), Kind(field.MapValue().Kind()))
+		if err != nil {
+			return err
+		}
+		t, _ := GoType(field)
+		v := reflect.MakeMapWithSize(t, int(size))
+		for i := uint32(0); i < size; i++ {
+			key, err := decodeValue(d, field.MapKey())
+			if err != nil {
+				return err
+			}
+			value, err := decodeValue(d, field.MapValue())
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+		}
+		m.values[field.Index()] = v.Interface()
+	}
+	return nil
+}
+
+// decodeValue decodes a single (non-repeated) value of the field's kind.
+func decodeValue(d *polyglot.BufferDecoder, field protoreflect.FieldDescriptor) (interface{}, error) {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return d.Bool()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return d.Int32()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.EnumKind:
+		return d.Uint32()
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return d.Int64()
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return d.Uint64()
+	case protoreflect.FloatKind:
+		return d.Float32()
+	case protoreflect.DoubleKind:
+		return d.Float64()
+	case protoreflect.StringKind:
+		return d.String()
+	case protoreflect.BytesKind:
+		return d.Bytes(nil)
+	case protoreflect.MessageKind:
+		value := NewMessage(field.Message())
+		return value, value.decode(d)
+	default:
+		return nil, ErrUnsupportedField
+	}
+}
@@ -0,0 +1,165 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/internal/fixture"
+	"github.com/loopholelabs/polyglot/v2/internal/fixture/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"testing"
+	"time"
+)
+
+func TestEncodeLayout(t *testing.T) {
+	t.Parallel()
+
+	inner := NewMessage(testDescriptor(t, "Inner"))
+	require.NoError(t, inner.Set("name", "Test String"))
+
+	m := NewMessage(testDescriptor(t, "Outer"))
+	require.NoError(t, m.Set("inner", inner))
+	require.NoError(t, m.Set("id", int64(-32)))
+	require.NoError(t, m.Set("tags", []string{"a", "b"}))
+	require.NoError(t, m.Set("status", uint32(1)))
+	require.NoError(t, m.Set("counts", map[string]uint32{"a": 1}))
+	require.NoError(t, m.Set("children", []*Message{inner, nil}))
+	require.NoError(t, m.Set("ratio", float32(0.5)))
+
+	// This is the order in which the generated Encode method of Outer writes its fields.
+	expected := polyglot.NewBuffer()
+	polyglot.Encoder(expected).Int64(-32).Uint32(1).Bytes(nil).Float32(0.5).
+		Slice(2, polyglot.StringKind).String("a").String("b").
+		Slice(2, polyglot.AnyKind).String("Test String").Nil().
+		String("Test String").
+		Map(1, polyglot.StringKind, polyglot.Uint32Kind).String("a").Uint32(1)
+
+	b := polyglot.NewBuffer()
+	m.Encode(b)
+	assert.Equal(t, expected.Bytes(), b.Bytes())
+
+	decoded := NewMessage(testDescriptor(t, "Outer"))
+	require.NoError(t, decoded.Decode(b.Bytes()))
+
+	value, err := decoded.Get("id")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-32), value)
+
+	value, err = decoded.Get("inner")
+	require.NoError(t, err)
+	name, err := value.(*Message).Get("name")
+	require.NoError(t, err)
+	assert.Equal(t, "Test String", name)
+
+	value, err = decoded.Get("counts")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint32{"a": 1}, value)
+
+	// Like the generated code, nil elements of repeated message fields
+	// are decoded as empty messages.
+	value, err = decoded.Get("children")
+	require.NoError(t, err)
+	children := value.([]*Message)
+	require.Len(t, children, 2)
+	require.NotNil(t, children[1])
+	name, err = children[1].Get("name")
+	require.NoError(t, err)
+	assert.Equal(t, "", name)
+}
+
+func TestDecodeNilAndInvalid(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	polyglot.Encoder(b).Nil()
+
+	m := NewMessage(testDescriptor(t, "Outer"))
+	require.NoError(t, m.Decode(b.Bytes()))
+	assert.ErrorIs(t, (*Message)(nil).Decode(b.Bytes()), ErrDecodeNil)
+
+	b.Reset()
+	polyglot.Encoder(b).String("not an int64")
+	assert.ErrorIs(t, m.Decode(b.Bytes()), polyglot.ErrInvalidInt64)
+}
+
+func TestGeneratedFixture(t *testing.T) {
+	t.Parallel()
+
+	file, err := schema.File()
+	require.NoError(t, err)
+	desc := file.Messages().ByName("User")
+	status := file.Enums().ByName("Status")
+
+	deleted, ok := EnumIndex(status, 10)
+	require.True(t, ok)
+	assert.Equal(t, uint32(2), deleted)
+	number, ok := EnumNumber(status, deleted)
+	require.True(t, ok)
+	assert.Equal(t, protoreflect.EnumNumber(10), number)
+	_, ok = EnumIndex(status, 1)
+	assert.False(t, ok)
+	_, ok = EnumNumber(status, 3)
+	assert.False(t, ok)
+
+	home := NewMessage(desc.Fields().ByName("home").Message())
+	require.NoError(t, home.Set("city", "London"))
+	m := NewMessage(desc)
+	require.NoError(t, m.Set("id", "u1"))
+	require.NoError(t, m.Set("password_hash", "omitted"))
+	require.NoError(t, m.Set("timeout", int64(5)))
+	require.NoError(t, m.Set("status", deleted))
+	require.NoError(t, m.Set("kind", uint32(1)))
+	require.NoError(t, m.Set("home", home))
+	require.NoError(t, m.Set("history", []uint32{1, 2}))
+	require.NoError(t, m.Set("keys", [][]byte{{1}}))
+	require.NoError(t, m.Set("statuses", map[string]uint32{"last": 1}))
+
+	b := polyglot.NewBuffer()
+	m.Encode(b)
+	user := new(fixture.FixtureUser)
+	require.NoError(t, user.Decode(b.Bytes()))
+	assert.Equal(t, "u1", user.UserID)
+	assert.Equal(t, time.Duration(5), user.Timeout)
+	assert.Equal(t, fixture.FixtureSTATUS_DELETED, user.Status)
+	assert.Equal(t, fixture.FixtureUserKIND_ROBOT, user.Kind)
+	assert.Equal(t, &fixture.FixtureAddress{City: "London"}, user.Home)
+	assert.Equal(t, []fixture.FixtureStatus{fixture.FixtureSTATUS_ACTIVE, fixture.FixtureSTATUS_DELETED}, user.History)
+	assert.Equal(t, [][]byte{{1}}, user.Keys)
+	assert.Equal(t, fixture.FixtureUserStatusesMap{"last": fixture.FixtureSTATUS_ACTIVE}, user.Statuses)
+
+	b.Reset()
+	user.Encode(b)
+	decoded := NewMessage(desc)
+	require.NoError(t, decoded.Decode(b.Bytes()))
+	// Omitted fields are not encoded.
+	require.NoError(t, m.Set("password_hash", ""))
+	for _, name := range []protoreflect.Name{"id", "password_hash", "timeout", "status", "kind", "history", "keys", "statuses"} {
+		expected, err := m.Get(string(name))
+		require.NoError(t, err)
+		actual, err := decoded.Get(string(name))
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, name)
+	}
+	value, err := decoded.Get("home")
+	require.NoError(t, err)
+	city, err := value.(*Message).Get("city")
+	require.NoError(t, err)
+	assert.Equal(t, "London", city)
+}
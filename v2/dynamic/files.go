@@ -0,0 +1,60 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrNotAMessage = errors.New("descriptor is not a message")
+)
+
+// LoadFiles reads a serialized FileDescriptorSet, such as the output of
+// protoc --descriptor_set_out, and resolves all of the files within it.
+func LoadFiles(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := new(descriptorpb.FileDescriptorSet)
+	if err = proto.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+	return protodesc.NewFiles(set)
+}
+
+// FindMessage looks up the descriptor of a message by its full name.
+func FindMessage(files *protoregistry.Files, fullName string) (protoreflect.MessageDescriptor, error) {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(fullName))
+	if err != nil {
+		return nil, err
+	}
+	message, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotAMessage, fullName)
+	}
+	return message, nil
+}
@@ -0,0 +1,184 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package dynamic encodes and decodes polyglot messages whose schema is only
+// known at runtime, using exactly the same layout as the code generated by
+// v2/generator/golang.
+//
+// Field values use the same Go types as the generated structs: scalars map to
+// their Go equivalents, enums are uint32, nested messages are *Message,
+// repeated fields are slices of the element type and map fields are Go maps
+// keyed by the key type. Like the constants of generated enums, enum values
+// are the index of the value in its enum declaration rather than its proto
+// number; EnumIndex and EnumNumber convert between the two.
+package dynamic
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrUnknownField     = errors.New("unknown field")
+	ErrInvalidValue     = errors.New("invalid value for field")
+	ErrUnsupportedField = errors.New("unsupported field")
+	ErrDecodeNil        = errors.New("cannot decode into a nil root message")
+)
+
+var (
+	messageType = reflect.TypeOf((*Message)(nil))
+
+	scalarTypes = map[protoreflect.Kind]reflect.Type{
+		protoreflect.BoolKind:     reflect.TypeOf(false),
+		protoreflect.Int32Kind:    reflect.TypeOf(int32(0)),
+		protoreflect.Sint32Kind:   reflect.TypeOf(int32(0)),
+		protoreflect.Sfixed32Kind: reflect.TypeOf(int32(0)),
+		protoreflect.Uint32Kind:   reflect.TypeOf(uint32(0)),
+		protoreflect.Fixed32Kind:  reflect.TypeOf(uint32(0)),
+		protoreflect.Int64Kind:    reflect.TypeOf(int64(0)),
+		protoreflect.Sint64Kind:   reflect.TypeOf(int64(0)),
+		protoreflect.Sfixed64Kind: reflect.TypeOf(int64(0)),
+		protoreflect.Uint64Kind:   reflect.TypeOf(uint64(0)),
+		protoreflect.Fixed64Kind:  reflect.TypeOf(uint64(0)),
+		protoreflect.FloatKind:    reflect.TypeOf(float32(0)),
+		protoreflect.DoubleKind:   reflect.TypeOf(float64(0)),
+		protoreflect.StringKind:   reflect.TypeOf(""),
+		protoreflect.BytesKind:    reflect.TypeOf([]byte(nil)),
+		protoreflect.EnumKind:     reflect.TypeOf(uint32(0)),
+		protoreflect.MessageKind:  messageType,
+	}
+)
+
+// Message is a polyglot message described by a protoreflect.MessageDescriptor.
+type Message struct {
+	desc   protoreflect.MessageDescriptor
+	values []interface{}
+}
+
+func NewMessage(desc protoreflect.MessageDescriptor) *Message {
+	return &Message{
+		desc:   desc,
+		values: make([]interface{}, desc.Fields().Len()),
+	}
+}
+
+func (m *Message) Descriptor() protoreflect.MessageDescriptor {
+	return m.desc
+}
+
+// Get returns the value of the named field, or the zero value of its type
+// if it has not been set.
+func (m *Message) Get(name string) (interface{}, error) {
+	field := m.desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownField, name)
+	}
+	return m.get(field)
+}
+
+// Set replaces the value of the named field. The value must have the Go type
+// of the field, and nil is accepted for message, repeated and map fields.
+func (m *Message) Set(name string, value interface{}) error {
+	field := m.desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownField, name)
+	}
+	return m.set(field, value)
+}
+
+func (m *Message) get(field protoreflect.FieldDescriptor) (interface{}, error) {
+	if value := m.values[field.Index()]; value != nil {
+		return value, nil
+	}
+	t, err := GoType(field)
+	if err != nil {
+		return nil, err
+	}
+	return reflect.Zero(t).Interface(), nil
+}
+
+func (m *Message) set(field protoreflect.FieldDescriptor, value interface{}) error {
+	t, err := GoType(field)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			m.values[field.Index()] = nil
+			return nil
+		}
+	}
+	if reflect.TypeOf(value) != t {
+		return fmt.Errorf("%w %s: expected %s, got %T", ErrInvalidValue, field.Name(), t, value)
+	}
+	m.values[field.Index()] = value
+	return nil
+}
+
+// GoType returns the Go type used to hold the values of a field in a Message.
+func GoType(field protoreflect.FieldDescriptor) (reflect.Type, error) {
+	if field.IsMap() {
+		key, err := elementType(field.MapKey())
+		if err != nil {
+			return nil, err
+		}
+		value, err := elementType(field.MapValue())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(key, value), nil
+	}
+	t, err := elementType(field)
+	if err != nil {
+		return nil, err
+	}
+	if field.Cardinality() == protoreflect.Repeated {
+		return reflect.SliceOf(t), nil
+	}
+	return t, nil
+}
+
+func elementType(field protoreflect.FieldDescriptor) (reflect.Type, error) {
+	t, ok := scalarTypes[field.Kind()]
+	if !ok {
+		return nil, fmt.Errorf("%w %s: %s", ErrUnsupportedField, field.FullName(), field.Kind())
+	}
+	return t, nil
+}
+
+// EnumIndex returns the value of the enum value with the given number in
+// dynamic messages and generated code, which is the index of its
+// declaration. Aliases return the index of the first value declared with the
+// number.
+func EnumIndex(enum protoreflect.EnumDescriptor, number protoreflect.EnumNumber) (uint32, bool) {
+	value := enum.Values().ByNumber(number)
+	if value == nil {
+		return 0, false
+	}
+	return uint32(value.Index()), true
+}
+
+// EnumNumber returns the number of the enum value declared at index.
+func EnumNumber(enum protoreflect.EnumDescriptor, index uint32) (protoreflect.EnumNumber, bool) {
+	if index >= uint32(enum.Values().Len()) {
+		return 0, false
+	}
+	return enum.Values().Get(int(index)).Number(), true
+}
@@ -0,0 +1,135 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"testing"
+)
+
+func testField(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+	field := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Type:     kind.Enum(),
+		Label:    label.Enum(),
+	}
+	if typeName != "" {
+		field.TypeName = proto.String(typeName)
+	}
+	return field
+}
+
+func testFileDescriptorProto() *descriptorpb.FileDescriptorProto {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("dynamic_test.proto"),
+		Package: proto.String("dynamic"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("STATUS_OK"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Inner"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					testField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				},
+			},
+			{
+				Name: proto.String("Outer"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					testField("inner", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".dynamic.Inner"),
+					testField("id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					testField("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated, ""),
+					testField("status", 4, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional, ".dynamic.Status"),
+					testField("counts", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".dynamic.Outer.CountsEntry"),
+					testField("children", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".dynamic.Inner"),
+					testField("data", 7, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional, ""),
+					testField("ratio", 8, descriptorpb.FieldDescriptorProto_TYPE_FLOAT, optional, ""),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("CountsEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						testField("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+						testField("value", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT32, optional, ""),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+		},
+	}
+}
+
+func testDescriptor(t *testing.T, name protoreflect.Name) protoreflect.MessageDescriptor {
+	file, err := protodesc.NewFile(testFileDescriptorProto(), nil)
+	require.NoError(t, err)
+	desc := file.Messages().ByName(name)
+	require.NotNil(t, desc)
+	return desc
+}
+
+func TestMessageGetSet(t *testing.T) {
+	t.Parallel()
+
+	m := NewMessage(testDescriptor(t, "Outer"))
+
+	value, err := m.Get("id")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), value)
+
+	value, err = m.Get("inner")
+	require.NoError(t, err)
+	assert.Nil(t, value.(*Message))
+
+	value, err = m.Get("counts")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint32(nil), value)
+
+	require.NoError(t, m.Set("id", int64(32)))
+	require.NoError(t, m.Set("status", uint32(1)))
+	require.NoError(t, m.Set("tags", []string{"a"}))
+	require.NoError(t, m.Set("counts", map[string]uint32{"a": 1}))
+
+	value, err = m.Get("tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, value)
+
+	require.NoError(t, m.Set("tags", nil))
+	value, err = m.Get("tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string(nil), value)
+
+	assert.ErrorIs(t, m.Set("id", 32), ErrInvalidValue)
+	assert.ErrorIs(t, m.Set("id", nil), ErrInvalidValue)
+	assert.ErrorIs(t, m.Set("counts", map[string]int64{}), ErrInvalidValue)
+	assert.ErrorIs(t, m.Set("missing", 1), ErrUnknownField)
+	_, err = m.Get("missing")
+	assert.ErrorIs(t, err, ErrUnknownField)
+}
@@ -0,0 +1,197 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidValue  = errors.New("invalid value encoding")
+	ErrUnknownExtent = errors.New("extent of value depends on its schema")
+)
+
+// Extent returns the number of bytes taken by the encoded value at the start
+// of b. Messages, and slices and maps of messages (encoded with AnyKind),
+// are not self-describing, so measuring them returns ErrUnknownExtent; the
+// generated code measures those using their schema.
+func Extent(b []byte) (int, error) {
+	rest, err := skip(b)
+	if err != nil {
+		return 0, err
+	}
+	return len(b) - len(rest), nil
+}
+
+func skip(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return b, ErrInvalidValue
+	}
+	switch b[0] {
+	case NilRawKind:
+		return b[1:], nil
+	case BoolRawKind, Uint8RawKind:
+		if len(b) < 2 {
+			return b, invalidKind(b[0])
+		}
+		return b[2:], nil
+	case Uint16RawKind:
+		return skipVarint(b, VarIntLen16)
+	case Uint32RawKind, Int32RawKind:
+		return skipVarint(b, VarIntLen32)
+	case Uint64RawKind, Int64RawKind:
+		return skipVarint(b, VarIntLen64)
+	case Float32RawKind:
+		if len(b) < 5 {
+			return b, ErrInvalidFloat32
+		}
+		return b[5:], nil
+	case Float64RawKind:
+		if len(b) < 9 {
+			return b, ErrInvalidFloat64
+		}
+		return b[9:], nil
+	case BytesRawKind, StringRawKind:
+		rest, size, err := skipUint32(b[1:])
+		if err != nil || uint64(len(rest)) < uint64(size) {
+			return b, invalidKind(b[0])
+		}
+		return rest[size:], nil
+	case ErrorRawKind:
+		if len(b) < 2 || b[1] != StringRawKind {
+			return b, ErrInvalidError
+		}
+		rest, err := skip(b[1:])
+		if err != nil {
+			return b, ErrInvalidError
+		}
+		return rest, nil
+	case SliceRawKind:
+		if len(b) < 2 {
+			return b, ErrInvalidSlice
+		}
+		kind := b[1]
+		rest, size, err := skipUint32(b[2:])
+		if err != nil {
+			return b, ErrInvalidSlice
+		}
+		return skipElements(rest, size, kind)
+	case MapRawKind:
+		if len(b) < 3 {
+			return b, ErrInvalidMap
+		}
+		keyKind, valueKind := b[1], b[2]
+		rest, size, err := skipUint32(b[3:])
+		if err != nil {
+			return b, ErrInvalidMap
+		}
+		if size > 0 && (keyKind == AnyRawKind || valueKind == AnyRawKind) {
+			return b, ErrUnknownExtent
+		}
+		for i := uint32(0); i < size; i++ {
+			if rest, err = skipKind(rest, keyKind); err != nil {
+				return b, err
+			}
+			if rest, err = skipKind(rest, valueKind); err != nil {
+				return b, err
+			}
+		}
+		return rest, nil
+	case AnyRawKind:
+		return b, ErrUnknownExtent
+	default:
+		return b, ErrInvalidValue
+	}
+}
+
+func skipElements(b []byte, size uint32, kind byte) ([]byte, error) {
+	if size > 0 && kind == AnyRawKind {
+		return b, ErrUnknownExtent
+	}
+	var err error
+	for i := uint32(0); i < size; i++ {
+		if b, err = skipKind(b, kind); err != nil {
+			return b, err
+		}
+	}
+	return b, nil
+}
+
+// skipKind skips a value that must be of kind.
+func skipKind(b []byte, kind byte) ([]byte, error) {
+	if len(b) == 0 || b[0] != kind {
+		return b, invalidKind(kind)
+	}
+	return skip(b)
+}
+
+// skipUint32 skips an encoded uint32 and returns its value, for the sizes of
+// slices, maps, bytes and strings.
+func skipUint32(b []byte) ([]byte, uint32, error) {
+	if _, err := skipVarint(b, VarIntLen32); err != nil {
+		return b, 0, err
+	}
+	return decodeUint32(b)
+}
+
+func skipVarint(b []byte, max int) ([]byte, error) {
+	for i := 1; i < len(b) && i <= max; i++ {
+		if b[i] < continuation {
+			return b[i+1:], nil
+		}
+	}
+	if len(b) > 0 {
+		return b, invalidKind(b[0])
+	}
+	return b, ErrInvalidValue
+}
+
+// invalidKind returns the decoding error of a kind.
+func invalidKind(kind byte) error {
+	switch kind {
+	case SliceRawKind:
+		return ErrInvalidSlice
+	case MapRawKind:
+		return ErrInvalidMap
+	case BytesRawKind:
+		return ErrInvalidBytes
+	case StringRawKind:
+		return ErrInvalidString
+	case ErrorRawKind:
+		return ErrInvalidError
+	case BoolRawKind:
+		return ErrInvalidBool
+	case Uint8RawKind:
+		return ErrInvalidUint8
+	case Uint16RawKind:
+		return ErrInvalidUint16
+	case Uint32RawKind:
+		return ErrInvalidUint32
+	case Uint64RawKind:
+		return ErrInvalidUint64
+	case Int32RawKind:
+		return ErrInvalidInt32
+	case Int64RawKind:
+		return ErrInvalidInt64
+	case Float32RawKind:
+		return ErrInvalidFloat32
+	case Float64RawKind:
+		return ErrInvalidFloat64
+	default:
+		return ErrInvalidValue
+	}
+}
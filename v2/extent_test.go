@@ -0,0 +1,108 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestExtent(t *testing.T) {
+	t.Parallel()
+
+	values := []func(e *BufferEncoder){
+		func(e *BufferEncoder) { e.Nil() },
+		func(e *BufferEncoder) { e.Bool(true) },
+		func(e *BufferEncoder) { e.Uint8(math.MaxUint8) },
+		func(e *BufferEncoder) { e.Uint16(math.MaxUint16) },
+		func(e *BufferEncoder) { e.Uint32(math.MaxUint32) },
+		func(e *BufferEncoder) { e.Uint64(math.MaxUint64) },
+		func(e *BufferEncoder) { e.Int32(math.MinInt32) },
+		func(e *BufferEncoder) { e.Int64(math.MinInt64) },
+		func(e *BufferEncoder) { e.Float32(math.MaxFloat32) },
+		func(e *BufferEncoder) { e.Float64(math.MaxFloat64) },
+		func(e *BufferEncoder) { e.String("polyglot") },
+		func(e *BufferEncoder) { e.Bytes(make([]byte, 300)) },
+		func(e *BufferEncoder) { e.Error(errors.New("polyglot")) },
+		func(e *BufferEncoder) { e.Slice(2, StringKind).String("a").String("b") },
+		func(e *BufferEncoder) { e.Slice(0, AnyKind) },
+		func(e *BufferEncoder) {
+			e.Slice(2, SliceKind).Slice(1, Int32Kind).Int32(-1).Slice(0, Int32Kind)
+		},
+		func(e *BufferEncoder) { e.Map(2, StringKind, Uint32Kind).String("a").Uint32(1).String("b").Uint32(2) },
+	}
+
+	for i, value := range values {
+		p := NewBuffer()
+		value(Encoder(p))
+		data := p.Bytes()
+		size := len(data)
+
+		n, err := Extent(data)
+		require.NoError(t, err, "value %d", i)
+		assert.Equal(t, size, n, "value %d", i)
+
+		// Trailing values are not part of the extent.
+		Encoder(p).String("trailing")
+		n, err = Extent(p.Bytes())
+		require.NoError(t, err, "value %d", i)
+		assert.Equal(t, size, n, "value %d", i)
+
+		// Truncated values are reported instead of read out of bounds.
+		for j := 0; j < size; j++ {
+			_, err = Extent(data[:j])
+			assert.Error(t, err, "value %d truncated to %d bytes", i, j)
+		}
+	}
+
+	p := NewBuffer()
+	Encoder(p).Slice(1, AnyKind).String("message field")
+	_, err := Extent(p.Bytes())
+	assert.ErrorIs(t, err, ErrUnknownExtent)
+
+	p.Reset()
+	Encoder(p).Map(1, StringKind, AnyKind).String("key").String("message field")
+	_, err = Extent(p.Bytes())
+	assert.ErrorIs(t, err, ErrUnknownExtent)
+
+	p.Reset()
+	Encoder(p).Slice(1, StringKind).Uint32(1)
+	_, err = Extent(p.Bytes())
+	assert.ErrorIs(t, err, ErrInvalidString)
+
+	_, err = Extent([]byte{0xff})
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestDecoderSkip(t *testing.T) {
+	t.Parallel()
+
+	p := NewBuffer()
+	Encoder(p).String("skipped").Slice(1, BoolKind).Bool(true).Uint32(32)
+
+	d := Decoder(p.Bytes())
+	require.NoError(t, d.Skip())
+	require.NoError(t, d.Skip())
+	v, err := d.Uint32()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(32), v)
+	assert.Error(t, d.Skip())
+}
@@ -0,0 +1,117 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidFieldPath = errors.New("invalid field path")
+)
+
+// FieldMask selects the fields read by the generated DecodeFields methods and
+// written by EncodeFieldsZeroFill. Paths name fields by their proto names, with nested
+// messages separated by dots, as in "user.address.city". Selecting a message
+// field selects all of its fields unless a longer path selects only some of
+// them. Paths continue through repeated message fields to the fields of every
+// element; map fields are always selected as a whole.
+//
+// A nil *FieldMask selects every field.
+type FieldMask struct {
+	fields map[string]*FieldMask
+}
+
+// NewFieldMask returns a FieldMask selecting the given paths.
+func NewFieldMask(paths ...string) (*FieldMask, error) {
+	m := &FieldMask{fields: make(map[string]*FieldMask)}
+	for _, path := range paths {
+		if err := m.add(path); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *FieldMask) add(path string) error {
+	names := strings.Split(path, ".")
+	for _, name := range names {
+		if name == "" {
+			return ErrInvalidFieldPath
+		}
+	}
+	for i, name := range names {
+		sub, ok := m.fields[name]
+		if ok && sub == nil {
+			// The field is already selected as a whole.
+			return nil
+		}
+		if i == len(names)-1 {
+			m.fields[name] = nil
+			return nil
+		}
+		if !ok {
+			sub = &FieldMask{fields: make(map[string]*FieldMask)}
+			m.fields[name] = sub
+		}
+		m = sub
+	}
+	return nil
+}
+
+// Has reports whether the field with the given name is selected.
+func (m *FieldMask) Has(name string) bool {
+	if m == nil {
+		return true
+	}
+	_, ok := m.fields[name]
+	return ok
+}
+
+// Sub returns the mask for the fields of the message field with the given
+// name, which is nil if the field is selected as a whole.
+func (m *FieldMask) Sub(name string) *FieldMask {
+	if m == nil {
+		return nil
+	}
+	return m.fields[name]
+}
+
+// Paths returns the sorted paths selected by m.
+func (m *FieldMask) Paths() []string {
+	if m == nil {
+		return nil
+	}
+	var paths []string
+	for name, sub := range m.fields {
+		if sub == nil {
+			paths = append(paths, name)
+			continue
+		}
+		for _, path := range sub.Paths() {
+			paths = append(paths, name+"."+path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (m *FieldMask) String() string {
+	return strings.Join(m.Paths(), ",")
+}
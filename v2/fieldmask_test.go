@@ -0,0 +1,67 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func TestFieldMask(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewFieldMask("id", "user.address.city", "user.name", "tags", "user.address.zip")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "tags", "user.address.city", "user.address.zip", "user.name"}, m.Paths())
+	assert.Equal(t, "id,tags,user.address.city,user.address.zip,user.name", m.String())
+
+	assert.True(t, m.Has("id"))
+	assert.True(t, m.Has("user"))
+	assert.False(t, m.Has("body"))
+	assert.Nil(t, m.Sub("id"))
+
+	user := m.Sub("user")
+	require.NotNil(t, user)
+	assert.True(t, user.Has("name"))
+	assert.False(t, user.Has("email"))
+	address := user.Sub("address")
+	require.NotNil(t, address)
+	assert.True(t, address.Has("city"))
+	assert.False(t, address.Has("street"))
+
+	m, err = NewFieldMask("user.name", "user", "user.address.city")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, m.Paths())
+	assert.Nil(t, m.Sub("user"))
+
+	m, err = NewFieldMask()
+	require.NoError(t, err)
+	assert.False(t, m.Has("id"))
+	assert.Empty(t, m.Paths())
+
+	var all *FieldMask
+	assert.True(t, all.Has("id"))
+	assert.Nil(t, all.Sub("user"))
+	assert.Nil(t, all.Paths())
+
+	for _, path := range []string{"", "user.", ".name", "user..name"} {
+		_, err = NewFieldMask(path)
+		assert.ErrorIs(t, err, ErrInvalidFieldPath, path)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package golang
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"fmt"
+)
+
+const (
+	columnarImportPath = protogen.GoImportPath("github.com/loopholelabs/polyglot/v2/columnar")
+
+	// columnValid is the name of the column that records which rows of a
+	// batch are not nil.
+	columnValid = "Valid"
+)
+
+// ColumnKind returns how a field is stored in the columns of a batch: the
+// suffix of the columnar functions for packed scalar fields, Message for
+// singular message fields, or Values for repeated and map fields.
+func ColumnKind(field protoreflect.FieldDescriptor) string {
+	if field.IsList() || field.IsMap() {
+		return "Values"
+	}
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return "Bools"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind, protoreflect.EnumKind:
+		return "Uints"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind, protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "Ints"
+	case protoreflect.FloatKind:
+		return "Float32s"
+	case protoreflect.DoubleKind:
+		return "Float64s"
+	case protoreflect.StringKind:
+		return "Strings"
+	case protoreflect.BytesKind:
+		return "Bytes"
+	case protoreflect.MessageKind:
+		return "Message"
+	default:
+		panic(errUnknownKind)
+	}
+}
+
+// columnarConflicts returns an error if a message of the files being
+// generated has a field whose Go name is used by its columns type.
+func columnarConflicts(plugin *protogen.Plugin) error {
+	var check func(messages []*protogen.Message) error
+	check = func(messages []*protogen.Message) error {
+		for _, message := range messages {
+			for _, field := range GeneratedFields(message.Desc.Fields()) {
+				if FieldName(field) == columnValid {
+					return fmt.Errorf("%s: the Go field name %s is reserved by the columnar option", field.FullName(), columnValid)
+				}
+			}
+			if err := check(message.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, f := range plugin.Files {
+		if f.Generate {
+			if err := check(f.Messages); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
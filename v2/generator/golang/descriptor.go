@@ -0,0 +1,230 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package golang
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+	"github.com/loopholelabs/polyglot/v2/utils"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"fmt"
+	"strings"
+)
+
+var (
+	polyglotKindLUT = map[protoreflect.Kind]polyglot.Kind{
+		protoreflect.BoolKind:     polyglot.BoolKind,
+		protoreflect.Int32Kind:    polyglot.Int32Kind,
+		protoreflect.Sint32Kind:   polyglot.Int32Kind,
+		protoreflect.Uint32Kind:   polyglot.Uint32Kind,
+		protoreflect.Int64Kind:    polyglot.Int64Kind,
+		protoreflect.Sint64Kind:   polyglot.Int64Kind,
+		protoreflect.Uint64Kind:   polyglot.Uint64Kind,
+		protoreflect.Sfixed32Kind: polyglot.Int32Kind,
+		protoreflect.Sfixed64Kind: polyglot.Int64Kind,
+		protoreflect.Fixed32Kind:  polyglot.Uint32Kind,
+		protoreflect.Fixed64Kind:  polyglot.Uint64Kind,
+		protoreflect.StringKind:   polyglot.StringKind,
+		protoreflect.FloatKind:    polyglot.Float32Kind,
+		protoreflect.DoubleKind:   polyglot.Float64Kind,
+		protoreflect.BytesKind:    polyglot.BytesKind,
+		protoreflect.EnumKind:     polyglot.Uint32Kind,
+		protoreflect.MessageKind:  polyglot.AnyKind,
+	}
+)
+
+// fileDescriptor holds the descriptor of the file being generated, along with
+// the position of every message and enum within it.
+type fileDescriptor struct {
+	varName  string
+	file     *descriptor.File
+	messages map[protoreflect.FullName]int
+	enums    map[protoreflect.FullName]int
+
+	fingerprints map[protoreflect.FullName]uint64
+}
+
+func newFileDescriptor(file protoreflect.FileDescriptor) (*fileDescriptor, error) {
+	fd := &fileDescriptor{
+		varName:  descriptorVarName(file.Path()),
+		file:     NewDescriptor(file),
+		messages: make(map[protoreflect.FullName]int),
+		enums:    make(map[protoreflect.FullName]int),
+
+		fingerprints: make(map[protoreflect.FullName]uint64),
+	}
+	for i, m := range fd.file.Messages {
+		fd.messages[protoreflect.FullName(m.FullName)] = i
+	}
+	for i, e := range fd.file.Enums {
+		fd.enums[protoreflect.FullName(e.FullName)] = i
+	}
+
+	// Fingerprints cover messages from imported files, so every file the
+	// file depends on is registered along with it.
+	registry, err := NewRegistry(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Path(), err)
+	}
+	for _, m := range fd.file.Messages {
+		fingerprint, err := registry.Fingerprint(m.FullName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Path(), err)
+		}
+		fd.fingerprints[protoreflect.FullName(m.FullName)] = fingerprint
+	}
+	return fd, nil
+}
+
+// NewDescriptor builds the runtime descriptor of a file. Nested messages and
+// enums are flattened into the file in declaration order, and map entry
+// messages are folded into the Key and Value of their fields.
+func NewDescriptor(file protoreflect.FileDescriptor) *descriptor.File {
+	f := &descriptor.File{
+		Path:    file.Path(),
+		Package: string(file.Package()),
+	}
+	for i := 0; i < file.Enums().Len(); i++ {
+		f.Enums = append(f.Enums, newEnumDescriptor(file.Enums().Get(i)))
+	}
+	for i := 0; i < file.Messages().Len(); i++ {
+		appendMessageDescriptors(f, file.Messages().Get(i))
+	}
+	return f
+}
+
+// NewRegistry returns a registry holding the runtime descriptors of the
+// files and of every file they import.
+func NewRegistry(files ...protoreflect.FileDescriptor) (*descriptor.Registry, error) {
+	registry := descriptor.NewRegistry()
+	var register func(file protoreflect.FileDescriptor) error
+	register = func(file protoreflect.FileDescriptor) error {
+		if _, ok := registry.FindFile(file.Path()); ok {
+			return nil
+		}
+		if err := registry.Register(NewDescriptor(file)); err != nil {
+			return err
+		}
+		for i := 0; i < file.Imports().Len(); i++ {
+			if err := register(file.Imports().Get(i).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, file := range files {
+		if err := register(file); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+func appendMessageDescriptors(f *descriptor.File, message protoreflect.MessageDescriptor) {
+	m := &descriptor.Message{
+		FullName: string(message.FullName()),
+	}
+	for _, field := range GeneratedFields(message.Fields()) {
+		m.Fields = append(m.Fields, newFieldDescriptor(field))
+	}
+	f.Messages = append(f.Messages, m)
+
+	for i := 0; i < message.Enums().Len(); i++ {
+		f.Enums = append(f.Enums, newEnumDescriptor(message.Enums().Get(i)))
+	}
+	for i := 0; i < message.Messages().Len(); i++ {
+		if nested := message.Messages().Get(i); !nested.IsMapEntry() {
+			appendMessageDescriptors(f, nested)
+		}
+	}
+}
+
+func newFieldDescriptor(field protoreflect.FieldDescriptor) *descriptor.Field {
+	f := &descriptor.Field{
+		Name:     string(field.Name()),
+		JSONName: field.JSONName(),
+		Number:   int32(field.Number()),
+	}
+	switch {
+	case field.IsMap():
+		f.Kind = polyglot.MapKind
+		f.Key = newTypeDescriptor(field.MapKey())
+		f.Value = newTypeDescriptor(field.MapValue())
+	case field.Cardinality() == protoreflect.Repeated:
+		f.Kind = polyglot.SliceKind
+		f.Value = newTypeDescriptor(field)
+	default:
+		f.Value = newTypeDescriptor(field)
+		f.Kind = f.Value.Kind
+	}
+	return f
+}
+
+func newTypeDescriptor(field protoreflect.FieldDescriptor) descriptor.Type {
+	t := descriptor.Type{
+		Kind: polyglotKindLUT[field.Kind()],
+	}
+	switch field.Kind() {
+	case protoreflect.MessageKind:
+		t.Message = string(field.Message().FullName())
+	case protoreflect.EnumKind:
+		t.Enum = string(field.Enum().FullName())
+	}
+	return t
+}
+
+func newEnumDescriptor(enum protoreflect.EnumDescriptor) *descriptor.Enum {
+	e := &descriptor.Enum{
+		FullName: string(enum.FullName()),
+	}
+	for i := 0; i < enum.Values().Len(); i++ {
+		value := enum.Values().Get(i)
+		e.Values = append(e.Values, &descriptor.EnumValue{
+			Name:   string(value.Name()),
+			Number: int32(value.Number()),
+		})
+	}
+	return e
+}
+
+func descriptorVarName(path string) string {
+	name := strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, path)
+	return utils.AppendString("polyglotDescriptor", utils.CamelCase(strings.ToLower(name)))
+}
+
+// Bytes returns the polyglot encoding of the file descriptor as the body of
+// a Go byte slice literal.
+func (fd *fileDescriptor) Bytes() string {
+	b := polyglot.NewBuffer()
+	fd.file.Encode(b)
+
+	builder := new(strings.Builder)
+	for i, c := range b.Bytes() {
+		if i%16 == 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("0x%02x,", c))
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
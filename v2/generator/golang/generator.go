@@ -18,26 +18,68 @@ package golang
 
 import (
 	"github.com/loopholelabs/polyglot/v2/generator/golang/templates"
+	"github.com/loopholelabs/polyglot/v2/generator/validate"
+	"github.com/loopholelabs/polyglot/v2/options"
 	"github.com/loopholelabs/polyglot/v2/utils"
 	"github.com/loopholelabs/polyglot/v2/version"
 
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/pluginpb"
 
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
 	"text/template"
 )
 
+// parameters holds the options passed to the plugin with --go-polyglot_opt.
+//
+// There is no parameter for selecting the encoding: polyglot only has the
+// positional encoding, and a field-numbered one would be a new wire format
+// that the Rust and TypeScript runtimes would have to read as well, so it is
+// out of scope for the generator.
+type parameters struct {
+	suffix       string
+	packageName  string
+	constructors bool
+	errors       bool
+	tags         string
+
+	validateOnDecode bool
+	grpc             bool
+	lazy             bool
+	columnar         bool
+}
+
 type Generator struct {
 	options      *protogen.Options
 	templ        *template.Template
 	CustomFields func() string
 	CustomEncode func() string
 	CustomDecode func() string
+
+	params     *parameters
+	genFile    *protogen.GeneratedFile
+	descriptor *fileDescriptor
 }
 
 func New() *Generator {
 	var g *Generator
+
+	params := new(parameters)
+	var flags flag.FlagSet
+	flags.StringVar(&params.suffix, "suffix", Extension, "Suffix of generated files")
+	flags.StringVar(&params.packageName, "package", "", "Package name of generated files (defaults to the proto package name)")
+	flags.BoolVar(&params.constructors, "constructors", true, "Generate New<Message> constructors")
+	flags.BoolVar(&params.errors, "errors", true, "Generate Error methods on messages")
+	flags.StringVar(&params.tags, "tags", "", "Build constraint added to generated files, for example \"linux && !race\"")
+	flags.BoolVar(&params.validateOnDecode, "validate_on_decode", false, "Call Validate at the end of Decode")
+	flags.BoolVar(&params.lazy, "lazy", false, "Leave nested message, repeated and map fields encoded until their getters are called")
+	flags.BoolVar(&params.columnar, "columnar", false, "Generate functions that encode slices of messages as one column per field")
+	flags.BoolVar(&params.grpc, "grpc", false, "Generate gRPC service descriptors and clients using the polyglot codec")
 	templ := template.Must(template.New("main").Funcs(template.FuncMap{
 		"CamelCase":          utils.CamelCaseFullName,
 		"CamelCaseName":      utils.CamelCaseName,
@@ -52,6 +94,71 @@ func New() *Generator {
 		"GetEncodingFields":  GetEncodingFields,
 		"GetDecodingFields":  GetDecodingFields,
 		"GetKindLUT":         GetKindLUT,
+		"GeneratedFields":    GeneratedFields,
+		"LazyFields":         LazyFields,
+		"MaskedValues":       MaskedValues,
+		"ColumnKind":         ColumnKind,
+		"FieldName":          FieldName,
+		"FieldTags":          FieldTags,
+		"FieldType": func(field protoreflect.FieldDescriptor) string {
+			return g.fieldType(field)
+		},
+		"GoType": func(field protoreflect.FieldDescriptor) string {
+			return g.goType(field)
+		},
+		"DescriptorVar": func() string {
+			return g.descriptor.varName
+		},
+		"MessageDescriptorIndex": func(name protoreflect.FullName) int {
+			return g.descriptor.messages[name]
+		},
+		"MessageFingerprint": func(name protoreflect.FullName) string {
+			return fmt.Sprintf("0x%016x", g.descriptor.fingerprints[name])
+		},
+		"EnumDescriptorIndex": func(name protoreflect.FullName) int {
+			return g.descriptor.enums[name]
+		},
+		"Constructors": func() bool {
+			return g.params.constructors
+		},
+		"ErrorMethods": func() bool {
+			return g.params.errors
+		},
+		"Lazy": func() bool {
+			return g.params.lazy
+		},
+		"ColumnarBatches": func() bool {
+			return g.params.columnar
+		},
+		"Columnar": func(name string) string {
+			return g.genFile.QualifiedGoIdent(columnarImportPath.Ident(name))
+		},
+		"ValidateOnDecode": func() bool {
+			return g.params.validateOnDecode
+		},
+		"ValidationPatterns": ValidationPatterns,
+		"ValidationChecks": func(field protoreflect.FieldDescriptor) []ValidationCheck {
+			return g.validationChecks(field)
+		},
+		"DefinedOnlyItems": DefinedOnlyItems,
+		"Validation": func(name string) string {
+			return g.genFile.QualifiedGoIdent(validationImportPath.Ident(name))
+		},
+		"RPC": func(name string) string {
+			return g.genFile.QualifiedGoIdent(rpcImportPath.Ident(name))
+		},
+		"GRPCServices": func() bool {
+			return g.params.grpc
+		},
+		"GRPC": func(name string) string {
+			return g.genFile.QualifiedGoIdent(grpcImportPath.Ident(name))
+		},
+		"Codec": func(name string) string {
+			return g.genFile.QualifiedGoIdent(codecImportPath.Ident(name))
+		},
+		"Qualify": func(importPath string, name string) string {
+			return g.genFile.QualifiedGoIdent(protogen.GoImportPath(importPath).Ident(name))
+		},
 		"CustomFields": func() string {
 			return g.CustomFields()
 		},
@@ -64,10 +171,11 @@ func New() *Generator {
 	}).ParseFS(templates.FS, "*"))
 	g = &Generator{
 		options: &protogen.Options{
-			ParamFunc:         func(name string, value string) error { return nil },
+			ParamFunc:         flags.Set,
 			ImportRewriteFunc: func(path protogen.GoImportPath) protogen.GoImportPath { return path },
 		},
 		templ:        templ,
+		params:       params,
 		CustomEncode: func() string { return "" },
 		CustomDecode: func() string { return "" },
 		CustomFields: func() string { return "" },
@@ -90,13 +198,32 @@ func (g *Generator) Generate(req *pluginpb.CodeGeneratorRequest) (res *pluginpb.
 		return nil, err
 	}
 
+	if err = validate.Plugin(plugin, validate.Go); err != nil {
+		plugin.Error(err)
+		return plugin.Response(), nil
+	}
+
+	if g.params.columnar {
+		if g.params.lazy {
+			plugin.Error(errors.New("the columnar and lazy options cannot be used together"))
+			return plugin.Response(), nil
+		}
+		if err = columnarConflicts(plugin); err != nil {
+			plugin.Error(err)
+			return plugin.Response(), nil
+		}
+	}
+
 	for _, f := range plugin.Files {
 		if !f.Generate {
 			continue
 		}
-		genFile := plugin.NewGeneratedFile(FileName(f.GeneratedFilenamePrefix), f.GoImportPath)
+		genFile := plugin.NewGeneratedFile(utils.AppendString(f.GeneratedFilenamePrefix, g.params.suffix), f.GoImportPath)
 
-		packageName := string(f.Desc.Package().Name())
+		packageName := g.params.packageName
+		if packageName == "" {
+			packageName = string(f.Desc.Package().Name())
+		}
 		if packageName == "" {
 			packageName = string(f.GoPackageName)
 		}
@@ -116,6 +243,17 @@ func (g *Generator) ExecuteTemplate(
 	packageName string,
 	header bool,
 ) error {
+	g.genFile = genFile
+	descriptor, err := newFileDescriptor(protoFile.Desc)
+	if err != nil {
+		return err
+	}
+	g.descriptor = descriptor
+	if header {
+		for _, importPath := range qualifiedImports {
+			genFile.QualifiedGoIdent(importPath.Ident(""))
+		}
+	}
 	return g.templ.ExecuteTemplate(genFile, "base.templ", map[string]interface{}{
 		"pluginVersion":   version.Version(),
 		"sourcePath":      protoFile.Desc.Path(),
@@ -123,6 +261,31 @@ func (g *Generator) ExecuteTemplate(
 		"requiredImports": RequiredImports,
 		"enums":           protoFile.Desc.Enums(),
 		"messages":        protoFile.Desc.Messages(),
+		"services":        protoFile.Desc.Services(),
 		"header":          header,
+		"buildTags":       g.params.tags,
+		"descriptor":      g.descriptor.Bytes(),
+	})
+}
+
+// goType returns the (polyglot.field).go_type of a field, qualified for use in
+// the file being generated, or an empty string if the field does not set one.
+func (g *Generator) goType(field protoreflect.FieldDescriptor) string {
+	goType := options.Field(field).GetGoType()
+	i := strings.LastIndex(goType, ".")
+	if i < 0 {
+		return goType
+	}
+	return g.genFile.QualifiedGoIdent(protogen.GoIdent{
+		GoName:       goType[i+1:],
+		GoImportPath: protogen.GoImportPath(goType[:i]),
 	})
 }
+
+// fieldType returns the Go type of the struct field generated for a field.
+func (g *Generator) fieldType(field protoreflect.FieldDescriptor) string {
+	if goType := g.goType(field); goType != "" {
+		return goType
+	}
+	return FindValue(field)
+}
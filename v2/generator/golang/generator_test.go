@@ -0,0 +1,107 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package golang
+
+import (
+	"github.com/loopholelabs/polyglot/v2/internal/fixture/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	fixtureDir    = "../../internal/fixture"
+	fixtureModule = "github.com/loopholelabs/polyglot/v2/internal/fixture"
+)
+
+// Run `go test -run TestFixtures -generate` to regenerate the fixture packages
+// after changing the templates. The fixture packages are built and tested
+// with the rest of the module.
+var generateFixtures = flag.Bool("generate", false, "regenerate the fixture packages in "+fixtureDir)
+
+// fixtures are the packages generated from fixture.proto. Between them they
+// set every plugin parameter; columnar and lazy cannot be used together.
+var fixtures = []struct {
+	name      string
+	parameter string
+}{
+	{"default", ""},
+	{"columnar", "Mfixture.proto=" + fixtureModule + "/columnar,package=columnar,suffix=.columnar.go,constructors=false,errors=false,tags=!nofixture,validate_on_decode=true,grpc=true,columnar=true"},
+	{"lazy", "Mfixture.proto=" + fixtureModule + "/lazy,package=lazy,validate_on_decode=true,grpc=true,lazy=true"},
+}
+
+func fixtureRequest(t *testing.T) *pluginpb.CodeGeneratorRequest {
+	file, err := schema.File()
+	require.NoError(t, err)
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{schema.Path},
+	}
+	seen := make(map[string]struct{})
+	var add func(file protoreflect.FileDescriptor)
+	add = func(file protoreflect.FileDescriptor) {
+		if _, ok := seen[file.Path()]; ok {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		for i := 0; i < file.Imports().Len(); i++ {
+			add(file.Imports().Get(i).FileDescriptor)
+		}
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(file))
+	}
+	add(file)
+	return req
+}
+
+func TestFixtures(t *testing.T) {
+	t.Parallel()
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := fixtureRequest(t)
+			parameter := "module=" + fixtureModule
+			if fixture.parameter != "" {
+				parameter += "," + fixture.parameter
+			}
+			req.Parameter = proto.String(parameter)
+
+			res, err := New().Generate(req)
+			require.NoError(t, err)
+			require.Empty(t, res.GetError())
+			require.Len(t, res.File, 1)
+
+			path := filepath.Join(fixtureDir, filepath.FromSlash(res.File[0].GetName()))
+			if *generateFixtures {
+				require.NoError(t, os.WriteFile(path, []byte(res.File[0].GetContent()), 0o644))
+				return
+			}
+			expected, err := os.ReadFile(path)
+			require.NoError(t, err, "run go test -run TestFixtures -generate")
+			assert.Equal(t, string(expected), res.File[0].GetContent(), "%s is out of date, run go test -run TestFixtures -generate", path)
+		})
+	}
+}
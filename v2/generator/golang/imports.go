@@ -16,9 +16,21 @@
 
 package golang
 
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
 var (
 	RequiredImports = []string{
 		"github.com/loopholelabs/polyglot/v2",
+	}
+
+	// qualifiedImports are imported by every file through protogen, so that
+	// packages referenced by go_type options with the same names are
+	// imported under other names.
+	qualifiedImports = []protogen.GoImportPath{
+		"github.com/loopholelabs/polyglot/v2/descriptor",
+		"github.com/loopholelabs/polyglot/v2/json",
 		"errors",
 	}
 )
@@ -17,11 +17,13 @@
 package golang
 
 import (
+	"github.com/loopholelabs/polyglot/v2/options"
 	"github.com/loopholelabs/polyglot/v2/utils"
 	"google.golang.org/protobuf/reflect/protoreflect"
 
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -114,7 +116,7 @@ func FindValue(field protoreflect.FieldDescriptor) string {
 			case protoreflect.Optional, protoreflect.Required:
 				return utils.CamelCase(string(field.Enum().FullName()))
 			case protoreflect.Repeated:
-				return utils.CamelCase(utils.AppendString(Slice, string(field.Enum().FullName())))
+				return utils.AppendString(Slice, utils.CamelCase(string(field.Enum().FullName())))
 			default:
 				panic(errUnknownCardinality)
 			}
@@ -142,6 +144,42 @@ func FindValue(field protoreflect.FieldDescriptor) string {
 	}
 }
 
+// GeneratedFields returns the fields that are not omitted with the
+// (polyglot.field).omit option, in declaration order.
+func GeneratedFields(fields protoreflect.FieldDescriptors) []protoreflect.FieldDescriptor {
+	generated := make([]protoreflect.FieldDescriptor, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		if field := fields.Get(i); !options.Omitted(field) {
+			generated = append(generated, field)
+		}
+	}
+	return generated
+}
+
+// FieldName returns the name of the struct field generated for a field.
+func FieldName(field protoreflect.FieldDescriptor) string {
+	if name := options.Field(field).GetGoName(); name != "" {
+		return name
+	}
+	return utils.CamelCase(string(field.Name()))
+}
+
+// FieldTags returns the struct tags of a field, including the backquotes, or
+// an empty string if it has none.
+func FieldTags(field protoreflect.FieldDescriptor) string {
+	var tags []string
+	if messageTags := options.Message(field.ContainingMessage()).GetGoTags(); messageTags != "" {
+		tags = append(tags, strings.NewReplacer("{name}", string(field.Name()), "{json}", field.JSONName()).Replace(messageTags))
+	}
+	if fieldTags := options.Field(field).GetGoTags(); fieldTags != "" {
+		tags = append(tags, fieldTags)
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return utils.AppendString("`", strings.Join(tags, " "), "`")
+}
+
 type EncodingFields struct {
 	MessageFields []protoreflect.FieldDescriptor
 	SliceFields   []protoreflect.FieldDescriptor
@@ -153,8 +191,7 @@ func GetEncodingFields(fields protoreflect.FieldDescriptors) EncodingFields {
 	var sliceFields []protoreflect.FieldDescriptor
 	var values []string
 
-	for i := 0; i < fields.Len(); i++ {
-		field := fields.Get(i)
+	for _, field := range GeneratedFields(fields) {
 		if field.Cardinality() == protoreflect.Repeated && !field.IsMap() {
 			sliceFields = append(sliceFields, field)
 		} else {
@@ -166,10 +203,13 @@ func GetEncodingFields(fields protoreflect.FieldDescriptors) EncodingFields {
 					panic(errUnknownKind)
 				}
 			} else {
-				if field.Kind() == protoreflect.EnumKind {
-					values = append(values, fmt.Sprintf("%s(uint32(x.%s))", encoder, utils.CamelCase(string(field.Name()))))
-				} else {
-					values = append(values, fmt.Sprintf("%s(x.%s)", encoder, utils.CamelCase(string(field.Name()))))
+				switch {
+				case field.Kind() == protoreflect.EnumKind:
+					values = append(values, fmt.Sprintf("%s(uint32(x.%s))", encoder, FieldName(field)))
+				case options.Field(field).GetGoType() != "":
+					values = append(values, fmt.Sprintf("%s(%s(x.%s))", encoder, typeLUT[field.Kind()], FieldName(field)))
+				default:
+					values = append(values, fmt.Sprintf("%s(x.%s)", encoder, FieldName(field)))
 				}
 			}
 		}
@@ -181,6 +221,45 @@ func GetEncodingFields(fields protoreflect.FieldDescriptors) EncodingFields {
 	}
 }
 
+// MaskedValue is the encoding of a scalar or enum field, and of its zero
+// value, for fields that are not selected by a field mask.
+type MaskedValue struct {
+	Name  string
+	Value string
+	Zero  string
+}
+
+// MaskedValues returns the scalar and enum fields in the order they are
+// encoded.
+func MaskedValues(fields protoreflect.FieldDescriptors) []MaskedValue {
+	values := GetEncodingFields(fields).Values
+	masked := make([]MaskedValue, 0, len(values))
+	for _, field := range GeneratedFields(fields) {
+		if field.Cardinality() == protoreflect.Repeated {
+			continue
+		}
+		encoder, ok := encodeLUT[field.Kind()]
+		if !ok {
+			continue
+		}
+		zero := "0"
+		switch field.Kind() {
+		case protoreflect.BoolKind:
+			zero = "false"
+		case protoreflect.StringKind:
+			zero = `""`
+		case protoreflect.BytesKind:
+			zero = "nil"
+		}
+		masked = append(masked, MaskedValue{
+			Name:  string(field.Name()),
+			Value: values[len(masked)],
+			Zero:  fmt.Sprintf("%s(%s)", encoder, zero),
+		})
+	}
+	return masked
+}
+
 type DecodingFields struct {
 	MessageFields []protoreflect.FieldDescriptor
 	SliceFields   []protoreflect.FieldDescriptor
@@ -192,8 +271,7 @@ func GetDecodingFields(fields protoreflect.FieldDescriptors) DecodingFields {
 	var sliceFields []protoreflect.FieldDescriptor
 	var other []protoreflect.FieldDescriptor
 
-	for i := 0; i < fields.Len(); i++ {
-		field := fields.Get(i)
+	for _, field := range GeneratedFields(fields) {
 		if field.Cardinality() == protoreflect.Repeated && !field.IsMap() {
 			sliceFields = append(sliceFields, field)
 		} else {
@@ -217,6 +295,13 @@ func GetDecodingFields(fields protoreflect.FieldDescriptors) DecodingFields {
 	}
 }
 
+// LazyFields returns the fields that the lazy mode leaves encoded until they
+// are read, in the order they are decoded.
+func LazyFields(fields protoreflect.FieldDescriptors) []protoreflect.FieldDescriptor {
+	decoding := GetDecodingFields(fields)
+	return append(decoding.SliceFields, decoding.MessageFields...)
+}
+
 func GetKind(kind protoreflect.Kind) string {
 	var outKind string
 	var ok bool
@@ -0,0 +1,240 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package golang
+
+import (
+	"github.com/loopholelabs/polyglot/v2/options"
+	"github.com/loopholelabs/polyglot/v2/utils"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+const (
+	validationImportPath = protogen.GoImportPath("github.com/loopholelabs/polyglot/v2/validation")
+)
+
+var (
+	// integerLimits holds the smallest and largest values of the Go types of
+	// integer fields. Enums are uint32 values.
+	integerLimits = map[protoreflect.Kind][2]*big.Int{
+		protoreflect.Int32Kind:    {big.NewInt(math.MinInt32), big.NewInt(math.MaxInt32)},
+		protoreflect.Sint32Kind:   {big.NewInt(math.MinInt32), big.NewInt(math.MaxInt32)},
+		protoreflect.Sfixed32Kind: {big.NewInt(math.MinInt32), big.NewInt(math.MaxInt32)},
+		protoreflect.Int64Kind:    {big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)},
+		protoreflect.Sint64Kind:   {big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)},
+		protoreflect.Sfixed64Kind: {big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)},
+		protoreflect.Uint32Kind:   {big.NewInt(0), big.NewInt(math.MaxUint32)},
+		protoreflect.Fixed32Kind:  {big.NewInt(0), big.NewInt(math.MaxUint32)},
+		protoreflect.EnumKind:     {big.NewInt(0), big.NewInt(math.MaxUint32)},
+		protoreflect.Uint64Kind:   {big.NewInt(0), new(big.Int).SetUint64(math.MaxUint64)},
+		protoreflect.Fixed64Kind:  {big.NewInt(0), new(big.Int).SetUint64(math.MaxUint64)},
+	}
+)
+
+// ValidationCheck is a single rule of a field. Condition is a Go expression
+// that holds when the value of the field breaks the rule.
+type ValidationCheck struct {
+	Condition string
+	Reason    string
+}
+
+// ValidationPattern is the compiled regular expression of a pattern rule.
+type ValidationPattern struct {
+	Var     string
+	Pattern string
+}
+
+func patternVar(field protoreflect.FieldDescriptor) string {
+	return utils.AppendString("polyglotPattern", utils.CamelCase(string(field.FullName())))
+}
+
+// ValidationPatterns returns the patterns used by the fields of a message.
+func ValidationPatterns(fields protoreflect.FieldDescriptors) []ValidationPattern {
+	var patterns []ValidationPattern
+	for _, field := range GeneratedFields(fields) {
+		if pattern := options.Field(field).GetRules().GetPattern(); pattern != "" {
+			patterns = append(patterns, ValidationPattern{
+				Var:     patternVar(field),
+				Pattern: strconv.Quote(pattern),
+			})
+		}
+	}
+	return patterns
+}
+
+// validationChecks returns the checks of a field's rules, in the order they
+// are evaluated. Checks on the items of repeated enum fields and on nested
+// messages are generated by the validate template.
+func (g *Generator) validationChecks(field protoreflect.FieldDescriptor) []ValidationCheck {
+	rules := options.Field(field).GetRules()
+	if rules == nil {
+		return nil
+	}
+
+	value := utils.AppendString("x.", FieldName(field))
+	var checks []ValidationCheck
+	check := func(condition string, reason string, args ...interface{}) {
+		checks = append(checks, ValidationCheck{
+			Condition: condition,
+			Reason:    fmt.Sprintf(reason, args...),
+		})
+	}
+
+	if field.IsList() || field.IsMap() {
+		if rules.GetRequired() {
+			check(fmt.Sprintf("len(%s) == 0", value), "is required")
+		}
+		if rules.MinItems != nil {
+			check(fmt.Sprintf("len(%s) < %d", value, rules.GetMinItems()), "must have at least %d items", rules.GetMinItems())
+		}
+		if rules.MaxItems != nil {
+			check(fmt.Sprintf("len(%s) > %d", value, rules.GetMaxItems()), "must have at most %d items", rules.GetMaxItems())
+		}
+		return checks
+	}
+
+	if rules.GetRequired() {
+		switch field.Kind() {
+		case protoreflect.MessageKind:
+			check(fmt.Sprintf("%s == nil", value), "is required")
+		case protoreflect.StringKind:
+			check(fmt.Sprintf("%s == \"\"", value), "is required")
+		case protoreflect.BytesKind:
+			check(fmt.Sprintf("len(%s) == 0", value), "is required")
+		case protoreflect.BoolKind:
+			check(fmt.Sprintf("!%s", value), "is required")
+		default:
+			check(fmt.Sprintf("%s == 0", value), "is required")
+		}
+	}
+
+	length := fmt.Sprintf("len(%s)", value)
+	unit := "bytes"
+	if field.Kind() == protoreflect.StringKind {
+		length = fmt.Sprintf("%s(string(%s))", g.genFile.QualifiedGoIdent(protogen.GoIdent{GoName: "RuneCountInString", GoImportPath: "unicode/utf8"}), value)
+		unit = "characters"
+	}
+	if rules.MinLen != nil {
+		check(fmt.Sprintf("%s < %d", length, rules.GetMinLen()), "must be at least %d %s long", rules.GetMinLen(), unit)
+	}
+	if rules.MaxLen != nil {
+		check(fmt.Sprintf("%s > %d", length, rules.GetMaxLen()), "must be at most %d %s long", rules.GetMaxLen(), unit)
+	}
+	if pattern := rules.GetPattern(); pattern != "" {
+		check(fmt.Sprintf("!%s.MatchString(string(%s))", patternVar(field), value), "must match the pattern %q", pattern)
+	}
+
+	bounds := []struct {
+		bound *float64
+		op    string
+		text  string
+	}{
+		{rules.Gt, "<=", "greater than"},
+		{rules.Gte, "<", "greater than or equal to"},
+		{rules.Lt, ">=", "less than"},
+		{rules.Lte, ">", "less than or equal to"},
+	}
+	for _, b := range bounds {
+		if b.bound == nil {
+			continue
+		}
+		if limits, ok := integerLimits[field.Kind()]; ok {
+			if condition, ok := integerBoundCondition(value, limits, b.op, *b.bound); ok {
+				check(condition, "must be %s %s", b.text, formatIntegerBound(*b.bound))
+			}
+			continue
+		}
+		bound := strconv.FormatFloat(*b.bound, 'g', -1, 64)
+		check(fmt.Sprintf("float64(%s) %s %s", value, b.op, bound), "must be %s %s", b.text, bound)
+	}
+
+	if rules.GetDefinedOnly() && field.Kind() == protoreflect.EnumKind {
+		check(fmt.Sprintf("%sName[%s] == \"\"", utils.CamelCase(string(field.Enum().FullName())), value), "must be a defined enum value")
+	}
+	return checks
+}
+
+// DefinedOnlyItems reports whether the items of a repeated enum field must be
+// defined enum values.
+func DefinedOnlyItems(field protoreflect.FieldDescriptor) bool {
+	return field.IsList() && field.Kind() == protoreflect.EnumKind && options.Field(field).GetRules().GetDefinedOnly()
+}
+
+// integerBoundCondition returns the condition under which an integer value
+// breaks a bound, where op is the comparison that breaks it. The value is
+// compared in its own type, because converting 64-bit integers to float64
+// rounds them, and bounds that are not whole numbers are rounded to the
+// integer that gives the same result. It returns false if no value in limits
+// breaks the bound.
+func integerBoundCondition(value string, limits [2]*big.Int, op string, bound float64) (string, bool) {
+	if math.IsNaN(bound) {
+		return "", false
+	}
+	lower, upper := limits[0], limits[1]
+
+	var n *big.Int
+	switch {
+	case math.IsInf(bound, 1):
+		n = new(big.Int).Add(upper, big.NewInt(1))
+	case math.IsInf(bound, -1):
+		n = new(big.Int).Sub(lower, big.NewInt(1))
+	default:
+		// Int truncates towards zero, so step to the floor of the bound
+		// for <= and >, and to its ceiling for < and >=.
+		n, _ = big.NewFloat(bound).Int(nil)
+		if math.Trunc(bound) != bound {
+			if bound < 0 && (op == "<=" || op == ">") {
+				n.Sub(n, big.NewInt(1))
+			} else if bound > 0 && (op == "<" || op == ">=") {
+				n.Add(n, big.NewInt(1))
+			}
+		}
+	}
+
+	switch op {
+	case "<", "<=":
+		if n.Cmp(lower) < 0 || (op == "<" && n.Cmp(lower) == 0) {
+			return "", false
+		}
+		if n.Cmp(upper) > 0 {
+			op, n = "<=", upper
+		}
+	default:
+		if n.Cmp(upper) > 0 || (op == ">" && n.Cmp(upper) == 0) {
+			return "", false
+		}
+		if n.Cmp(lower) < 0 {
+			op, n = ">=", lower
+		}
+	}
+	return fmt.Sprintf("%s %s %s", value, op, n), true
+}
+
+// formatIntegerBound formats the bound of an integer field, writing every
+// digit of whole numbers rather than an exponent.
+func formatIntegerBound(bound float64) string {
+	if math.IsInf(bound, 0) || math.Trunc(bound) != bound {
+		return strconv.FormatFloat(bound, 'g', -1, 64)
+	}
+	n, _ := big.NewFloat(bound).Int(nil)
+	return n.String()
+}
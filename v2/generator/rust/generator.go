@@ -18,6 +18,7 @@ package rust
 
 import (
 	"github.com/loopholelabs/polyglot/v2/generator/rust/templates"
+	"github.com/loopholelabs/polyglot/v2/generator/validate"
 	"github.com/loopholelabs/polyglot/v2/utils"
 	"github.com/loopholelabs/polyglot/v2/version"
 
@@ -111,6 +112,11 @@ func (g *Generator) Generate(req *pluginpb.CodeGeneratorRequest) (res *pluginpb.
 		return nil, err
 	}
 
+	if err = validate.Plugin(plugin, validate.Rust); err != nil {
+		plugin.Error(err)
+		return plugin.Response(), nil
+	}
+
 	for _, f := range plugin.Files {
 		if !f.Generate {
 			continue
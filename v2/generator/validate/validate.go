@@ -0,0 +1,274 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package validate checks proto files for constructs the polyglot generators
+// cannot produce code for, so they can be reported before any templates run.
+package validate
+
+import (
+	"github.com/loopholelabs/polyglot/v2/options"
+	"github.com/loopholelabs/polyglot/v2/utils"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+var (
+	numericKinds = map[protoreflect.Kind]struct{}{
+		protoreflect.Int32Kind:    {},
+		protoreflect.Sint32Kind:   {},
+		protoreflect.Uint32Kind:   {},
+		protoreflect.Int64Kind:    {},
+		protoreflect.Sint64Kind:   {},
+		protoreflect.Uint64Kind:   {},
+		protoreflect.Sfixed32Kind: {},
+		protoreflect.Sfixed64Kind: {},
+		protoreflect.Fixed32Kind:  {},
+		protoreflect.Fixed64Kind:  {},
+		protoreflect.FloatKind:    {},
+		protoreflect.DoubleKind:   {},
+	}
+
+	supportedKinds = map[protoreflect.Kind]struct{}{
+		protoreflect.BoolKind:     {},
+		protoreflect.Int32Kind:    {},
+		protoreflect.Sint32Kind:   {},
+		protoreflect.Uint32Kind:   {},
+		protoreflect.Int64Kind:    {},
+		protoreflect.Sint64Kind:   {},
+		protoreflect.Uint64Kind:   {},
+		protoreflect.Sfixed32Kind: {},
+		protoreflect.Sfixed64Kind: {},
+		protoreflect.Fixed32Kind:  {},
+		protoreflect.Fixed64Kind:  {},
+		protoreflect.FloatKind:    {},
+		protoreflect.DoubleKind:   {},
+		protoreflect.StringKind:   {},
+		protoreflect.BytesKind:    {},
+		protoreflect.EnumKind:     {},
+		protoreflect.MessageKind:  {},
+	}
+)
+
+// Language is the language of a generator. Constructs that only affect the
+// generated code of one language are only checked for that language.
+type Language string
+
+const (
+	Go         Language = "go"
+	Rust       Language = "rust"
+	TypeScript Language = "typescript"
+)
+
+// Issue is a single unsupported construct found in a proto file.
+type Issue struct {
+	Path    string
+	Line    int
+	Column  int
+	Name    protoreflect.FullName
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", i.Path, i.Line, i.Column, i.Name, i.Message)
+}
+
+// Errors is returned by File when one or more unsupported constructs are found.
+type Errors []Issue
+
+func (e Errors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, issue := range e {
+		lines = append(lines, issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// File returns an Errors value listing every construct in the file that the
+// generator for lang does not support, or nil if there are none.
+func File(file protoreflect.FileDescriptor, lang Language) error {
+	return errorOf(validateFile(file, lang))
+}
+
+// Plugin validates every file the plugin has been asked to generate.
+func Plugin(plugin *protogen.Plugin, lang Language) error {
+	var issues Errors
+	for _, f := range plugin.Files {
+		if f.Generate {
+			issues = append(issues, validateFile(f.Desc, lang)...)
+		}
+	}
+	return errorOf(issues)
+}
+
+func errorOf(issues Errors) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return issues
+}
+
+func validateFile(file protoreflect.FileDescriptor, lang Language) Errors {
+	v := &validator{file: file, lang: lang}
+	for i := 0; i < file.Extensions().Len(); i++ {
+		v.extension(file.Extensions().Get(i))
+	}
+	for i := 0; i < file.Messages().Len(); i++ {
+		v.message(file.Messages().Get(i))
+	}
+	return v.issues
+}
+
+type validator struct {
+	file   protoreflect.FileDescriptor
+	lang   Language
+	issues Errors
+}
+
+func (v *validator) report(desc protoreflect.Descriptor, format string, args ...interface{}) {
+	issue := Issue{
+		Path:    v.file.Path(),
+		Name:    desc.FullName(),
+		Message: fmt.Sprintf(format, args...),
+	}
+	// Source locations are zero-based and only present when the request
+	// includes source info.
+	if location := v.file.SourceLocations().ByDescriptor(desc); location.Path != nil {
+		issue.Line = location.StartLine + 1
+		issue.Column = location.StartColumn + 1
+	}
+	v.issues = append(v.issues, issue)
+}
+
+func (v *validator) extension(field protoreflect.FieldDescriptor) {
+	v.report(field, "extensions are not supported (extends %s)", field.ContainingMessage().FullName())
+}
+
+func (v *validator) message(message protoreflect.MessageDescriptor) {
+	for i := 0; i < message.Extensions().Len(); i++ {
+		v.extension(message.Extensions().Get(i))
+	}
+	for i := 0; i < message.Fields().Len(); i++ {
+		v.field(message.Fields().Get(i))
+	}
+	if v.lang == Go {
+		v.goOptions(message)
+	}
+	for i := 0; i < message.Messages().Len(); i++ {
+		if nested := message.Messages().Get(i); !nested.IsMapEntry() {
+			v.message(nested)
+		}
+	}
+}
+
+func (v *validator) field(field protoreflect.FieldDescriptor) {
+	if field.IsMap() {
+		if key := field.MapKey(); key.Kind() == protoreflect.MessageKind || key.Kind() == protoreflect.GroupKind {
+			v.report(field, "map keys of kind %s are not supported", key.Kind())
+		} else {
+			v.kind(field, key.Kind(), "map keys")
+		}
+		v.kind(field, field.MapValue().Kind(), "map values")
+		return
+	}
+	v.kind(field, field.Kind(), "fields")
+}
+
+// goOptions checks the polyglot options of a message and its fields, which
+// only the Go generator reads.
+func (v *validator) goOptions(message protoreflect.MessageDescriptor) {
+	if strings.Contains(options.Message(message).GetGoTags(), "`") {
+		v.report(message, "go_tags must not contain backquotes")
+	}
+	names := make(map[string]struct{}, message.Fields().Len())
+	for i := 0; i < message.Fields().Len(); i++ {
+		field := message.Fields().Get(i)
+		if options.Omitted(field) {
+			continue
+		}
+		v.fieldOptions(field)
+
+		name := options.Field(field).GetGoName()
+		if name == "" {
+			name = utils.CamelCase(string(field.Name()))
+		}
+		if _, ok := names[name]; ok {
+			v.report(field, "Go field name %s is already used in the message", name)
+		}
+		names[name] = struct{}{}
+	}
+}
+
+func (v *validator) fieldOptions(field protoreflect.FieldDescriptor) {
+	opts := options.Field(field)
+	if strings.Contains(opts.GetGoTags(), "`") {
+		v.report(field, "go_tags must not contain backquotes")
+	}
+	if name := opts.GetGoName(); name != "" && !token.IsIdentifier(name) {
+		v.report(field, "go_name %q is not a valid Go identifier", name)
+	}
+	if rules := opts.GetRules(); rules != nil {
+		v.rules(field, rules)
+	}
+	if goType := opts.GetGoType(); goType != "" {
+		switch {
+		case field.Cardinality() == protoreflect.Repeated, field.Kind() == protoreflect.MessageKind, field.Kind() == protoreflect.EnumKind:
+			v.report(field, "go_type is only supported on singular scalar fields")
+		case !token.IsIdentifier(goType[strings.LastIndex(goType, ".")+1:]):
+			v.report(field, "go_type %q is not a valid Go type name", goType)
+		}
+	}
+}
+
+func (v *validator) rules(field protoreflect.FieldDescriptor, rules *options.Rules) {
+	collection := field.IsList() || field.IsMap()
+	if (rules.MinLen != nil || rules.MaxLen != nil) && (collection || (field.Kind() != protoreflect.StringKind && field.Kind() != protoreflect.BytesKind)) {
+		v.report(field, "min_len and max_len are only supported on singular string and bytes fields")
+	}
+	if pattern := rules.GetPattern(); pattern != "" {
+		if collection || field.Kind() != protoreflect.StringKind {
+			v.report(field, "pattern is only supported on singular string fields")
+		} else if _, err := regexp.Compile(pattern); err != nil {
+			v.report(field, "invalid pattern: %v", err)
+		}
+	}
+	if rules.Gt != nil || rules.Gte != nil || rules.Lt != nil || rules.Lte != nil {
+		if _, ok := numericKinds[field.Kind()]; collection || !ok {
+			v.report(field, "gt, gte, lt and lte are only supported on singular numeric fields")
+		}
+	}
+	if (rules.MinItems != nil || rules.MaxItems != nil) && !collection {
+		v.report(field, "min_items and max_items are only supported on repeated and map fields")
+	}
+	if rules.GetDefinedOnly() && (field.IsMap() || field.Kind() != protoreflect.EnumKind) {
+		v.report(field, "defined_only is only supported on enum fields")
+	}
+}
+
+func (v *validator) kind(field protoreflect.FieldDescriptor, kind protoreflect.Kind, what string) {
+	switch kind {
+	case protoreflect.GroupKind:
+		v.report(field, "groups are not supported")
+	default:
+		if _, ok := supportedKinds[kind]; !ok {
+			v.report(field, "%s of kind %s are not supported", what, kind)
+		}
+	}
+}
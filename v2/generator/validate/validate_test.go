@@ -0,0 +1,155 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package validate
+
+import (
+	"github.com/bufbuild/protocompile"
+	"github.com/loopholelabs/polyglot/v2/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"context"
+	"os"
+	"testing"
+)
+
+func compile(t *testing.T, source string) protoreflect.FileDescriptor {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(protocompile.CompositeResolver{
+			&protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(map[string]string{"test.proto": source}),
+			},
+			protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+				if path == options.File_polyglot_options_proto.Path() {
+					return protocompile.SearchResult{Desc: options.File_polyglot_options_proto}, nil
+				}
+				return protocompile.SearchResult{}, os.ErrNotExist
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	return files[0]
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	languages := []Language{Go, Rust, TypeScript}
+	tests := []struct {
+		name   string
+		source string
+		// issues are reported for every language unless goOnly is set.
+		issues []string
+		goOnly bool
+	}{
+		{
+			name: "clean",
+			source: `syntax = "proto3";
+package test;
+import "polyglot/options.proto";
+enum E {
+  E_ZERO = 0;
+}
+message M {
+  string a = 1 [(polyglot.field) = {go_name: "Name", go_tags: "db:\"a\"", rules: {max_len: 8}}];
+  repeated M children = 2;
+  map<string, E> values = 3;
+  message Nested {
+    bytes data = 1;
+  }
+}`,
+		},
+		{
+			name: "extensions",
+			source: `syntax = "proto2";
+package test;
+message M {
+  extensions 10 to 20;
+  extend M {
+    optional int32 nested = 11;
+  }
+}
+extend M {
+  optional int32 top = 10;
+}`,
+			issues: []string{
+				"test.proto:10:3: test.top: extensions are not supported (extends test.M)",
+				"test.proto:6:5: test.M.nested: extensions are not supported (extends test.M)",
+			},
+		},
+		{
+			name: "groups",
+			source: `syntax = "proto2";
+package test;
+message M {
+  optional group G = 1 {
+    optional int32 a = 1;
+  }
+}`,
+			issues: []string{
+				"test.proto:4:3: test.M.g: groups are not supported",
+			},
+		},
+		{
+			name: "go options",
+			source: `syntax = "proto3";
+package test;
+import "polyglot/options.proto";
+message M {
+  option (polyglot.message).go_tags = "a:` + "`b`" + `";
+  string a = 1 [(polyglot.field).go_name = "B"];
+  string b = 2;
+  repeated string c = 3 [(polyglot.field).go_type = "Name"];
+  int32 d = 4 [(polyglot.field).rules = {min_len: 1}];
+  string e = 5 [(polyglot.field).go_name = "not-an-identifier"];
+}`,
+			issues: []string{
+				"test.proto:4:1: test.M: go_tags must not contain backquotes",
+				"test.proto:7:3: test.M.b: Go field name B is already used in the message",
+				"test.proto:8:3: test.M.c: go_type is only supported on singular scalar fields",
+				"test.proto:9:3: test.M.d: min_len and max_len are only supported on singular string and bytes fields",
+				`test.proto:10:3: test.M.e: go_name "not-an-identifier" is not a valid Go identifier`,
+			},
+			goOnly: true,
+		},
+	}
+
+	for _, test := range tests {
+		file := compile(t, test.source)
+		for _, lang := range languages {
+			t.Run(test.name+"/"+string(lang), func(t *testing.T) {
+				t.Parallel()
+
+				err := File(file, lang)
+				if len(test.issues) == 0 || (test.goOnly && lang != Go) {
+					assert.NoError(t, err)
+					return
+				}
+				var errs Errors
+				require.ErrorAs(t, err, &errs)
+				issues := make([]string, 0, len(errs))
+				for _, issue := range errs {
+					issues = append(issues, issue.String())
+				}
+				assert.Equal(t, test.issues, issues)
+			})
+		}
+	}
+}
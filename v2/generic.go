@@ -0,0 +1,299 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Scalar is the set of types encoded as a single value, including named
+// types such as generated enums.
+type Scalar interface {
+	Key | ~[]byte
+}
+
+// Key is the set of scalar types that can be map keys.
+type Key interface {
+	~bool | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~int32 | ~int64 | ~float32 | ~float64 | ~string
+}
+
+// Codec encodes and decodes values of type T. ScalarCodec, SliceCodec and
+// MapCodec compose, so a codec for map[string][]int64 is
+//
+//	MapCodec(ScalarCodec[string](), SliceCodec(ScalarCodec[int64]()))
+type Codec[T any] interface {
+	Kind() Kind
+	Encode(e *BufferEncoder, value T)
+	Decode(d *BufferDecoder) (T, error)
+}
+
+// EncodeSlice encodes s as a slice of the kind of T.
+func EncodeSlice[T Scalar](b *Buffer, s []T) {
+	kind := scalarKind[T]()
+	e := Encoder(b)
+	e.Slice(uint32(len(s)), kind)
+	for i := range s {
+		encodeScalar(e, kind, unsafe.Pointer(&s[i]))
+	}
+}
+
+// DecodeSlice decodes a slice of the kind of T, reusing dst if it has the
+// capacity.
+func DecodeSlice[T Scalar](d *BufferDecoder, dst []T) ([]T, error) {
+	kind := scalarKind[T]()
+	size, err := d.Slice(kind)
+	if err != nil {
+		return nil, err
+	}
+	if uint32(cap(dst)) < size {
+		dst = make([]T, size)
+	}
+	dst = dst[:size]
+	for i := range dst {
+		if err = decodeScalar(d, kind, unsafe.Pointer(&dst[i])); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// EncodeMap encodes m as a map of the kinds of K and V.
+func EncodeMap[K Key, V Scalar](b *Buffer, m map[K]V) {
+	keyKind, valueKind := scalarKind[K](), scalarKind[V]()
+	e := Encoder(b)
+	e.Map(uint32(len(m)), keyKind, valueKind)
+	for k, v := range m {
+		encodeScalar(e, keyKind, unsafe.Pointer(&k))
+		encodeScalar(e, valueKind, unsafe.Pointer(&v))
+	}
+}
+
+// DecodeMap decodes a map of the kinds of K and V into dst, which is
+// cleared first. A nil dst is allocated.
+func DecodeMap[K Key, V Scalar](d *BufferDecoder, dst map[K]V) (map[K]V, error) {
+	keyKind, valueKind := scalarKind[K](), scalarKind[V]()
+	size, err := d.Map(keyKind, valueKind)
+	if err != nil {
+		return nil, err
+	}
+	if dst == nil {
+		dst = make(map[K]V, size)
+	} else {
+		clear(dst)
+	}
+	for i := uint32(0); i < size; i++ {
+		var k K
+		var v V
+		if err = decodeScalar(d, keyKind, unsafe.Pointer(&k)); err != nil {
+			return nil, err
+		}
+		if err = decodeScalar(d, valueKind, unsafe.Pointer(&v)); err != nil {
+			return nil, err
+		}
+		dst[k] = v
+	}
+	return dst, nil
+}
+
+type scalarCodec[T Scalar] struct {
+	kind Kind
+}
+
+// ScalarCodec returns the codec of a scalar type.
+func ScalarCodec[T Scalar]() Codec[T] {
+	return scalarCodec[T]{kind: scalarKind[T]()}
+}
+
+func (c scalarCodec[T]) Kind() Kind {
+	return c.kind
+}
+
+func (c scalarCodec[T]) Encode(e *BufferEncoder, value T) {
+	encodeScalar(e, c.kind, unsafe.Pointer(&value))
+}
+
+func (c scalarCodec[T]) Decode(d *BufferDecoder) (T, error) {
+	var value T
+	err := decodeScalar(d, c.kind, unsafe.Pointer(&value))
+	return value, err
+}
+
+type sliceCodec[T any] struct {
+	elem Codec[T]
+}
+
+// SliceCodec returns the codec of slices of elements encoded with elem.
+func SliceCodec[T any](elem Codec[T]) Codec[[]T] {
+	return sliceCodec[T]{elem: elem}
+}
+
+func (c sliceCodec[T]) Kind() Kind {
+	return SliceKind
+}
+
+func (c sliceCodec[T]) Encode(e *BufferEncoder, value []T) {
+	e.Slice(uint32(len(value)), c.elem.Kind())
+	for _, v := range value {
+		c.elem.Encode(e, v)
+	}
+}
+
+func (c sliceCodec[T]) Decode(d *BufferDecoder) ([]T, error) {
+	size, err := d.Slice(c.elem.Kind())
+	if err != nil {
+		return nil, err
+	}
+	value := make([]T, size)
+	for i := range value {
+		if value[i], err = c.elem.Decode(d); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+type mapCodec[K comparable, V any] struct {
+	key   Codec[K]
+	value Codec[V]
+}
+
+// MapCodec returns the codec of maps with keys encoded with key and values
+// encoded with value.
+func MapCodec[K comparable, V any](key Codec[K], value Codec[V]) Codec[map[K]V] {
+	return mapCodec[K, V]{key: key, value: value}
+}
+
+func (c mapCodec[K, V]) Kind() Kind {
+	return MapKind
+}
+
+func (c mapCodec[K, V]) Encode(e *BufferEncoder, value map[K]V) {
+	e.Map(uint32(len(value)), c.key.Kind(), c.value.Kind())
+	for k, v := range value {
+		c.key.Encode(e, k)
+		c.value.Encode(e, v)
+	}
+}
+
+func (c mapCodec[K, V]) Decode(d *BufferDecoder) (map[K]V, error) {
+	size, err := d.Map(c.key.Kind(), c.value.Kind())
+	if err != nil {
+		return nil, err
+	}
+	value := make(map[K]V, size)
+	for i := uint32(0); i < size; i++ {
+		k, err := c.key.Decode(d)
+		if err != nil {
+			return nil, err
+		}
+		v, err := c.value.Decode(d)
+		if err != nil {
+			return nil, err
+		}
+		value[k] = v
+	}
+	return value, nil
+}
+
+// scalarKind returns the kind of T from its underlying type.
+func scalarKind[T Scalar]() Kind {
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Bool:
+		return BoolKind
+	case reflect.Uint8:
+		return Uint8Kind
+	case reflect.Uint16:
+		return Uint16Kind
+	case reflect.Uint32:
+		return Uint32Kind
+	case reflect.Uint64:
+		return Uint64Kind
+	case reflect.Int32:
+		return Int32Kind
+	case reflect.Int64:
+		return Int64Kind
+	case reflect.Float32:
+		return Float32Kind
+	case reflect.Float64:
+		return Float64Kind
+	case reflect.String:
+		return StringKind
+	default:
+		return BytesKind
+	}
+}
+
+// encodeScalar encodes the value at p, whose underlying type is the one of
+// kind.
+func encodeScalar(e *BufferEncoder, kind Kind, p unsafe.Pointer) {
+	switch kind {
+	case BoolKind:
+		e.Bool(*(*bool)(p))
+	case Uint8Kind:
+		e.Uint8(*(*uint8)(p))
+	case Uint16Kind:
+		e.Uint16(*(*uint16)(p))
+	case Uint32Kind:
+		e.Uint32(*(*uint32)(p))
+	case Uint64Kind:
+		e.Uint64(*(*uint64)(p))
+	case Int32Kind:
+		e.Int32(*(*int32)(p))
+	case Int64Kind:
+		e.Int64(*(*int64)(p))
+	case Float32Kind:
+		e.Float32(*(*float32)(p))
+	case Float64Kind:
+		e.Float64(*(*float64)(p))
+	case StringKind:
+		e.String(*(*string)(p))
+	default:
+		e.Bytes(*(*[]byte)(p))
+	}
+}
+
+// decodeScalar decodes into the value at p, whose underlying type is the
+// one of kind.
+func decodeScalar(d *BufferDecoder, kind Kind, p unsafe.Pointer) (err error) {
+	switch kind {
+	case BoolKind:
+		*(*bool)(p), err = d.Bool()
+	case Uint8Kind:
+		*(*uint8)(p), err = d.Uint8()
+	case Uint16Kind:
+		*(*uint16)(p), err = d.Uint16()
+	case Uint32Kind:
+		*(*uint32)(p), err = d.Uint32()
+	case Uint64Kind:
+		*(*uint64)(p), err = d.Uint64()
+	case Int32Kind:
+		*(*int32)(p), err = d.Int32()
+	case Int64Kind:
+		*(*int64)(p), err = d.Int64()
+	case Float32Kind:
+		*(*float32)(p), err = d.Float32()
+	case Float64Kind:
+		*(*float64)(p), err = d.Float64()
+	case StringKind:
+		*(*string)(p), err = d.String()
+	default:
+		b := (*[]byte)(p)
+		*b, err = d.Bytes(*b)
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+type genericEnum uint32
+
+func TestGenericSlice(t *testing.T) {
+	t.Parallel()
+
+	p := NewBuffer()
+	EncodeSlice(p, []string{"polyglot", "generic"})
+	EncodeSlice(p, []genericEnum{1, 5})
+	EncodeSlice(p, [][]byte{[]byte("bytes")})
+	EncodeSlice(p, []int64{-1, 1 << 40})
+
+	expected := NewBuffer()
+	Encoder(expected).Slice(2, StringKind).String("polyglot").String("generic").
+		Slice(2, Uint32Kind).Uint32(1).Uint32(5).
+		Slice(1, BytesKind).Bytes([]byte("bytes")).
+		Slice(2, Int64Kind).Int64(-1).Int64(1 << 40)
+	require.Equal(t, expected.Bytes(), p.Bytes())
+
+	d := Decoder(p.Bytes())
+	strings, err := DecodeSlice[string](d, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"polyglot", "generic"}, strings)
+
+	dst := make([]genericEnum, 0, 4)
+	enums, err := DecodeSlice(d, dst)
+	require.NoError(t, err)
+	assert.Equal(t, []genericEnum{1, 5}, enums)
+	assert.Same(t, &dst[:1][0], &enums[0])
+
+	bytes, err := DecodeSlice[[]byte](d, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("bytes")}, bytes)
+
+	_, err = DecodeSlice[bool](d, nil)
+	assert.ErrorIs(t, err, ErrInvalidSlice)
+	ints, err := DecodeSlice[int64](d, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-1, 1 << 40}, ints)
+	assert.Empty(t, *d)
+}
+
+func TestGenericMap(t *testing.T) {
+	t.Parallel()
+
+	m := map[string]genericEnum{"a": 1, "b": 2}
+	p := NewBuffer()
+	EncodeMap(p, m)
+
+	d := Decoder(p.Bytes())
+	size, err := d.Map(StringKind, Uint32Kind)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), size)
+
+	dst := map[string]genericEnum{"stale": 3}
+	decoded, err := DecodeMap(Decoder(p.Bytes()), dst)
+	require.NoError(t, err)
+	assert.Equal(t, m, decoded)
+	assert.Equal(t, m, dst)
+
+	_, err = DecodeMap[string, uint64](Decoder(p.Bytes()), nil)
+	assert.ErrorIs(t, err, ErrInvalidMap)
+}
+
+func TestCodec(t *testing.T) {
+	t.Parallel()
+
+	c := MapCodec(ScalarCodec[string](), SliceCodec(ScalarCodec[float64]()))
+	assert.Equal(t, MapKind, c.Kind())
+
+	value := map[string][]float64{"x": {1.5, -2}, "y": {}}
+	p := NewBuffer()
+	c.Encode(Encoder(p), value)
+
+	d := Decoder(p.Bytes())
+	size, err := d.Map(StringKind, SliceKind)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), size)
+
+	decoded, err := c.Decode(Decoder(p.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, value, decoded)
+
+	nested := SliceCodec(SliceCodec(ScalarCodec[bool]()))
+	p.Reset()
+	nested.Encode(Encoder(p), [][]bool{{true}, {false, true}})
+	bools, err := nested.Decode(Decoder(p.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, [][]bool{{true}, {false, true}}, bools)
+
+	_, err = SliceCodec(ScalarCodec[string]()).Decode(Decoder(p.Bytes()))
+	assert.ErrorIs(t, err, ErrInvalidSlice)
+}
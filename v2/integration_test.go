@@ -0,0 +1,352 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+const integrationTestDataPath = "../integration-test-data.json"
+
+// Run `go test -run TestIntegration -generate` to rewrite the shared
+// integration test data from integrationVectors.
+var generateIntegrationTestData = flag.Bool("generate", false, "regenerate "+integrationTestDataPath+" from the Go implementation")
+
+type integrationTestData struct {
+	Name         string          `json:"name"`
+	Kind         Kind            `json:"kind"`
+	DecodedValue json.RawMessage `json:"decodedValue"`
+	EncodedValue string          `json:"encodedValue"`
+}
+
+// integrationVectors are the golden values shared with the Rust and
+// TypeScript implementations. Slices hold strings and maps hold string keys
+// with uint32 values.
+var integrationVectors = []struct {
+	name  string
+	kind  Kind
+	value interface{}
+}{
+	{"None", NilKind, nil},
+	{"true Bool", BoolKind, true},
+	{"false Bool", BoolKind, false},
+	{"U8", Uint8Kind, uint8(32)},
+	{"U16", Uint16Kind, uint16(1024)},
+	{"U32", Uint32Kind, uint32(4294967290)},
+	{"U64", Uint64Kind, uint64(18446744073709551610)},
+	{"I32", Int32Kind, int32(-2147483648)},
+	{"I64", Int64Kind, int64(-9223372036854775808)},
+	{"F32", Float32Kind, -214648.34432},
+	{"F64", Float64Kind, -922337203685.2345},
+	{"Array", SliceKind, []string{"1", "2", "3"}},
+	{"Map", MapKind, map[string]uint32{"1": 1, "2": 2, "3": 3}},
+	{"nil or empty Map", MapKind, map[string]uint32{}},
+	{"Bytes", BytesKind, []byte("Test String")},
+	{"String", StringKind, "Test String"},
+	{"Error", ErrorKind, "Test String"},
+}
+
+func parseIntegrationValue(raw json.RawMessage, value interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	return d.Decode(value)
+}
+
+func parseIntegrationNumber(raw json.RawMessage, bitSize int, signed bool) (int64, uint64, error) {
+	var n json.Number
+	if err := parseIntegrationValue(raw, &n); err != nil {
+		return 0, 0, err
+	}
+	if signed {
+		v, err := strconv.ParseInt(n.String(), 10, bitSize)
+		return v, 0, err
+	}
+	v, err := strconv.ParseUint(n.String(), 10, bitSize)
+	return 0, v, err
+}
+
+// encodeIntegrationValue encodes the JSON representation of a test value
+// using the encoder for the given kind.
+func encodeIntegrationValue(b *Buffer, kind Kind, raw json.RawMessage) error {
+	e := Encoder(b)
+	switch kind {
+	case NilKind:
+		e.Nil()
+	case BoolKind:
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		e.Bool(v)
+	case Uint8Kind, Uint16Kind, Uint32Kind, Uint64Kind:
+		bitSize := map[Kind]int{Uint8Kind: 8, Uint16Kind: 16, Uint32Kind: 32, Uint64Kind: 64}[kind]
+		_, v, err := parseIntegrationNumber(raw, bitSize, false)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case Uint8Kind:
+			e.Uint8(uint8(v))
+		case Uint16Kind:
+			e.Uint16(uint16(v))
+		case Uint32Kind:
+			e.Uint32(uint32(v))
+		default:
+			e.Uint64(v)
+		}
+	case Int32Kind, Int64Kind:
+		bitSize := map[Kind]int{Int32Kind: 32, Int64Kind: 64}[kind]
+		v, _, err := parseIntegrationNumber(raw, bitSize, true)
+		if err != nil {
+			return err
+		}
+		if kind == Int32Kind {
+			e.Int32(int32(v))
+		} else {
+			e.Int64(v)
+		}
+	case Float32Kind, Float64Kind:
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if kind == Float32Kind {
+			e.Float32(float32(v))
+		} else {
+			e.Float64(v)
+		}
+	case SliceKind:
+		var v []string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		e.Slice(uint32(len(v)), StringKind)
+		for _, s := range v {
+			e.String(s)
+		}
+	case MapKind:
+		var v map[string]uint32
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.Map(uint32(len(v)), StringKind, Uint32Kind)
+		for _, k := range keys {
+			e.String(k).Uint32(v[k])
+		}
+	case BytesKind:
+		var v []byte
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		e.Bytes(v)
+	case StringKind:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		e.String(v)
+	case ErrorKind:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		e.Error(errors.New(v))
+	default:
+		return fmt.Errorf("unsupported kind %d", kind)
+	}
+	return nil
+}
+
+// checkIntegrationDecode decodes the encoded test value and compares it to
+// the JSON representation of the expected value.
+func checkIntegrationDecode(t *testing.T, v integrationTestData, encoded []byte) {
+	d := Decoder(encoded)
+	switch v.Kind {
+	case NilKind:
+		assert.Equal(t, string(v.DecodedValue) == "null", d.Nil())
+	case BoolKind:
+		var expected bool
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		value, err := d.Bool()
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+	case Uint8Kind:
+		_, expected, err := parseIntegrationNumber(v.DecodedValue, 8, false)
+		require.NoError(t, err)
+		value, err := d.Uint8()
+		require.NoError(t, err)
+		assert.Equal(t, uint8(expected), value)
+	case Uint16Kind:
+		_, expected, err := parseIntegrationNumber(v.DecodedValue, 16, false)
+		require.NoError(t, err)
+		value, err := d.Uint16()
+		require.NoError(t, err)
+		assert.Equal(t, uint16(expected), value)
+	case Uint32Kind:
+		_, expected, err := parseIntegrationNumber(v.DecodedValue, 32, false)
+		require.NoError(t, err)
+		value, err := d.Uint32()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(expected), value)
+	case Uint64Kind:
+		_, expected, err := parseIntegrationNumber(v.DecodedValue, 64, false)
+		require.NoError(t, err)
+		value, err := d.Uint64()
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+	case Int32Kind:
+		expected, _, err := parseIntegrationNumber(v.DecodedValue, 32, true)
+		require.NoError(t, err)
+		value, err := d.Int32()
+		require.NoError(t, err)
+		assert.Equal(t, int32(expected), value)
+	case Int64Kind:
+		expected, _, err := parseIntegrationNumber(v.DecodedValue, 64, true)
+		require.NoError(t, err)
+		value, err := d.Int64()
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+	case Float32Kind:
+		var expected float64
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		value, err := d.Float32()
+		require.NoError(t, err)
+		assert.Equal(t, float32(expected), value)
+	case Float64Kind:
+		var expected float64
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		value, err := d.Float64()
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+	case SliceKind:
+		var expected []string
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		size, err := d.Slice(StringKind)
+		require.NoError(t, err)
+		require.Equal(t, uint32(len(expected)), size)
+		for i := range expected {
+			value, err := d.String()
+			require.NoError(t, err)
+			assert.Equal(t, expected[i], value)
+		}
+	case MapKind:
+		var expected map[string]uint32
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		size, err := d.Map(StringKind, Uint32Kind)
+		require.NoError(t, err)
+		value := make(map[string]uint32, size)
+		for i := uint32(0); i < size; i++ {
+			key, err := d.String()
+			require.NoError(t, err)
+			value[key], err = d.Uint32()
+			require.NoError(t, err)
+		}
+		assert.Equal(t, expected, value)
+	case BytesKind:
+		var expected []byte
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		value, err := d.Bytes(nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+	case StringKind:
+		var expected string
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		value, err := d.String()
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+	case ErrorKind:
+		var expected string
+		require.NoError(t, json.Unmarshal(v.DecodedValue, &expected))
+		value, err := d.Error()
+		require.NoError(t, err)
+		assert.EqualError(t, value, expected)
+	default:
+		t.Fatalf("unsupported kind %d", v.Kind)
+	}
+	assert.Empty(t, *d, "unexpected trailing bytes")
+}
+
+func generateIntegration(t *testing.T) {
+	data := make([]integrationTestData, 0, len(integrationVectors))
+	b := NewBuffer()
+	for _, vector := range integrationVectors {
+		raw, err := json.Marshal(vector.value)
+		require.NoError(t, err)
+		b.Reset()
+		require.NoError(t, encodeIntegrationValue(b, vector.kind, raw))
+		data = append(data, integrationTestData{
+			Name:         vector.name,
+			Kind:         vector.kind,
+			DecodedValue: raw,
+			EncodedValue: base64.StdEncoding.EncodeToString(b.Bytes()),
+		})
+	}
+	out, err := json.Marshal(data)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(integrationTestDataPath, out, 0644))
+}
+
+func TestIntegration(t *testing.T) {
+	if *generateIntegrationTestData {
+		generateIntegration(t)
+	}
+
+	raw, err := os.ReadFile(integrationTestDataPath)
+	require.NoError(t, err)
+	var data []integrationTestData
+	require.NoError(t, json.Unmarshal(raw, &data))
+	require.NotEmpty(t, data)
+
+	covered := make(map[Kind]bool)
+	for _, v := range data {
+		v := v
+		covered[v.Kind] = true
+		t.Run(v.Name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := base64.StdEncoding.DecodeString(v.EncodedValue)
+			require.NoError(t, err)
+
+			b := NewBuffer()
+			require.NoError(t, encodeIntegrationValue(b, v.Kind, v.DecodedValue))
+			assert.Equal(t, encoded, b.Bytes())
+
+			checkIntegrationDecode(t, v, encoded)
+		})
+	}
+
+	for _, vector := range integrationVectors {
+		assert.True(t, covered[vector.kind], "no integration test data for kind %d, run with -generate", vector.kind)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package integrity
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+var (
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+)
+
+type crc32c struct{}
+
+// NewCRC32C returns a Signer and Verifier using the CRC-32C checksum.
+func NewCRC32C() interface {
+	Signer
+	Verifier
+} {
+	return crc32c{}
+}
+
+func (crc32c) Algorithm() Algorithm {
+	return CRC32C
+}
+
+func (crc32c) Size() int {
+	return 4
+}
+
+func (crc32c) Sign(dst []byte, data []byte) []byte {
+	return binary.BigEndian.AppendUint32(dst, crc32.Checksum(data, castagnoli))
+}
+
+func (crc32c) Verify(data []byte, tag []byte) bool {
+	return len(tag) == 4 && binary.BigEndian.Uint32(tag) == crc32.Checksum(data, castagnoli)
+}
+
+type xxhash64 struct{}
+
+// NewXXHash64 returns a Signer and Verifier using the 64-bit xxHash
+// checksum.
+func NewXXHash64() interface {
+	Signer
+	Verifier
+} {
+	return xxhash64{}
+}
+
+func (xxhash64) Algorithm() Algorithm {
+	return XXHash64
+}
+
+func (xxhash64) Size() int {
+	return 8
+}
+
+func (xxhash64) Sign(dst []byte, data []byte) []byte {
+	return binary.BigEndian.AppendUint64(dst, sum64(data))
+}
+
+func (xxhash64) Verify(data []byte, tag []byte) bool {
+	return len(tag) == 8 && binary.BigEndian.Uint64(tag) == sum64(data)
+}
+
+type hmacSHA256 struct {
+	key []byte
+}
+
+// NewHMAC returns a Signer and Verifier using HMAC-SHA256 with the given key.
+func NewHMAC(key []byte) interface {
+	Signer
+	Verifier
+} {
+	return &hmacSHA256{key: append([]byte(nil), key...)}
+}
+
+func (h *hmacSHA256) Algorithm() Algorithm {
+	return HMACSHA256
+}
+
+func (h *hmacSHA256) Size() int {
+	return sha256.Size
+}
+
+func (h *hmacSHA256) Sign(dst []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write(data)
+	return mac.Sum(dst)
+}
+
+func (h *hmacSHA256) Verify(data []byte, tag []byte) bool {
+	var sum [sha256.Size]byte
+	return hmac.Equal(tag, h.Sign(sum[:0], data))
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewEd25519Signer returns a Signer using Ed25519 signatures.
+func NewEd25519Signer(key ed25519.PrivateKey) Signer {
+	return &ed25519Signer{key: key}
+}
+
+func (s *ed25519Signer) Algorithm() Algorithm {
+	return Ed25519
+}
+
+func (s *ed25519Signer) Sign(dst []byte, data []byte) []byte {
+	return append(dst, ed25519.Sign(s.key, data)...)
+}
+
+type ed25519Verifier struct {
+	key ed25519.PublicKey
+}
+
+// NewEd25519Verifier returns a Verifier of Ed25519 signatures.
+func NewEd25519Verifier(key ed25519.PublicKey) Verifier {
+	return &ed25519Verifier{key: key}
+}
+
+func (v *ed25519Verifier) Algorithm() Algorithm {
+	return Ed25519
+}
+
+func (v *ed25519Verifier) Size() int {
+	return ed25519.SignatureSize
+}
+
+func (v *ed25519Verifier) Verify(data []byte, tag []byte) bool {
+	return len(v.key) == ed25519.PublicKeySize && ed25519.Verify(v.key, data, tag)
+}
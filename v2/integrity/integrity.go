@@ -0,0 +1,162 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package integrity wraps encoded polyglot buffers in an envelope carrying a
+// checksum or signature, so corruption and tampering are reported before the
+// buffer is decoded.
+//
+// An envelope is an algorithm byte, the size of the payload as an unsigned
+// varint, the payload, and a tag of a fixed size for the algorithm. The tag
+// covers everything before it. It is computed over the encoded bytes as they
+// were sealed, so envelopes must be verified before their payload is decoded
+// and never re-encoded: map fields are encoded in iteration order, and the
+// same message can have more than one encoding.
+package integrity
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidEnvelope   = errors.New("invalid integrity envelope")
+	ErrAlgorithmMismatch = errors.New("unexpected integrity algorithm")
+	ErrChecksumMismatch  = errors.New("checksum mismatch")
+	ErrSignatureInvalid  = errors.New("invalid signature")
+)
+
+// Algorithm identifies how the tag of an envelope is computed.
+type Algorithm uint8
+
+const (
+	CRC32C Algorithm = iota + 1
+	XXHash64
+	HMACSHA256
+	Ed25519
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case CRC32C:
+		return "crc32c"
+	case XXHash64:
+		return "xxhash64"
+	case HMACSHA256:
+		return "hmac-sha256"
+	case Ed25519:
+		return "ed25519"
+	default:
+		return fmt.Sprintf("algorithm(%d)", uint8(a))
+	}
+}
+
+// Signer computes the tags of new envelopes.
+type Signer interface {
+	Algorithm() Algorithm
+
+	// Sign appends the tag of data to dst.
+	Sign(dst []byte, data []byte) []byte
+}
+
+// Verifier checks the tags of envelopes.
+type Verifier interface {
+	Algorithm() Algorithm
+
+	// Size is the size of the tags of the algorithm.
+	Size() int
+
+	// Verify reports whether tag is valid for data.
+	Verify(data []byte, tag []byte) bool
+}
+
+// VerifyError is returned when the tag of an envelope does not match its
+// contents. It unwraps to ErrChecksumMismatch for checksums and to
+// ErrSignatureInvalid for HMACs and signatures.
+type VerifyError struct {
+	Algorithm Algorithm
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("integrity: %s: %v", e.Algorithm, e.Unwrap())
+}
+
+func (e *VerifyError) Unwrap() error {
+	switch e.Algorithm {
+	case CRC32C, XXHash64:
+		return ErrChecksumMismatch
+	default:
+		return ErrSignatureInvalid
+	}
+}
+
+// Message is implemented by generated messages.
+type Message interface {
+	Encode(b *polyglot.Buffer)
+	Decode(b []byte) error
+}
+
+// Seal appends the envelope of payload to dst.
+func Seal(dst []byte, payload []byte, s Signer) []byte {
+	start := len(dst)
+	dst = append(dst, byte(s.Algorithm()))
+	dst = binary.AppendUvarint(dst, uint64(len(payload)))
+	dst = append(dst, payload...)
+	return s.Sign(dst, dst[start:])
+}
+
+// Open verifies the envelope in src and returns its payload, which shares
+// the memory of src.
+func Open(src []byte, v Verifier) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, ErrInvalidEnvelope
+	}
+	if Algorithm(src[0]) != v.Algorithm() {
+		return nil, ErrAlgorithmMismatch
+	}
+	size, n := binary.Uvarint(src[1:])
+	if n <= 0 {
+		return nil, ErrInvalidEnvelope
+	}
+	header := 1 + n
+	if size > uint64(len(src)-header) || uint64(len(src)-header)-size != uint64(v.Size()) {
+		return nil, ErrInvalidEnvelope
+	}
+	end := header + int(size)
+	if !v.Verify(src[:end], src[end:]) {
+		return nil, &VerifyError{Algorithm: v.Algorithm()}
+	}
+	return src[header:end:end], nil
+}
+
+// SealMessage appends the envelope of the encoding of m to dst.
+func SealMessage(dst []byte, m Message, s Signer) []byte {
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	m.Encode(b)
+	return Seal(dst, b.Bytes(), s)
+}
+
+// OpenMessage verifies the envelope in src and decodes its payload into m.
+func OpenMessage(src []byte, v Verifier, m Message) error {
+	payload, err := Open(src, v)
+	if err != nil {
+		return err
+	}
+	return m.Decode(payload)
+}
@@ -0,0 +1,124 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package integrity
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"crypto/ed25519"
+	"errors"
+	"testing"
+)
+
+type testMessage struct {
+	Name string
+	Size uint32
+}
+
+func (m *testMessage) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(m.Name).Uint32(m.Size)
+}
+
+func (m *testMessage) Decode(b []byte) (err error) {
+	d := polyglot.Decoder(b)
+	if m.Name, err = d.String(); err != nil {
+		return err
+	}
+	m.Size, err = d.Uint32()
+	return err
+}
+
+func TestSum64(t *testing.T) {
+	t.Parallel()
+
+	for input, sum := range map[string]uint64{
+		"":     0xef46db3751d8e999,
+		"a":    0xd24ec4f1a98c6e5b,
+		"as":   0x1c330fb2d66be179,
+		"asd":  0x631c37ce72a97393,
+		"asdf": 0x415872f599cea71e,
+		"abc":  0x44bc2cf5ad770999,
+		"Call me Ishmael. Some years ago--never mind how long precisely-": 0x02a2e85470d6fd96,
+	} {
+		assert.Equal(t, sum, sum64([]byte(input)), input)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	hmacKey := []byte("secret")
+
+	crc := NewCRC32C()
+	xxh := NewXXHash64()
+	mac := NewHMAC(hmacKey)
+	cases := []struct {
+		signer   Signer
+		verifier Verifier
+		sentinel error
+	}{
+		{crc, crc, ErrChecksumMismatch},
+		{xxh, xxh, ErrChecksumMismatch},
+		{mac, mac, ErrSignatureInvalid},
+		{NewEd25519Signer(private), NewEd25519Verifier(public), ErrSignatureInvalid},
+	}
+	for _, c := range cases {
+		name := c.signer.Algorithm().String()
+		in := &testMessage{Name: "spool", Size: 1 << 20}
+		sealed := SealMessage([]byte("prefix"), in, c.signer)
+		require.Equal(t, "prefix", string(sealed[:6]), name)
+		sealed = sealed[6:]
+		assert.Equal(t, byte(c.signer.Algorithm()), sealed[0], name)
+
+		out := new(testMessage)
+		require.NoError(t, OpenMessage(sealed, c.verifier, out), name)
+		assert.Equal(t, in, out, name)
+
+		for i := 1; i < len(sealed); i++ {
+			corrupt := append([]byte(nil), sealed...)
+			corrupt[i] ^= 0x01
+			_, err := Open(corrupt, c.verifier)
+			require.Error(t, err, name)
+			var verifyErr *VerifyError
+			if errors.As(err, &verifyErr) {
+				assert.Equal(t, c.signer.Algorithm(), verifyErr.Algorithm, name)
+				assert.ErrorIs(t, err, c.sentinel, name)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidEnvelope, name)
+			}
+		}
+		_, err := Open(sealed[:len(sealed)-1], c.verifier)
+		assert.ErrorIs(t, err, ErrInvalidEnvelope, name)
+	}
+
+	sealed := Seal(nil, []byte("payload"), mac)
+	_, err = Open(sealed, NewHMAC([]byte("other")))
+	assert.ErrorIs(t, err, ErrSignatureInvalid)
+	assert.EqualError(t, err, "integrity: hmac-sha256: invalid signature")
+	_, err = Open(sealed, crc)
+	assert.ErrorIs(t, err, ErrAlgorithmMismatch)
+	_, err = Open(nil, crc)
+	assert.ErrorIs(t, err, ErrInvalidEnvelope)
+
+	payload, err := Open(sealed, mac)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(payload))
+}
@@ -0,0 +1,125 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package columnar
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+	"time"
+)
+
+func testUser(name string) *FixtureUser {
+	return &FixtureUser{
+		UserID:    "u-" + name,
+		Name:      name,
+		Balance:   "12.50",
+		Timeout:   time.Second,
+		Admin:     true,
+		Age:       36,
+		Delta:     -3,
+		Logins:    7,
+		Offset:    -1 << 40,
+		Visits:    1 << 50,
+		Flags:     0xff,
+		Ratio:     0.5,
+		Score:     99.5,
+		Avatar:    []byte{1, 2, 3},
+		Status:    FixtureSTATUS_DELETED,
+		Kind:      FixtureUserKIND_ROBOT,
+		Home:      &FixtureAddress{City: "London", Zip: "N1"},
+		Tags:      []string{"a", "b"},
+		Samples:   []int64{-1, 2},
+		Keys:      [][]byte{{1}, {2, 3}},
+		History:   []FixtureStatus{FixtureSTATUS_ACTIVE, FixtureSTATUS_DELETED},
+		Addresses: []*FixtureAddress{{City: "Paris"}},
+		Counters:  FixtureUserCountersMap{"logins": 1},
+		Offices:   FixtureUserOfficesMap{1: {City: "Berlin"}},
+		Statuses:  FixtureUserStatusesMap{"last": FixtureSTATUS_ACTIVE},
+		Blobs:     FixtureUserBlobsMap{"key": {9}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	user := testUser("ada")
+	user.Encode(b)
+	decodedUser := new(FixtureUser)
+	require.NoError(t, decodedUser.Decode(b.Bytes()))
+	assert.Equal(t, user, decodedUser)
+
+	b.Reset()
+	page := &FixturePage{Users: []*FixtureUser{testUser("ada"), testUser("bob")}}
+	page.Encode(b)
+	decodedPage := new(FixturePage)
+	require.NoError(t, decodedPage.Decode(b.Bytes()))
+	assert.Equal(t, page, decodedPage)
+
+	b.Reset()
+	// Nil maps decode as empty maps.
+	leaf := func(name string) *FixtureNode {
+		return &FixtureNode{Name: name, Named: FixtureNodeNamedMap{}}
+	}
+	node := &FixtureNode{
+		Name:     "root",
+		Next:     leaf("next"),
+		Children: []*FixtureNode{leaf("child")},
+		Named:    FixtureNodeNamedMap{"leaf": leaf("leaf")},
+	}
+	node.Encode(b)
+	decodedNode := new(FixtureNode)
+	require.NoError(t, decodedNode.Decode(b.Bytes()))
+	assert.Equal(t, node, decodedNode)
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	bob := testUser("bob")
+	bob.Home = nil
+	bob.Tags = nil
+	rows := []*FixtureUser{testUser("ada"), nil, bob}
+
+	b := polyglot.NewBuffer()
+	EncodeFixtureUserBatch(b, rows)
+	decoded, err := DecodeFixtureUserBatch(b.Bytes())
+	require.NoError(t, err)
+	require.Len(t, decoded, 3)
+	assert.Equal(t, testUser("ada"), decoded[0])
+	assert.Nil(t, decoded[1])
+	assert.Equal(t, "bob", decoded[2].Name)
+	assert.Nil(t, decoded[2].Home)
+	assert.Empty(t, decoded[2].Tags)
+
+	columns, err := DecodeFixtureUserColumns(b.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 3, columns.Len())
+	assert.Equal(t, []bool{true, false, true}, columns.Valid)
+	assert.Equal(t, "ada", columns.Name[0])
+	assert.Equal(t, "London", columns.Home.City[0])
+	assert.Equal(t, decoded, columns.Rows())
+
+	b.Reset()
+	EncodeFixtureUserBatch(b, nil)
+	decoded, err = DecodeFixtureUserBatch(b.Bytes())
+	require.NoError(t, err)
+	assert.Empty(t, decoded)
+}
@@ -0,0 +1,140 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package columnar
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// directory echoes the users it is given.
+type directory struct{}
+
+func (directory) Get(_ context.Context, req *FixtureUser) (*FixtureUser, error) {
+	return req, nil
+}
+
+func (directory) List(req *FixtureUser, stream FixtureDirectoryListServer) error {
+	for i := int32(0); i < req.Age; i++ {
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (directory) Upload(stream FixtureDirectoryUploadServer) error {
+	page := new(FixturePage)
+	for {
+		u, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(page)
+		}
+		if err != nil {
+			return err
+		}
+		page.Users = append(page.Users, u)
+	}
+}
+
+func (directory) Sync(stream FixtureDirectorySyncServer) error {
+	for {
+		u, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = stream.Send(u); err != nil {
+			return err
+		}
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	t.Parallel()
+
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterFixtureDirectoryGRPCServer(s, directory{})
+	go func() {
+		_ = s.Serve(l)
+	}()
+	defer s.Stop()
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer cc.Close()
+	client := NewFixtureDirectoryGRPCClient(cc)
+	ctx := context.Background()
+
+	res, err := client.Get(ctx, testUser("ada"))
+	require.NoError(t, err)
+	assert.Equal(t, testUser("ada"), res)
+
+	// Requests are validated when the server decodes them.
+	_, err = client.Get(ctx, testUser(strings.Repeat("a", 33)))
+	assert.Error(t, err)
+
+	req := testUser("ada")
+	req.Age = 2
+	list, err := client.List(ctx, req)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		u, err := list.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, req, u)
+	}
+	_, err = list.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+
+	upload, err := client.Upload(ctx)
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(testUser("ada")))
+	require.NoError(t, upload.Send(testUser("bob")))
+	page, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, &FixturePage{Users: []*FixtureUser{testUser("ada"), testUser("bob")}}, page)
+
+	sync, err := client.Sync(ctx)
+	require.NoError(t, err)
+	for _, name := range []string{"ada", "bob"} {
+		require.NoError(t, sync.Send(testUser(name)))
+		u, err := sync.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, testUser(name), u)
+	}
+	require.NoError(t, sync.CloseSend())
+	_, err = sync.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,90 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fixture
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+	fixtureschema "github.com/loopholelabs/polyglot/v2/internal/fixture/schema"
+	"github.com/loopholelabs/polyglot/v2/options"
+	"github.com/loopholelabs/polyglot/v2/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"testing"
+)
+
+func TestDescriptor(t *testing.T) {
+	t.Parallel()
+
+	file, err := fixtureschema.File()
+	require.NoError(t, err)
+
+	_, ok := descriptor.FindFile(fixtureschema.Path)
+	require.True(t, ok)
+
+	for _, m := range []interface {
+		Descriptor() *descriptor.Message
+		Fingerprint() uint64
+	}{new(FixtureAddress), new(FixtureUser), new(FixturePage), new(FixtureNode)} {
+		desc := m.Descriptor()
+		found, ok := descriptor.FindMessage(desc.FullName)
+		require.True(t, ok, desc.FullName)
+		assert.Same(t, desc, found)
+
+		message := file.Messages().ByName(protoreflect.FullName(desc.FullName).Name())
+		require.NotNil(t, message, desc.FullName)
+		var numbers []int32
+		for i := 0; i < message.Fields().Len(); i++ {
+			if field := message.Fields().Get(i); !options.Omitted(field) {
+				numbers = append(numbers, int32(field.Number()))
+			}
+		}
+		var described []int32
+		for _, field := range desc.Fields {
+			described = append(described, field.Number)
+		}
+		assert.Equal(t, numbers, described, desc.FullName)
+
+		fingerprint, err := descriptor.Fingerprint(desc.FullName)
+		require.NoError(t, err)
+		assert.Equal(t, fingerprint, m.Fingerprint(), desc.FullName)
+	}
+	assert.NotEqual(t, new(FixtureUser).Fingerprint(), new(FixtureAddress).Fingerprint())
+}
+
+func TestSchemaHeader(t *testing.T) {
+	t.Parallel()
+
+	sealed := schema.SealMessage(nil, testUser("ada"))
+	decoded := new(FixtureUser)
+	require.NoError(t, schema.OpenMessage(sealed, decoded))
+	assert.Equal(t, testUser("ada"), decoded)
+
+	err := schema.OpenMessage(sealed, new(FixturePage))
+	var mismatch *schema.MismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, new(FixturePage).Fingerprint(), mismatch.Expected)
+	assert.Equal(t, decoded.Fingerprint(), mismatch.Actual)
+
+	b := polyglot.NewBuffer()
+	testUser("ada").Encode(b)
+	payload, err := schema.Open(sealed, decoded.Fingerprint())
+	require.NoError(t, err)
+	assert.Equal(t, b.Bytes(), payload)
+}
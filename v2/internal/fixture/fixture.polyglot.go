@@ -0,0 +1,2359 @@
+// Code generated by polyglot v2.0.5, DO NOT EDIT.
+// source: fixture.proto
+
+package fixture
+
+import (
+	context "context"
+	json1 "encoding/json"
+	errors "errors"
+	descriptor "github.com/loopholelabs/polyglot/v2/descriptor"
+	json "github.com/loopholelabs/polyglot/v2/json"
+	rpc "github.com/loopholelabs/polyglot/v2/rpc"
+	validation "github.com/loopholelabs/polyglot/v2/validation"
+	time "time"
+	utf8 "unicode/utf8"
+)
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+)
+
+var (
+	ErrDecodeNil = errors.New("cannot decode into a nil root struct")
+)
+
+var (
+	polyglotDescriptorFixtureProto = descriptor.RegisterEmbedded([]byte{
+		0x05, 0x0a, 0x0d, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+		0x05, 0x0a, 0x07, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x01, 0x03, 0x0a, 0x04, 0x05, 0x0a,
+		0x0f, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+		0x01, 0x03, 0x0a, 0x02, 0x05, 0x0a, 0x04, 0x63, 0x69, 0x74, 0x79, 0x05, 0x0a, 0x04, 0x63, 0x69,
+		0x74, 0x79, 0x0c, 0x02, 0x08, 0x05, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x05,
+		0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x03, 0x7a, 0x69, 0x70, 0x05, 0x0a, 0x03, 0x7a,
+		0x69, 0x70, 0x0c, 0x04, 0x08, 0x05, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x05,
+		0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x0c, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65,
+		0x2e, 0x55, 0x73, 0x65, 0x72, 0x01, 0x03, 0x0a, 0x1a, 0x05, 0x0a, 0x02, 0x69, 0x64, 0x05, 0x0a,
+		0x02, 0x69, 0x64, 0x0c, 0x02, 0x08, 0x05, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08,
+		0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x05, 0x0a,
+		0x04, 0x6e, 0x61, 0x6d, 0x65, 0x0c, 0x04, 0x08, 0x05, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a,
+		0x00, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x07, 0x62, 0x61, 0x6c, 0x61,
+		0x6e, 0x63, 0x65, 0x05, 0x0a, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x0c, 0x06, 0x08,
+		0x05, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a,
+		0x00, 0x05, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x05, 0x0a, 0x07, 0x74, 0x69,
+		0x6d, 0x65, 0x6f, 0x75, 0x74, 0x0c, 0x08, 0x08, 0x0d, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a,
+		0x00, 0x08, 0x0d, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x05, 0x61, 0x64, 0x6d, 0x69,
+		0x6e, 0x05, 0x0a, 0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x0c, 0x0c, 0x08, 0x07, 0x08, 0x00, 0x05,
+		0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x07, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x03,
+		0x61, 0x67, 0x65, 0x05, 0x0a, 0x03, 0x61, 0x67, 0x65, 0x0c, 0x0e, 0x08, 0x0c, 0x08, 0x00, 0x05,
+		0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0c, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x05,
+		0x64, 0x65, 0x6c, 0x74, 0x61, 0x05, 0x0a, 0x05, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x0c, 0x10, 0x08,
+		0x0c, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0c, 0x05, 0x0a, 0x00, 0x05, 0x0a,
+		0x00, 0x05, 0x0a, 0x06, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x73, 0x05, 0x0a, 0x06, 0x6c, 0x6f, 0x67,
+		0x69, 0x6e, 0x73, 0x0c, 0x12, 0x08, 0x0a, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08,
+		0x0a, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74,
+		0x05, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x0c, 0x14, 0x08, 0x0d, 0x08, 0x00, 0x05,
+		0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0d, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x06,
+		0x76, 0x69, 0x73, 0x69, 0x74, 0x73, 0x05, 0x0a, 0x06, 0x76, 0x69, 0x73, 0x69, 0x74, 0x73, 0x0c,
+		0x16, 0x08, 0x0b, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0b, 0x05, 0x0a, 0x00,
+		0x05, 0x0a, 0x00, 0x05, 0x0a, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x05, 0x0a, 0x05, 0x66, 0x6c,
+		0x61, 0x67, 0x73, 0x0c, 0x18, 0x08, 0x0b, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08,
+		0x0b, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x05, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x05,
+		0x0a, 0x05, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x0c, 0x1a, 0x08, 0x0e, 0x08, 0x00, 0x05, 0x0a, 0x00,
+		0x05, 0x0a, 0x00, 0x08, 0x0e, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x05, 0x73, 0x63,
+		0x6f, 0x72, 0x65, 0x05, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x0c, 0x1c, 0x08, 0x0f, 0x08,
+		0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0f, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05,
+		0x0a, 0x06, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x05, 0x0a, 0x06, 0x61, 0x76, 0x61, 0x74, 0x61,
+		0x72, 0x0c, 0x1e, 0x08, 0x04, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x04, 0x05,
+		0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x05, 0x0a,
+		0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x0c, 0x20, 0x08, 0x0a, 0x08, 0x00, 0x05, 0x0a, 0x00,
+		0x05, 0x0a, 0x00, 0x08, 0x0a, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x0e, 0x66, 0x69, 0x78, 0x74, 0x75,
+		0x72, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x05, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64,
+		0x05, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x0c, 0x22, 0x08, 0x0a, 0x08, 0x00, 0x05, 0x0a, 0x00,
+		0x05, 0x0a, 0x00, 0x08, 0x0a, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x11, 0x66, 0x69, 0x78, 0x74, 0x75,
+		0x72, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x2e, 0x4b, 0x69, 0x6e, 0x64, 0x05, 0x0a, 0x04, 0x68,
+		0x6f, 0x6d, 0x65, 0x05, 0x0a, 0x04, 0x68, 0x6f, 0x6d, 0x65, 0x0c, 0x24, 0x08, 0x03, 0x08, 0x00,
+		0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x03, 0x05, 0x0a, 0x0f, 0x66, 0x69, 0x78, 0x74, 0x75,
+		0x72, 0x65, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x04,
+		0x74, 0x61, 0x67, 0x73, 0x05, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x0c, 0x26, 0x08, 0x01, 0x08,
+		0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05,
+		0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x05, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70,
+		0x6c, 0x65, 0x73, 0x0c, 0x28, 0x08, 0x01, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08,
+		0x0d, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x05, 0x0a,
+		0x04, 0x6b, 0x65, 0x79, 0x73, 0x0c, 0x2a, 0x08, 0x01, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a,
+		0x00, 0x08, 0x04, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x07, 0x68, 0x69, 0x73, 0x74,
+		0x6f, 0x72, 0x79, 0x05, 0x0a, 0x07, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x0c, 0x2c, 0x08,
+		0x01, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0a, 0x05, 0x0a, 0x00, 0x05, 0x0a,
+		0x0e, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x05,
+		0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x05, 0x0a, 0x09, 0x61, 0x64,
+		0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x0c, 0x2e, 0x08, 0x01, 0x08, 0x00, 0x05, 0x0a, 0x00,
+		0x05, 0x0a, 0x00, 0x08, 0x03, 0x05, 0x0a, 0x0f, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e,
+		0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x08, 0x63, 0x6f, 0x75,
+		0x6e, 0x74, 0x65, 0x72, 0x73, 0x05, 0x0a, 0x08, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x73,
+		0x0c, 0x30, 0x08, 0x02, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0d, 0x05, 0x0a,
+		0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x07, 0x6f, 0x66, 0x66, 0x69, 0x63, 0x65, 0x73, 0x05, 0x0a,
+		0x07, 0x6f, 0x66, 0x66, 0x69, 0x63, 0x65, 0x73, 0x0c, 0x32, 0x08, 0x02, 0x08, 0x0c, 0x05, 0x0a,
+		0x00, 0x05, 0x0a, 0x00, 0x08, 0x03, 0x05, 0x0a, 0x0f, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65,
+		0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x08, 0x73, 0x74,
+		0x61, 0x74, 0x75, 0x73, 0x65, 0x73, 0x05, 0x0a, 0x08, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x65,
+		0x73, 0x0c, 0x34, 0x08, 0x02, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x0a, 0x05,
+		0x0a, 0x00, 0x05, 0x0a, 0x0e, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x53, 0x74, 0x61,
+		0x74, 0x75, 0x73, 0x05, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x62, 0x73, 0x05, 0x0a, 0x05, 0x62, 0x6c,
+		0x6f, 0x62, 0x73, 0x0c, 0x36, 0x08, 0x02, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08,
+		0x04, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x0c, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72,
+		0x65, 0x2e, 0x50, 0x61, 0x67, 0x65, 0x01, 0x03, 0x0a, 0x01, 0x05, 0x0a, 0x05, 0x75, 0x73, 0x65,
+		0x72, 0x73, 0x05, 0x0a, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x0c, 0x02, 0x08, 0x01, 0x08, 0x00,
+		0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x03, 0x05, 0x0a, 0x0c, 0x66, 0x69, 0x78, 0x74, 0x75,
+		0x72, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x0c, 0x66, 0x69, 0x78,
+		0x74, 0x75, 0x72, 0x65, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x01, 0x03, 0x0a, 0x04, 0x05, 0x0a, 0x04,
+		0x6e, 0x61, 0x6d, 0x65, 0x05, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x0c, 0x02, 0x08, 0x05, 0x08,
+		0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x05,
+		0x0a, 0x04, 0x6e, 0x65, 0x78, 0x74, 0x05, 0x0a, 0x04, 0x6e, 0x65, 0x78, 0x74, 0x0c, 0x04, 0x08,
+		0x03, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08, 0x03, 0x05, 0x0a, 0x0c, 0x66, 0x69,
+		0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x08,
+		0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x05, 0x0a, 0x08, 0x63, 0x68, 0x69, 0x6c, 0x64,
+		0x72, 0x65, 0x6e, 0x0c, 0x06, 0x08, 0x01, 0x08, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08,
+		0x03, 0x05, 0x0a, 0x0c, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x4e, 0x6f, 0x64, 0x65,
+		0x05, 0x0a, 0x00, 0x05, 0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x64, 0x05, 0x0a, 0x05, 0x6e, 0x61,
+		0x6d, 0x65, 0x64, 0x0c, 0x08, 0x08, 0x02, 0x08, 0x05, 0x05, 0x0a, 0x00, 0x05, 0x0a, 0x00, 0x08,
+		0x03, 0x05, 0x0a, 0x0c, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x4e, 0x6f, 0x64, 0x65,
+		0x05, 0x0a, 0x00, 0x01, 0x03, 0x0a, 0x02, 0x05, 0x0a, 0x0e, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72,
+		0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x01, 0x03, 0x0a, 0x03, 0x05, 0x0a, 0x0e, 0x53,
+		0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x0c, 0x00, 0x05,
+		0x0a, 0x0d, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x0c,
+		0x0a, 0x05, 0x0a, 0x0e, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54,
+		0x45, 0x44, 0x0c, 0x14, 0x05, 0x0a, 0x11, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x55,
+		0x73, 0x65, 0x72, 0x2e, 0x4b, 0x69, 0x6e, 0x64, 0x01, 0x03, 0x0a, 0x02, 0x05, 0x0a, 0x0a, 0x4b,
+		0x49, 0x4e, 0x44, 0x5f, 0x48, 0x55, 0x4d, 0x41, 0x4e, 0x0c, 0x00, 0x05, 0x0a, 0x0a, 0x4b, 0x49,
+		0x4e, 0x44, 0x5f, 0x52, 0x4f, 0x42, 0x4f, 0x54, 0x0c, 0x02,
+	})
+)
+
+type FixtureStatus uint32
+
+const (
+	FixtureSTATUS_UNKNOWN = FixtureStatus(0)
+	FixtureSTATUS_ACTIVE  = FixtureStatus(1)
+	FixtureSTATUS_DELETED = FixtureStatus(2)
+)
+
+var (
+	FixtureStatusName = map[FixtureStatus]string{
+		FixtureSTATUS_UNKNOWN: "STATUS_UNKNOWN",
+		FixtureSTATUS_ACTIVE:  "STATUS_ACTIVE",
+		FixtureSTATUS_DELETED: "STATUS_DELETED",
+	}
+	FixtureStatusValue = map[string]FixtureStatus{
+		"STATUS_UNKNOWN": FixtureSTATUS_UNKNOWN,
+		"STATUS_ACTIVE":  FixtureSTATUS_ACTIVE,
+		"STATUS_DELETED": FixtureSTATUS_DELETED,
+	}
+	// FixtureStatusNumber holds the proto number of every value, indexed by the value.
+	FixtureStatusNumber = []int32{
+		0,
+		5,
+		10,
+	}
+)
+
+func (x FixtureStatus) MarshalJSON() ([]byte, error) {
+	return json.MarshalEnum(FixtureStatusName, x)
+}
+
+func (x *FixtureStatus) UnmarshalJSON(b []byte) error {
+	return json.UnmarshalEnum(FixtureStatusValue, FixtureStatusNumber, b, x)
+}
+
+func (x FixtureStatus) Descriptor() *descriptor.Enum {
+	return polyglotDescriptorFixtureProto.Enums[0]
+}
+
+type FixtureAddress struct {
+	City string
+	Zip  string
+}
+
+func NewFixtureAddress() *FixtureAddress {
+	return &FixtureAddress{}
+}
+
+func (x *FixtureAddress) Descriptor() *descriptor.Message {
+	return polyglotDescriptorFixtureProto.Messages[0]
+}
+
+// Fingerprint returns the schema fingerprint of FixtureAddress, which changes
+// whenever the layout of its encoding does.
+func (x *FixtureAddress) Fingerprint() uint64 {
+	return 0x3db3c2a370ee34bc
+}
+
+func (x *FixtureAddress) Error(b *polyglot.Buffer, err error) {
+	polyglot.Encoder(b).Error(err)
+}
+
+func (x *FixtureAddress) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Nil()
+	} else {
+
+		polyglot.Encoder(b).String(x.City).String(x.Zip)
+	}
+}
+
+func (x *FixtureAddress) Decode(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decode(polyglot.Decoder(b))
+}
+
+func (x *FixtureAddress) decode(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	x.City, err = d.String()
+	if err != nil {
+		return err
+	}
+	x.Zip, err = d.String()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func skipFixtureAddress(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// EncodeFieldsZeroFill encodes the fields of x selected by mask, and the zero
+// value in place of every other field, so the result can be read with Decode.
+// A nil mask selects every field.
+//
+// Polyglot has no field-numbered encoding in which unselected fields could be
+// left out, and decoders find fields by their position, so every field is
+// written. Zero values take a few bytes each, but a reader cannot tell an
+// unselected field from one that was set to its zero value.
+func (x *FixtureAddress) EncodeFieldsZeroFill(b *polyglot.Buffer, mask *polyglot.FieldMask) {
+	if x == nil || mask == nil {
+		x.Encode(b)
+		return
+	}
+
+	if mask.Has("city") {
+		polyglot.Encoder(b).String(x.City)
+	} else {
+		polyglot.Encoder(b).String("")
+	}
+	if mask.Has("zip") {
+		polyglot.Encoder(b).String(x.Zip)
+	} else {
+		polyglot.Encoder(b).String("")
+	}
+}
+
+// DecodeFields decodes the fields of b selected by mask and skips the others
+// without allocating them. Fields that are not selected are left as they are.
+// A nil mask selects every field.
+func (x *FixtureAddress) DecodeFields(b []byte, mask *polyglot.FieldMask) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decodeFields(polyglot.Decoder(b), mask)
+}
+
+func (x *FixtureAddress) decodeFields(d *polyglot.BufferDecoder, mask *polyglot.FieldMask) error {
+	if mask == nil {
+		return x.decode(d)
+	}
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	if mask.Has("city") {
+		x.City, err = d.String()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("zip") {
+		x.Zip, err = d.String()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (x *FixtureAddress) Validate() error {
+	if x == nil {
+		return nil
+	}
+	if x.City == "" {
+		return validation.NewError("city", "is required")
+	}
+	return nil
+}
+
+func (x *FixtureAddress) MarshalJSON() ([]byte, error) {
+	if x == nil {
+		return json.Null(), nil
+	}
+	e := json.NewEncoder()
+	e.Field("city", x.City)
+	e.Field("zip", x.Zip)
+	return e.Bytes()
+}
+
+func (x *FixtureAddress) UnmarshalJSON(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	d, err := json.NewDecoder(b)
+	if err != nil {
+		return err
+	}
+	d.Field("city", "city", &x.City)
+	d.Field("zip", "zip", &x.Zip)
+	return d.Error()
+}
+
+type FixtureUserKind uint32
+
+const (
+	FixtureUserKIND_HUMAN = FixtureUserKind(0)
+	FixtureUserKIND_ROBOT = FixtureUserKind(1)
+)
+
+var (
+	FixtureUserKindName = map[FixtureUserKind]string{
+		FixtureUserKIND_HUMAN: "KIND_HUMAN",
+		FixtureUserKIND_ROBOT: "KIND_ROBOT",
+	}
+	FixtureUserKindValue = map[string]FixtureUserKind{
+		"KIND_HUMAN": FixtureUserKIND_HUMAN,
+		"KIND_ROBOT": FixtureUserKIND_ROBOT,
+	}
+	// FixtureUserKindNumber holds the proto number of every value, indexed by the value.
+	FixtureUserKindNumber = []int32{
+		0,
+		1,
+	}
+)
+
+func (x FixtureUserKind) MarshalJSON() ([]byte, error) {
+	return json.MarshalEnum(FixtureUserKindName, x)
+}
+
+func (x *FixtureUserKind) UnmarshalJSON(b []byte) error {
+	return json.UnmarshalEnum(FixtureUserKindValue, FixtureUserKindNumber, b, x)
+}
+
+func (x FixtureUserKind) Descriptor() *descriptor.Enum {
+	return polyglotDescriptorFixtureProto.Enums[1]
+}
+
+type FixtureUserCountersMap map[string]int64
+
+func NewFixtureUserCountersMap(size uint32) map[string]int64 {
+	return make(map[string]int64, size)
+}
+
+func (x FixtureUserCountersMap) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.Int64Kind)
+	} else {
+		polyglot.Encoder(b).Map(uint32(len(x)), polyglot.StringKind, polyglot.Int64Kind)
+		for k, v := range x {
+			polyglot.Encoder(b).String(k)
+			polyglot.Encoder(b).Int64(v)
+		}
+	}
+}
+
+func (x FixtureUserCountersMap) decode(d *polyglot.BufferDecoder, size uint32) error {
+	if size == 0 {
+		return nil
+	}
+	var k string
+	var v int64
+	var err error
+	for i := uint32(0); i < size; i++ {
+		k, err = d.String()
+		if err != nil {
+			return err
+		}
+		v, err = d.Int64()
+		if err != nil {
+			return err
+		}
+		x[k] = v
+	}
+	return nil
+}
+
+type FixtureUserOfficesMap map[int32]*FixtureAddress
+
+func NewFixtureUserOfficesMap(size uint32) map[int32]*FixtureAddress {
+	return make(map[int32]*FixtureAddress, size)
+}
+
+func (x FixtureUserOfficesMap) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Map(0, polyglot.Int32Kind, polyglot.AnyKind)
+	} else {
+		polyglot.Encoder(b).Map(uint32(len(x)), polyglot.Int32Kind, polyglot.AnyKind)
+		for k, v := range x {
+			polyglot.Encoder(b).Int32(k)
+			v.Encode(b)
+		}
+	}
+}
+
+func (x FixtureUserOfficesMap) decode(d *polyglot.BufferDecoder, size uint32) error {
+	if size == 0 {
+		return nil
+	}
+	var k int32
+	var v *FixtureAddress
+	var err error
+	for i := uint32(0); i < size; i++ {
+		k, err = d.Int32()
+		if err != nil {
+			return err
+		}
+		v = NewFixtureAddress()
+		err = v.decode(d)
+		if err != nil {
+			return err
+		}
+		x[k] = v
+	}
+	return nil
+}
+
+type FixtureUserStatusesMap map[string]FixtureStatus
+
+func NewFixtureUserStatusesMap(size uint32) map[string]FixtureStatus {
+	return make(map[string]FixtureStatus, size)
+}
+
+func (x FixtureUserStatusesMap) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.Uint32Kind)
+	} else {
+		polyglot.Encoder(b).Map(uint32(len(x)), polyglot.StringKind, polyglot.Uint32Kind)
+		for k, v := range x {
+			polyglot.Encoder(b).String(k)
+			polyglot.Encoder(b).Uint32(uint32(v))
+		}
+	}
+}
+
+func (x FixtureUserStatusesMap) decode(d *polyglot.BufferDecoder, size uint32) error {
+	if size == 0 {
+		return nil
+	}
+	var k string
+	var v FixtureStatus
+	var ValueTemp uint32
+	var err error
+	for i := uint32(0); i < size; i++ {
+		k, err = d.String()
+		if err != nil {
+			return err
+		}
+		ValueTemp, err = d.Uint32()
+		v = FixtureStatus(ValueTemp)
+		if err != nil {
+			return err
+		}
+		x[k] = v
+	}
+	return nil
+}
+
+type FixtureUserBlobsMap map[string][]byte
+
+func NewFixtureUserBlobsMap(size uint32) map[string][]byte {
+	return make(map[string][]byte, size)
+}
+
+func (x FixtureUserBlobsMap) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.BytesKind)
+	} else {
+		polyglot.Encoder(b).Map(uint32(len(x)), polyglot.StringKind, polyglot.BytesKind)
+		for k, v := range x {
+			polyglot.Encoder(b).String(k)
+			polyglot.Encoder(b).Bytes(v)
+		}
+	}
+}
+
+func (x FixtureUserBlobsMap) decode(d *polyglot.BufferDecoder, size uint32) error {
+	if size == 0 {
+		return nil
+	}
+	var k string
+	var v []byte
+	var err error
+	for i := uint32(0); i < size; i++ {
+		k, err = d.String()
+		if err != nil {
+			return err
+		}
+		v, err = d.Bytes(nil)
+		if err != nil {
+			return err
+		}
+		x[k] = v
+	}
+	return nil
+}
+
+type FixtureUser struct {
+	UserID    string                 `db:"id" validate:"required"`
+	Name      string                 `db:"name"`
+	Balance   json1.Number           `db:"balance"`
+	Timeout   time.Duration          `db:"timeout"`
+	Admin     bool                   `db:"admin"`
+	Age       int32                  `db:"age"`
+	Delta     int32                  `db:"delta"`
+	Logins    uint32                 `db:"logins"`
+	Offset    int64                  `db:"offset"`
+	Visits    uint64                 `db:"visits"`
+	Flags     uint64                 `db:"flags"`
+	Ratio     float32                `db:"ratio"`
+	Score     float64                `db:"score"`
+	Avatar    []byte                 `db:"avatar"`
+	Status    FixtureStatus          `db:"status"`
+	Kind      FixtureUserKind        `db:"kind"`
+	Home      *FixtureAddress        `db:"home"`
+	Tags      []string               `db:"tags"`
+	Samples   []int64                `db:"samples"`
+	Keys      [][]byte               `db:"keys"`
+	History   []FixtureStatus        `db:"history"`
+	Addresses []*FixtureAddress      `db:"addresses"`
+	Counters  FixtureUserCountersMap `db:"counters"`
+	Offices   FixtureUserOfficesMap  `db:"offices"`
+	Statuses  FixtureUserStatusesMap `db:"statuses"`
+	Blobs     FixtureUserBlobsMap    `db:"blobs"`
+}
+
+func NewFixtureUser() *FixtureUser {
+	return &FixtureUser{}
+}
+
+func (x *FixtureUser) Descriptor() *descriptor.Message {
+	return polyglotDescriptorFixtureProto.Messages[1]
+}
+
+// Fingerprint returns the schema fingerprint of FixtureUser, which changes
+// whenever the layout of its encoding does.
+func (x *FixtureUser) Fingerprint() uint64 {
+	return 0xfe8c18e871d02f88
+}
+
+func (x *FixtureUser) Error(b *polyglot.Buffer, err error) {
+	polyglot.Encoder(b).Error(err)
+}
+
+func (x *FixtureUser) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Nil()
+	} else {
+
+		polyglot.Encoder(b).String(x.UserID).String(x.Name).String(string(x.Balance)).Int64(int64(x.Timeout)).Bool(x.Admin).Int32(x.Age).Int32(x.Delta).Uint32(x.Logins).Int64(x.Offset).Uint64(x.Visits).Uint64(x.Flags).Float32(x.Ratio).Float64(x.Score).Bytes(x.Avatar).Uint32(uint32(x.Status)).Uint32(uint32(x.Kind))
+		polyglot.Encoder(b).Slice(uint32(len(x.Tags)), polyglot.StringKind)
+		for _, v := range x.Tags {
+			polyglot.Encoder(b).String(v)
+		}
+		polyglot.Encoder(b).Slice(uint32(len(x.Samples)), polyglot.Int64Kind)
+		for _, v := range x.Samples {
+			polyglot.Encoder(b).Int64(v)
+		}
+		polyglot.Encoder(b).Slice(uint32(len(x.Keys)), polyglot.BytesKind)
+		for _, v := range x.Keys {
+			polyglot.Encoder(b).Bytes(v)
+		}
+		polyglot.Encoder(b).Slice(uint32(len(x.History)), polyglot.Uint32Kind)
+		for _, v := range x.History {
+			polyglot.Encoder(b).Uint32(uint32(v))
+		}
+		polyglot.Encoder(b).Slice(uint32(len(x.Addresses)), polyglot.AnyKind)
+		for _, v := range x.Addresses {
+			v.Encode(b)
+		}
+
+		x.Home.Encode(b)
+		x.Counters.Encode(b)
+		x.Offices.Encode(b)
+		x.Statuses.Encode(b)
+		x.Blobs.Encode(b)
+	}
+}
+
+func (x *FixtureUser) Decode(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decode(polyglot.Decoder(b))
+}
+
+func (x *FixtureUser) decode(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	x.UserID, err = d.String()
+	if err != nil {
+		return err
+	}
+	x.Name, err = d.String()
+	if err != nil {
+		return err
+	}
+	var BalanceTemp string
+	BalanceTemp, err = d.String()
+	x.Balance = json1.Number(BalanceTemp)
+	if err != nil {
+		return err
+	}
+	var TimeoutTemp int64
+	TimeoutTemp, err = d.Int64()
+	x.Timeout = time.Duration(TimeoutTemp)
+	if err != nil {
+		return err
+	}
+	x.Admin, err = d.Bool()
+	if err != nil {
+		return err
+	}
+	x.Age, err = d.Int32()
+	if err != nil {
+		return err
+	}
+	x.Delta, err = d.Int32()
+	if err != nil {
+		return err
+	}
+	x.Logins, err = d.Uint32()
+	if err != nil {
+		return err
+	}
+	x.Offset, err = d.Int64()
+	if err != nil {
+		return err
+	}
+	x.Visits, err = d.Uint64()
+	if err != nil {
+		return err
+	}
+	x.Flags, err = d.Uint64()
+	if err != nil {
+		return err
+	}
+	x.Ratio, err = d.Float32()
+	if err != nil {
+		return err
+	}
+	x.Score, err = d.Float64()
+	if err != nil {
+		return err
+	}
+	x.Avatar, err = d.Bytes(x.Avatar)
+	if err != nil {
+		return err
+	}
+	var StatusTemp uint32
+	StatusTemp, err = d.Uint32()
+	x.Status = FixtureStatus(StatusTemp)
+	if err != nil {
+		return err
+	}
+	var KindTemp uint32
+	KindTemp, err = d.Uint32()
+	x.Kind = FixtureUserKind(KindTemp)
+	if err != nil {
+		return err
+	}
+	var sliceSize uint32
+	sliceSize, err = d.Slice(polyglot.StringKind)
+	if err != nil {
+		return err
+	}
+	if uint32(len(x.Tags)) != sliceSize {
+		x.Tags = make([]string, sliceSize)
+	}
+	for i := uint32(0); i < sliceSize; i++ {
+		x.Tags[i], err = d.String()
+		if err != nil {
+			return err
+		}
+	}
+	sliceSize, err = d.Slice(polyglot.Int64Kind)
+	if err != nil {
+		return err
+	}
+	if uint32(len(x.Samples)) != sliceSize {
+		x.Samples = make([]int64, sliceSize)
+	}
+	for i := uint32(0); i < sliceSize; i++ {
+		x.Samples[i], err = d.Int64()
+		if err != nil {
+			return err
+		}
+	}
+	sliceSize, err = d.Slice(polyglot.BytesKind)
+	if err != nil {
+		return err
+	}
+	if uint32(len(x.Keys)) != sliceSize {
+		x.Keys = make([][]byte, sliceSize)
+	}
+	for i := uint32(0); i < sliceSize; i++ {
+		x.Keys[i], err = d.Bytes(nil)
+		if err != nil {
+			return err
+		}
+	}
+	sliceSize, err = d.Slice(polyglot.Uint32Kind)
+	if err != nil {
+		return err
+	}
+	if uint32(len(x.History)) != sliceSize {
+		x.History = make([]FixtureStatus, sliceSize)
+	}
+	for i := uint32(0); i < sliceSize; i++ {
+		var HistoryTemp uint32
+		HistoryTemp, err = d.Uint32()
+		x.History[i] = FixtureStatus(HistoryTemp)
+		if err != nil {
+			return err
+		}
+	}
+	sliceSize, err = d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	if uint32(len(x.Addresses)) != sliceSize {
+		x.Addresses = make([]*FixtureAddress, sliceSize)
+	}
+	for i := uint32(0); i < sliceSize; i++ {
+		if x.Addresses[i] == nil {
+			x.Addresses[i] = NewFixtureAddress()
+		}
+		err = x.Addresses[i].decode(d)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Nil() {
+		x.Home = NewFixtureAddress()
+		err = x.Home.decode(d)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Nil() {
+		CountersSize, err := d.Map(polyglot.StringKind, polyglot.Int64Kind)
+		if err != nil {
+			return err
+		}
+		x.Counters = NewFixtureUserCountersMap(CountersSize)
+		err = x.Counters.decode(d, CountersSize)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Nil() {
+		OfficesSize, err := d.Map(polyglot.Int32Kind, polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		x.Offices = NewFixtureUserOfficesMap(OfficesSize)
+		err = x.Offices.decode(d, OfficesSize)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Nil() {
+		StatusesSize, err := d.Map(polyglot.StringKind, polyglot.Uint32Kind)
+		if err != nil {
+			return err
+		}
+		x.Statuses = NewFixtureUserStatusesMap(StatusesSize)
+		err = x.Statuses.decode(d, StatusesSize)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Nil() {
+		BlobsSize, err := d.Map(polyglot.StringKind, polyglot.BytesKind)
+		if err != nil {
+			return err
+		}
+		x.Blobs = NewFixtureUserBlobsMap(BlobsSize)
+		err = x.Blobs.decode(d, BlobsSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skipFixtureUser(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	{
+		size, err := d.Slice(polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		for i := uint32(0); i < size; i++ {
+			if err = skipFixtureAddress(d); err != nil {
+				return err
+			}
+		}
+	}
+	if err := skipFixtureAddress(d); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if !d.Nil() {
+		size, err := d.Map(polyglot.Int32Kind, polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		for i := uint32(0); i < size; i++ {
+			if err = d.Skip(); err != nil {
+				return err
+			}
+			if err = skipFixtureAddress(d); err != nil {
+				return err
+			}
+		}
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// EncodeFieldsZeroFill encodes the fields of x selected by mask, and the zero
+// value in place of every other field, so the result can be read with Decode.
+// A nil mask selects every field.
+//
+// Polyglot has no field-numbered encoding in which unselected fields could be
+// left out, and decoders find fields by their position, so every field is
+// written. Zero values take a few bytes each, but a reader cannot tell an
+// unselected field from one that was set to its zero value.
+func (x *FixtureUser) EncodeFieldsZeroFill(b *polyglot.Buffer, mask *polyglot.FieldMask) {
+	if x == nil || mask == nil {
+		x.Encode(b)
+		return
+	}
+
+	if mask.Has("id") {
+		polyglot.Encoder(b).String(x.UserID)
+	} else {
+		polyglot.Encoder(b).String("")
+	}
+	if mask.Has("name") {
+		polyglot.Encoder(b).String(x.Name)
+	} else {
+		polyglot.Encoder(b).String("")
+	}
+	if mask.Has("balance") {
+		polyglot.Encoder(b).String(string(x.Balance))
+	} else {
+		polyglot.Encoder(b).String("")
+	}
+	if mask.Has("timeout") {
+		polyglot.Encoder(b).Int64(int64(x.Timeout))
+	} else {
+		polyglot.Encoder(b).Int64(0)
+	}
+	if mask.Has("admin") {
+		polyglot.Encoder(b).Bool(x.Admin)
+	} else {
+		polyglot.Encoder(b).Bool(false)
+	}
+	if mask.Has("age") {
+		polyglot.Encoder(b).Int32(x.Age)
+	} else {
+		polyglot.Encoder(b).Int32(0)
+	}
+	if mask.Has("delta") {
+		polyglot.Encoder(b).Int32(x.Delta)
+	} else {
+		polyglot.Encoder(b).Int32(0)
+	}
+	if mask.Has("logins") {
+		polyglot.Encoder(b).Uint32(x.Logins)
+	} else {
+		polyglot.Encoder(b).Uint32(0)
+	}
+	if mask.Has("offset") {
+		polyglot.Encoder(b).Int64(x.Offset)
+	} else {
+		polyglot.Encoder(b).Int64(0)
+	}
+	if mask.Has("visits") {
+		polyglot.Encoder(b).Uint64(x.Visits)
+	} else {
+		polyglot.Encoder(b).Uint64(0)
+	}
+	if mask.Has("flags") {
+		polyglot.Encoder(b).Uint64(x.Flags)
+	} else {
+		polyglot.Encoder(b).Uint64(0)
+	}
+	if mask.Has("ratio") {
+		polyglot.Encoder(b).Float32(x.Ratio)
+	} else {
+		polyglot.Encoder(b).Float32(0)
+	}
+	if mask.Has("score") {
+		polyglot.Encoder(b).Float64(x.Score)
+	} else {
+		polyglot.Encoder(b).Float64(0)
+	}
+	if mask.Has("avatar") {
+		polyglot.Encoder(b).Bytes(x.Avatar)
+	} else {
+		polyglot.Encoder(b).Bytes(nil)
+	}
+	if mask.Has("status") {
+		polyglot.Encoder(b).Uint32(uint32(x.Status))
+	} else {
+		polyglot.Encoder(b).Uint32(0)
+	}
+	if mask.Has("kind") {
+		polyglot.Encoder(b).Uint32(uint32(x.Kind))
+	} else {
+		polyglot.Encoder(b).Uint32(0)
+	}
+	if mask.Has("tags") {
+		polyglot.Encoder(b).Slice(uint32(len(x.Tags)), polyglot.StringKind)
+		for _, v := range x.Tags {
+			polyglot.Encoder(b).String(v)
+		}
+	} else {
+		polyglot.Encoder(b).Slice(0, polyglot.StringKind)
+	}
+	if mask.Has("samples") {
+		polyglot.Encoder(b).Slice(uint32(len(x.Samples)), polyglot.Int64Kind)
+		for _, v := range x.Samples {
+			polyglot.Encoder(b).Int64(v)
+		}
+	} else {
+		polyglot.Encoder(b).Slice(0, polyglot.Int64Kind)
+	}
+	if mask.Has("keys") {
+		polyglot.Encoder(b).Slice(uint32(len(x.Keys)), polyglot.BytesKind)
+		for _, v := range x.Keys {
+			polyglot.Encoder(b).Bytes(v)
+		}
+	} else {
+		polyglot.Encoder(b).Slice(0, polyglot.BytesKind)
+	}
+	if mask.Has("history") {
+		polyglot.Encoder(b).Slice(uint32(len(x.History)), polyglot.Uint32Kind)
+		for _, v := range x.History {
+			polyglot.Encoder(b).Uint32(uint32(v))
+		}
+	} else {
+		polyglot.Encoder(b).Slice(0, polyglot.Uint32Kind)
+	}
+	if mask.Has("addresses") {
+		polyglot.Encoder(b).Slice(uint32(len(x.Addresses)), polyglot.AnyKind)
+		sub := mask.Sub("addresses")
+		for _, v := range x.Addresses {
+			v.EncodeFieldsZeroFill(b, sub)
+		}
+	} else {
+		polyglot.Encoder(b).Slice(0, polyglot.AnyKind)
+	}
+	if mask.Has("home") {
+		x.Home.EncodeFieldsZeroFill(b, mask.Sub("home"))
+	} else {
+		polyglot.Encoder(b).Nil()
+	}
+	if mask.Has("counters") {
+		x.Counters.Encode(b)
+	} else {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.Int64Kind)
+	}
+	if mask.Has("offices") {
+		x.Offices.Encode(b)
+	} else {
+		polyglot.Encoder(b).Map(0, polyglot.Int32Kind, polyglot.AnyKind)
+	}
+	if mask.Has("statuses") {
+		x.Statuses.Encode(b)
+	} else {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.Uint32Kind)
+	}
+	if mask.Has("blobs") {
+		x.Blobs.Encode(b)
+	} else {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.BytesKind)
+	}
+}
+
+// DecodeFields decodes the fields of b selected by mask and skips the others
+// without allocating them. Fields that are not selected are left as they are.
+// A nil mask selects every field.
+func (x *FixtureUser) DecodeFields(b []byte, mask *polyglot.FieldMask) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decodeFields(polyglot.Decoder(b), mask)
+}
+
+func (x *FixtureUser) decodeFields(d *polyglot.BufferDecoder, mask *polyglot.FieldMask) error {
+	if mask == nil {
+		return x.decode(d)
+	}
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	if mask.Has("id") {
+		x.UserID, err = d.String()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("name") {
+		x.Name, err = d.String()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("balance") {
+		var BalanceTemp string
+		BalanceTemp, err = d.String()
+		x.Balance = json1.Number(BalanceTemp)
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("timeout") {
+		var TimeoutTemp int64
+		TimeoutTemp, err = d.Int64()
+		x.Timeout = time.Duration(TimeoutTemp)
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("admin") {
+		x.Admin, err = d.Bool()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("age") {
+		x.Age, err = d.Int32()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("delta") {
+		x.Delta, err = d.Int32()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("logins") {
+		x.Logins, err = d.Uint32()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("offset") {
+		x.Offset, err = d.Int64()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("visits") {
+		x.Visits, err = d.Uint64()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("flags") {
+		x.Flags, err = d.Uint64()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("ratio") {
+		x.Ratio, err = d.Float32()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("score") {
+		x.Score, err = d.Float64()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("avatar") {
+		x.Avatar, err = d.Bytes(x.Avatar)
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("status") {
+		var StatusTemp uint32
+		StatusTemp, err = d.Uint32()
+		x.Status = FixtureStatus(StatusTemp)
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	if mask.Has("kind") {
+		var KindTemp uint32
+		KindTemp, err = d.Uint32()
+		x.Kind = FixtureUserKind(KindTemp)
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	var sliceSize uint32
+	if mask.Has("tags") {
+		sliceSize, err = d.Slice(polyglot.StringKind)
+		if err != nil {
+			return err
+		}
+		if uint32(len(x.Tags)) != sliceSize {
+			x.Tags = make([]string, sliceSize)
+		}
+		for i := uint32(0); i < sliceSize; i++ {
+			x.Tags[i], err = d.String()
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	if mask.Has("samples") {
+		sliceSize, err = d.Slice(polyglot.Int64Kind)
+		if err != nil {
+			return err
+		}
+		if uint32(len(x.Samples)) != sliceSize {
+			x.Samples = make([]int64, sliceSize)
+		}
+		for i := uint32(0); i < sliceSize; i++ {
+			x.Samples[i], err = d.Int64()
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	if mask.Has("keys") {
+		sliceSize, err = d.Slice(polyglot.BytesKind)
+		if err != nil {
+			return err
+		}
+		if uint32(len(x.Keys)) != sliceSize {
+			x.Keys = make([][]byte, sliceSize)
+		}
+		for i := uint32(0); i < sliceSize; i++ {
+			x.Keys[i], err = d.Bytes(nil)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	if mask.Has("history") {
+		sliceSize, err = d.Slice(polyglot.Uint32Kind)
+		if err != nil {
+			return err
+		}
+		if uint32(len(x.History)) != sliceSize {
+			x.History = make([]FixtureStatus, sliceSize)
+		}
+		for i := uint32(0); i < sliceSize; i++ {
+			var HistoryTemp uint32
+			HistoryTemp, err = d.Uint32()
+			x.History[i] = FixtureStatus(HistoryTemp)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	if mask.Has("addresses") {
+		sliceSize, err = d.Slice(polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		if uint32(len(x.Addresses)) != sliceSize {
+			x.Addresses = make([]*FixtureAddress, sliceSize)
+		}
+		sub := mask.Sub("addresses")
+		for i := uint32(0); i < sliceSize; i++ {
+			if x.Addresses[i] == nil {
+				x.Addresses[i] = NewFixtureAddress()
+			}
+			if err = x.Addresses[i].decodeFields(d, sub); err != nil {
+				return err
+			}
+		}
+	} else {
+		{
+			size, err := d.Slice(polyglot.AnyKind)
+			if err != nil {
+				return err
+			}
+			for i := uint32(0); i < size; i++ {
+				if err = skipFixtureAddress(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if mask.Has("home") {
+		if !d.Nil() {
+			x.Home = NewFixtureAddress()
+			if err = x.Home.decodeFields(d, mask.Sub("home")); err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := skipFixtureAddress(d); err != nil {
+			return err
+		}
+	}
+	if mask.Has("counters") {
+		if !d.Nil() {
+			CountersSize, err := d.Map(polyglot.StringKind, polyglot.Int64Kind)
+			if err != nil {
+				return err
+			}
+			x.Counters = NewFixtureUserCountersMap(CountersSize)
+			err = x.Counters.decode(d, CountersSize)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	if mask.Has("offices") {
+		if !d.Nil() {
+			OfficesSize, err := d.Map(polyglot.Int32Kind, polyglot.AnyKind)
+			if err != nil {
+				return err
+			}
+			x.Offices = NewFixtureUserOfficesMap(OfficesSize)
+			err = x.Offices.decode(d, OfficesSize)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if !d.Nil() {
+			size, err := d.Map(polyglot.Int32Kind, polyglot.AnyKind)
+			if err != nil {
+				return err
+			}
+			for i := uint32(0); i < size; i++ {
+				if err = d.Skip(); err != nil {
+					return err
+				}
+				if err = skipFixtureAddress(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if mask.Has("statuses") {
+		if !d.Nil() {
+			StatusesSize, err := d.Map(polyglot.StringKind, polyglot.Uint32Kind)
+			if err != nil {
+				return err
+			}
+			x.Statuses = NewFixtureUserStatusesMap(StatusesSize)
+			err = x.Statuses.decode(d, StatusesSize)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	if mask.Has("blobs") {
+		if !d.Nil() {
+			BlobsSize, err := d.Map(polyglot.StringKind, polyglot.BytesKind)
+			if err != nil {
+				return err
+			}
+			x.Blobs = NewFixtureUserBlobsMap(BlobsSize)
+			err = x.Blobs.decode(d, BlobsSize)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *FixtureUser) Validate() error {
+	if x == nil {
+		return nil
+	}
+	if utf8.RuneCountInString(string(x.Name)) < 1 {
+		return validation.NewError("name", "must be at least 1 characters long")
+	}
+	if utf8.RuneCountInString(string(x.Name)) > 32 {
+		return validation.NewError("name", "must be at most 32 characters long")
+	}
+	if x.Age < 0 {
+		return validation.NewError("age", "must be greater than or equal to 0")
+	}
+	if x.Age >= 150 {
+		return validation.NewError("age", "must be less than 150")
+	}
+	if x.Offset <= -9223372036854775808 {
+		return validation.NewError("offset", "must be greater than -9223372036854775808")
+	}
+	if x.Offset >= 9223372036854774784 {
+		return validation.NewError("offset", "must be less than 9223372036854774784")
+	}
+	if x.Visits > 18446744073709549568 {
+		return validation.NewError("visits", "must be less than or equal to 18446744073709549568")
+	}
+	if FixtureStatusName[x.Status] == "" {
+		return validation.NewError("status", "must be a defined enum value")
+	}
+	if err := x.Home.Validate(); err != nil {
+		return validation.Prefix("home", err)
+	}
+	if len(x.Tags) > 8 {
+		return validation.NewError("tags", "must have at most 8 items")
+	}
+	for i, v := range x.Addresses {
+		if err := v.Validate(); err != nil {
+			return validation.PrefixIndex("addresses", i, err)
+		}
+	}
+	for k, v := range x.Offices {
+		if err := v.Validate(); err != nil {
+			return validation.PrefixKey("offices", k, err)
+		}
+	}
+	return nil
+}
+
+func (x *FixtureUser) MarshalJSON() ([]byte, error) {
+	if x == nil {
+		return json.Null(), nil
+	}
+	e := json.NewEncoder()
+	e.Field("id", x.UserID)
+	e.Field("name", x.Name)
+	e.Field("balance", x.Balance)
+	e.Field("timeout", x.Timeout)
+	e.Field("admin", x.Admin)
+	e.Field("age", x.Age)
+	e.Field("delta", x.Delta)
+	e.Field("logins", x.Logins)
+	e.Field("offset", x.Offset)
+	e.Field("visits", x.Visits)
+	e.Field("flags", x.Flags)
+	e.Field("ratio", x.Ratio)
+	e.Field("score", x.Score)
+	e.Field("avatar", x.Avatar)
+	e.Field("status", x.Status)
+	e.Field("kind", x.Kind)
+	e.Field("home", x.Home)
+	e.Field("tags", x.Tags)
+	e.Field("samples", x.Samples)
+	e.Field("keys", x.Keys)
+	e.Field("history", x.History)
+	e.Field("addresses", x.Addresses)
+	e.Field("counters", x.Counters)
+	e.Field("offices", x.Offices)
+	e.Field("statuses", x.Statuses)
+	e.Field("blobs", x.Blobs)
+	return e.Bytes()
+}
+
+func (x *FixtureUser) UnmarshalJSON(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	d, err := json.NewDecoder(b)
+	if err != nil {
+		return err
+	}
+	d.Field("id", "id", &x.UserID)
+	d.Field("name", "name", &x.Name)
+	d.Field("balance", "balance", &x.Balance)
+	d.Field("timeout", "timeout", &x.Timeout)
+	d.Field("admin", "admin", &x.Admin)
+	d.Field("age", "age", &x.Age)
+	d.Field("delta", "delta", &x.Delta)
+	d.Field("logins", "logins", &x.Logins)
+	d.Field("offset", "offset", &x.Offset)
+	d.Field("visits", "visits", &x.Visits)
+	d.Field("flags", "flags", &x.Flags)
+	d.Field("ratio", "ratio", &x.Ratio)
+	d.Field("score", "score", &x.Score)
+	d.Field("avatar", "avatar", &x.Avatar)
+	d.Field("status", "status", &x.Status)
+	d.Field("kind", "kind", &x.Kind)
+	d.Field("home", "home", &x.Home)
+	d.Field("tags", "tags", &x.Tags)
+	d.Field("samples", "samples", &x.Samples)
+	d.Field("keys", "keys", &x.Keys)
+	d.Field("history", "history", &x.History)
+	d.Field("addresses", "addresses", &x.Addresses)
+	d.Field("counters", "counters", &x.Counters)
+	d.Field("offices", "offices", &x.Offices)
+	d.Field("statuses", "statuses", &x.Statuses)
+	d.Field("blobs", "blobs", &x.Blobs)
+	return d.Error()
+}
+
+type FixturePage struct {
+	Users []*FixtureUser
+}
+
+func NewFixturePage() *FixturePage {
+	return &FixturePage{}
+}
+
+func (x *FixturePage) Descriptor() *descriptor.Message {
+	return polyglotDescriptorFixtureProto.Messages[2]
+}
+
+// Fingerprint returns the schema fingerprint of FixturePage, which changes
+// whenever the layout of its encoding does.
+func (x *FixturePage) Fingerprint() uint64 {
+	return 0x67f98621fc88c27a
+}
+
+func (x *FixturePage) Error(b *polyglot.Buffer, err error) {
+	polyglot.Encoder(b).Error(err)
+}
+
+func (x *FixturePage) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Nil()
+	} else {
+
+		polyglot.Encoder(b).Slice(uint32(len(x.Users)), polyglot.AnyKind)
+		for _, v := range x.Users {
+			v.Encode(b)
+		}
+	}
+}
+
+func (x *FixturePage) Decode(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decode(polyglot.Decoder(b))
+}
+
+func (x *FixturePage) decode(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	var sliceSize uint32
+	sliceSize, err = d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	if uint32(len(x.Users)) != sliceSize {
+		x.Users = make([]*FixtureUser, sliceSize)
+	}
+	for i := uint32(0); i < sliceSize; i++ {
+		if x.Users[i] == nil {
+			x.Users[i] = NewFixtureUser()
+		}
+		err = x.Users[i].decode(d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skipFixturePage(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+	{
+		size, err := d.Slice(polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		for i := uint32(0); i < size; i++ {
+			if err = skipFixtureUser(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EncodeFieldsZeroFill encodes the fields of x selected by mask, and the zero
+// value in place of every other field, so the result can be read with Decode.
+// A nil mask selects every field.
+//
+// Polyglot has no field-numbered encoding in which unselected fields could be
+// left out, and decoders find fields by their position, so every field is
+// written. Zero values take a few bytes each, but a reader cannot tell an
+// unselected field from one that was set to its zero value.
+func (x *FixturePage) EncodeFieldsZeroFill(b *polyglot.Buffer, mask *polyglot.FieldMask) {
+	if x == nil || mask == nil {
+		x.Encode(b)
+		return
+	}
+
+	if mask.Has("users") {
+		polyglot.Encoder(b).Slice(uint32(len(x.Users)), polyglot.AnyKind)
+		sub := mask.Sub("users")
+		for _, v := range x.Users {
+			v.EncodeFieldsZeroFill(b, sub)
+		}
+	} else {
+		polyglot.Encoder(b).Slice(0, polyglot.AnyKind)
+	}
+}
+
+// DecodeFields decodes the fields of b selected by mask and skips the others
+// without allocating them. Fields that are not selected are left as they are.
+// A nil mask selects every field.
+func (x *FixturePage) DecodeFields(b []byte, mask *polyglot.FieldMask) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decodeFields(polyglot.Decoder(b), mask)
+}
+
+func (x *FixturePage) decodeFields(d *polyglot.BufferDecoder, mask *polyglot.FieldMask) error {
+	if mask == nil {
+		return x.decode(d)
+	}
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	var sliceSize uint32
+	if mask.Has("users") {
+		sliceSize, err = d.Slice(polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		if uint32(len(x.Users)) != sliceSize {
+			x.Users = make([]*FixtureUser, sliceSize)
+		}
+		sub := mask.Sub("users")
+		for i := uint32(0); i < sliceSize; i++ {
+			if x.Users[i] == nil {
+				x.Users[i] = NewFixtureUser()
+			}
+			if err = x.Users[i].decodeFields(d, sub); err != nil {
+				return err
+			}
+		}
+	} else {
+		{
+			size, err := d.Slice(polyglot.AnyKind)
+			if err != nil {
+				return err
+			}
+			for i := uint32(0); i < size; i++ {
+				if err = skipFixtureUser(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (x *FixturePage) Validate() error {
+	if x == nil {
+		return nil
+	}
+	for i, v := range x.Users {
+		if err := v.Validate(); err != nil {
+			return validation.PrefixIndex("users", i, err)
+		}
+	}
+	return nil
+}
+
+func (x *FixturePage) MarshalJSON() ([]byte, error) {
+	if x == nil {
+		return json.Null(), nil
+	}
+	e := json.NewEncoder()
+	e.Field("users", x.Users)
+	return e.Bytes()
+}
+
+func (x *FixturePage) UnmarshalJSON(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	d, err := json.NewDecoder(b)
+	if err != nil {
+		return err
+	}
+	d.Field("users", "users", &x.Users)
+	return d.Error()
+}
+
+type FixtureNodeNamedMap map[string]*FixtureNode
+
+func NewFixtureNodeNamedMap(size uint32) map[string]*FixtureNode {
+	return make(map[string]*FixtureNode, size)
+}
+
+func (x FixtureNodeNamedMap) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.AnyKind)
+	} else {
+		polyglot.Encoder(b).Map(uint32(len(x)), polyglot.StringKind, polyglot.AnyKind)
+		for k, v := range x {
+			polyglot.Encoder(b).String(k)
+			v.Encode(b)
+		}
+	}
+}
+
+func (x FixtureNodeNamedMap) decode(d *polyglot.BufferDecoder, size uint32) error {
+	if size == 0 {
+		return nil
+	}
+	var k string
+	var v *FixtureNode
+	var err error
+	for i := uint32(0); i < size; i++ {
+		k, err = d.String()
+		if err != nil {
+			return err
+		}
+		v = NewFixtureNode()
+		err = v.decode(d)
+		if err != nil {
+			return err
+		}
+		x[k] = v
+	}
+	return nil
+}
+
+type FixtureNode struct {
+	Name     string
+	Next     *FixtureNode
+	Children []*FixtureNode
+	Named    FixtureNodeNamedMap
+}
+
+func NewFixtureNode() *FixtureNode {
+	return &FixtureNode{}
+}
+
+func (x *FixtureNode) Descriptor() *descriptor.Message {
+	return polyglotDescriptorFixtureProto.Messages[3]
+}
+
+// Fingerprint returns the schema fingerprint of FixtureNode, which changes
+// whenever the layout of its encoding does.
+func (x *FixtureNode) Fingerprint() uint64 {
+	return 0x2e65389b34a328ec
+}
+
+func (x *FixtureNode) Error(b *polyglot.Buffer, err error) {
+	polyglot.Encoder(b).Error(err)
+}
+
+func (x *FixtureNode) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Nil()
+	} else {
+
+		polyglot.Encoder(b).String(x.Name)
+		polyglot.Encoder(b).Slice(uint32(len(x.Children)), polyglot.AnyKind)
+		for _, v := range x.Children {
+			v.Encode(b)
+		}
+
+		x.Next.Encode(b)
+		x.Named.Encode(b)
+	}
+}
+
+func (x *FixtureNode) Decode(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decode(polyglot.Decoder(b))
+}
+
+func (x *FixtureNode) decode(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	x.Name, err = d.String()
+	if err != nil {
+		return err
+	}
+	var sliceSize uint32
+	sliceSize, err = d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	if uint32(len(x.Children)) != sliceSize {
+		x.Children = make([]*FixtureNode, sliceSize)
+	}
+	for i := uint32(0); i < sliceSize; i++ {
+		if x.Children[i] == nil {
+			x.Children[i] = NewFixtureNode()
+		}
+		err = x.Children[i].decode(d)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Nil() {
+		x.Next = NewFixtureNode()
+		err = x.Next.decode(d)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Nil() {
+		NamedSize, err := d.Map(polyglot.StringKind, polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		x.Named = NewFixtureNodeNamedMap(NamedSize)
+		err = x.Named.decode(d, NamedSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skipFixtureNode(d *polyglot.BufferDecoder) error {
+	if d.Nil() {
+		return nil
+	}
+	if err := d.Skip(); err != nil {
+		return err
+	}
+	{
+		size, err := d.Slice(polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		for i := uint32(0); i < size; i++ {
+			if err = skipFixtureNode(d); err != nil {
+				return err
+			}
+		}
+	}
+	if err := skipFixtureNode(d); err != nil {
+		return err
+	}
+	if !d.Nil() {
+		size, err := d.Map(polyglot.StringKind, polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		for i := uint32(0); i < size; i++ {
+			if err = d.Skip(); err != nil {
+				return err
+			}
+			if err = skipFixtureNode(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EncodeFieldsZeroFill encodes the fields of x selected by mask, and the zero
+// value in place of every other field, so the result can be read with Decode.
+// A nil mask selects every field.
+//
+// Polyglot has no field-numbered encoding in which unselected fields could be
+// left out, and decoders find fields by their position, so every field is
+// written. Zero values take a few bytes each, but a reader cannot tell an
+// unselected field from one that was set to its zero value.
+func (x *FixtureNode) EncodeFieldsZeroFill(b *polyglot.Buffer, mask *polyglot.FieldMask) {
+	if x == nil || mask == nil {
+		x.Encode(b)
+		return
+	}
+
+	if mask.Has("name") {
+		polyglot.Encoder(b).String(x.Name)
+	} else {
+		polyglot.Encoder(b).String("")
+	}
+	if mask.Has("children") {
+		polyglot.Encoder(b).Slice(uint32(len(x.Children)), polyglot.AnyKind)
+		sub := mask.Sub("children")
+		for _, v := range x.Children {
+			v.EncodeFieldsZeroFill(b, sub)
+		}
+	} else {
+		polyglot.Encoder(b).Slice(0, polyglot.AnyKind)
+	}
+	if mask.Has("next") {
+		x.Next.EncodeFieldsZeroFill(b, mask.Sub("next"))
+	} else {
+		polyglot.Encoder(b).Nil()
+	}
+	if mask.Has("named") {
+		x.Named.Encode(b)
+	} else {
+		polyglot.Encoder(b).Map(0, polyglot.StringKind, polyglot.AnyKind)
+	}
+}
+
+// DecodeFields decodes the fields of b selected by mask and skips the others
+// without allocating them. Fields that are not selected are left as they are.
+// A nil mask selects every field.
+func (x *FixtureNode) DecodeFields(b []byte, mask *polyglot.FieldMask) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	return x.decodeFields(polyglot.Decoder(b), mask)
+}
+
+func (x *FixtureNode) decodeFields(d *polyglot.BufferDecoder, mask *polyglot.FieldMask) error {
+	if mask == nil {
+		return x.decode(d)
+	}
+	if d.Nil() {
+		return nil
+	}
+
+	var err error
+
+	if mask.Has("name") {
+		x.Name, err = d.String()
+		if err != nil {
+			return err
+		}
+	} else if err = d.Skip(); err != nil {
+		return err
+	}
+	var sliceSize uint32
+	if mask.Has("children") {
+		sliceSize, err = d.Slice(polyglot.AnyKind)
+		if err != nil {
+			return err
+		}
+		if uint32(len(x.Children)) != sliceSize {
+			x.Children = make([]*FixtureNode, sliceSize)
+		}
+		sub := mask.Sub("children")
+		for i := uint32(0); i < sliceSize; i++ {
+			if x.Children[i] == nil {
+				x.Children[i] = NewFixtureNode()
+			}
+			if err = x.Children[i].decodeFields(d, sub); err != nil {
+				return err
+			}
+		}
+	} else {
+		{
+			size, err := d.Slice(polyglot.AnyKind)
+			if err != nil {
+				return err
+			}
+			for i := uint32(0); i < size; i++ {
+				if err = skipFixtureNode(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if mask.Has("next") {
+		if !d.Nil() {
+			x.Next = NewFixtureNode()
+			if err = x.Next.decodeFields(d, mask.Sub("next")); err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := skipFixtureNode(d); err != nil {
+			return err
+		}
+	}
+	if mask.Has("named") {
+		if !d.Nil() {
+			NamedSize, err := d.Map(polyglot.StringKind, polyglot.AnyKind)
+			if err != nil {
+				return err
+			}
+			x.Named = NewFixtureNodeNamedMap(NamedSize)
+			err = x.Named.decode(d, NamedSize)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if !d.Nil() {
+			size, err := d.Map(polyglot.StringKind, polyglot.AnyKind)
+			if err != nil {
+				return err
+			}
+			for i := uint32(0); i < size; i++ {
+				if err = d.Skip(); err != nil {
+					return err
+				}
+				if err = skipFixtureNode(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (x *FixtureNode) Validate() error {
+	if x == nil {
+		return nil
+	}
+	if err := x.Next.Validate(); err != nil {
+		return validation.Prefix("next", err)
+	}
+	for i, v := range x.Children {
+		if err := v.Validate(); err != nil {
+			return validation.PrefixIndex("children", i, err)
+		}
+	}
+	for k, v := range x.Named {
+		if err := v.Validate(); err != nil {
+			return validation.PrefixKey("named", k, err)
+		}
+	}
+	return nil
+}
+
+func (x *FixtureNode) MarshalJSON() ([]byte, error) {
+	if x == nil {
+		return json.Null(), nil
+	}
+	e := json.NewEncoder()
+	e.Field("name", x.Name)
+	e.Field("next", x.Next)
+	e.Field("children", x.Children)
+	e.Field("named", x.Named)
+	return e.Bytes()
+}
+
+func (x *FixtureNode) UnmarshalJSON(b []byte) error {
+	if x == nil {
+		return ErrDecodeNil
+	}
+	d, err := json.NewDecoder(b)
+	if err != nil {
+		return err
+	}
+	d.Field("name", "name", &x.Name)
+	d.Field("next", "next", &x.Next)
+	d.Field("children", "children", &x.Children)
+	d.Field("named", "named", &x.Named)
+	return d.Error()
+}
+
+type FixtureDirectoryServer interface {
+	Get(ctx context.Context, req *FixtureUser) (*FixtureUser, error)
+	List(req *FixtureUser, stream FixtureDirectoryListServer) error
+	Upload(stream FixtureDirectoryUploadServer) error
+	Sync(stream FixtureDirectorySyncServer) error
+}
+
+type FixtureDirectoryClient interface {
+	Get(ctx context.Context, req *FixtureUser) (*FixtureUser, error)
+	List(ctx context.Context, req *FixtureUser) (FixtureDirectoryListClient, error)
+	Upload(ctx context.Context) (FixtureDirectoryUploadClient, error)
+	Sync(ctx context.Context) (FixtureDirectorySyncClient, error)
+}
+
+type fixtureDirectoryClient struct {
+	transport rpc.Transport
+}
+
+func NewFixtureDirectoryClient(transport rpc.Transport) FixtureDirectoryClient {
+	return &fixtureDirectoryClient{transport: transport}
+}
+
+func (c *fixtureDirectoryClient) Get(ctx context.Context, req *FixtureUser) (*FixtureUser, error) {
+	res := new(FixtureUser)
+	if err := rpc.Invoke(ctx, c.transport, "/fixture.Directory/Get", req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *fixtureDirectoryClient) List(ctx context.Context, req *FixtureUser) (FixtureDirectoryListClient, error) {
+	stream, err := rpc.NewStream(ctx, c.transport, "/fixture.Directory/List")
+	if err != nil {
+		return nil, err
+	}
+	if err = stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return &fixtureDirectoryListClient{stream}, nil
+}
+
+type FixtureDirectoryListServer interface {
+	Send(*FixtureUser) error
+	rpc.ServerStream
+}
+
+type fixtureDirectoryListServer struct {
+	rpc.ServerStream
+}
+
+func (x *fixtureDirectoryListServer) Send(m *FixtureUser) error {
+	return x.SendMsg(m)
+}
+
+type FixtureDirectoryListClient interface {
+	Recv() (*FixtureUser, error)
+	rpc.ClientStream
+}
+
+type fixtureDirectoryListClient struct {
+	rpc.ClientStream
+}
+
+func (x *fixtureDirectoryListClient) Recv() (*FixtureUser, error) {
+	m := new(FixtureUser)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *fixtureDirectoryClient) Upload(ctx context.Context) (FixtureDirectoryUploadClient, error) {
+	stream, err := rpc.NewStream(ctx, c.transport, "/fixture.Directory/Upload")
+	if err != nil {
+		return nil, err
+	}
+	return &fixtureDirectoryUploadClient{stream}, nil
+}
+
+type FixtureDirectoryUploadServer interface {
+	Recv() (*FixtureUser, error)
+	SendAndClose(*FixturePage) error
+	rpc.ServerStream
+}
+
+type fixtureDirectoryUploadServer struct {
+	rpc.ServerStream
+}
+
+func (x *fixtureDirectoryUploadServer) SendAndClose(m *FixturePage) error {
+	return x.SendMsg(m)
+}
+
+func (x *fixtureDirectoryUploadServer) Recv() (*FixtureUser, error) {
+	m := new(FixtureUser)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type FixtureDirectoryUploadClient interface {
+	Send(*FixtureUser) error
+	CloseAndRecv() (*FixturePage, error)
+	rpc.ClientStream
+}
+
+type fixtureDirectoryUploadClient struct {
+	rpc.ClientStream
+}
+
+func (x *fixtureDirectoryUploadClient) Send(m *FixtureUser) error {
+	return x.SendMsg(m)
+}
+
+func (x *fixtureDirectoryUploadClient) CloseAndRecv() (*FixturePage, error) {
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(FixturePage)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *fixtureDirectoryClient) Sync(ctx context.Context) (FixtureDirectorySyncClient, error) {
+	stream, err := rpc.NewStream(ctx, c.transport, "/fixture.Directory/Sync")
+	if err != nil {
+		return nil, err
+	}
+	return &fixtureDirectorySyncClient{stream}, nil
+}
+
+type FixtureDirectorySyncServer interface {
+	Send(*FixtureUser) error
+	Recv() (*FixtureUser, error)
+	rpc.ServerStream
+}
+
+type fixtureDirectorySyncServer struct {
+	rpc.ServerStream
+}
+
+func (x *fixtureDirectorySyncServer) Send(m *FixtureUser) error {
+	return x.SendMsg(m)
+}
+
+func (x *fixtureDirectorySyncServer) Recv() (*FixtureUser, error) {
+	m := new(FixtureUser)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type FixtureDirectorySyncClient interface {
+	Send(*FixtureUser) error
+	Recv() (*FixtureUser, error)
+	rpc.ClientStream
+}
+
+type fixtureDirectorySyncClient struct {
+	rpc.ClientStream
+}
+
+func (x *fixtureDirectorySyncClient) Send(m *FixtureUser) error {
+	return x.SendMsg(m)
+}
+
+func (x *fixtureDirectorySyncClient) Recv() (*FixtureUser, error) {
+	m := new(FixtureUser)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var FixtureDirectoryServiceDesc = &rpc.ServiceDesc{
+	Name: "fixture.Directory",
+	Methods: []rpc.MethodDesc{
+		{
+			Name: "Get",
+			Handler: func(impl interface{}, ctx context.Context, request []byte, response *polyglot.Buffer) error {
+				req := new(FixtureUser)
+				if err := req.Decode(request); err != nil {
+					return err
+				}
+				res, err := impl.(FixtureDirectoryServer).Get(ctx, req)
+				if err != nil {
+					return err
+				}
+				res.Encode(response)
+				return nil
+			},
+		},
+	},
+	Streams: []rpc.StreamDesc{
+		{
+			Name: "List",
+			Handler: func(impl interface{}, stream rpc.ServerStream) error {
+				req := new(FixtureUser)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return impl.(FixtureDirectoryServer).List(req, &fixtureDirectoryListServer{stream})
+			},
+		},
+		{
+			Name: "Upload",
+			Handler: func(impl interface{}, stream rpc.ServerStream) error {
+				return impl.(FixtureDirectoryServer).Upload(&fixtureDirectoryUploadServer{stream})
+			},
+		},
+		{
+			Name: "Sync",
+			Handler: func(impl interface{}, stream rpc.ServerStream) error {
+				return impl.(FixtureDirectoryServer).Sync(&fixtureDirectorySyncServer{stream})
+			},
+		},
+	},
+}
+
+func RegisterFixtureDirectoryServer(s *rpc.Server, impl FixtureDirectoryServer) error {
+	return s.Register(FixtureDirectoryServiceDesc, impl)
+}
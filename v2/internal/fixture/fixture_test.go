@@ -0,0 +1,167 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fixture
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+	"time"
+)
+
+func testUser(name string) *FixtureUser {
+	return &FixtureUser{
+		UserID:    "u-" + name,
+		Name:      name,
+		Balance:   "12.50",
+		Timeout:   time.Second,
+		Admin:     true,
+		Age:       36,
+		Delta:     -3,
+		Logins:    7,
+		Offset:    -1 << 40,
+		Visits:    1 << 50,
+		Flags:     0xff,
+		Ratio:     0.5,
+		Score:     99.5,
+		Avatar:    []byte{1, 2, 3},
+		Status:    FixtureSTATUS_DELETED,
+		Kind:      FixtureUserKIND_ROBOT,
+		Home:      &FixtureAddress{City: "London", Zip: "N1"},
+		Tags:      []string{"a", "b"},
+		Samples:   []int64{-1, 2},
+		Keys:      [][]byte{{1}, {2, 3}},
+		History:   []FixtureStatus{FixtureSTATUS_ACTIVE, FixtureSTATUS_DELETED},
+		Addresses: []*FixtureAddress{{City: "Paris"}},
+		Counters:  FixtureUserCountersMap{"logins": 1},
+		Offices:   FixtureUserOfficesMap{1: {City: "Berlin"}},
+		Statuses:  FixtureUserStatusesMap{"last": FixtureSTATUS_ACTIVE},
+		Blobs:     FixtureUserBlobsMap{"key": {9}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	user := testUser("ada")
+	user.Encode(b)
+	decodedUser := new(FixtureUser)
+	require.NoError(t, decodedUser.Decode(b.Bytes()))
+	assert.Equal(t, user, decodedUser)
+
+	b.Reset()
+	page := &FixturePage{Users: []*FixtureUser{testUser("ada"), testUser("bob")}}
+	page.Encode(b)
+	decodedPage := new(FixturePage)
+	require.NoError(t, decodedPage.Decode(b.Bytes()))
+	assert.Equal(t, page, decodedPage)
+
+	b.Reset()
+	// Nil maps decode as empty maps.
+	leaf := func(name string) *FixtureNode {
+		return &FixtureNode{Name: name, Named: FixtureNodeNamedMap{}}
+	}
+	node := &FixtureNode{
+		Name:     "root",
+		Next:     leaf("next"),
+		Children: []*FixtureNode{leaf("child")},
+		Named:    FixtureNodeNamedMap{"leaf": leaf("leaf")},
+	}
+	node.Encode(b)
+	decodedNode := new(FixtureNode)
+	require.NoError(t, decodedNode.Decode(b.Bytes()))
+	assert.Equal(t, node, decodedNode)
+}
+
+func TestFieldMask(t *testing.T) {
+	t.Parallel()
+
+	mask, err := polyglot.NewFieldMask("name", "home.city", "addresses.city", "counters")
+	require.NoError(t, err)
+
+	b := polyglot.NewBuffer()
+	user := testUser("ada")
+	user.Encode(b)
+	decoded := new(FixtureUser)
+	require.NoError(t, decoded.DecodeFields(b.Bytes(), mask))
+	assert.Equal(t, &FixtureUser{
+		Name:      "ada",
+		Home:      &FixtureAddress{City: "London"},
+		Addresses: []*FixtureAddress{{City: "Paris"}},
+		Counters:  FixtureUserCountersMap{"logins": 1},
+	}, decoded)
+
+	b.Reset()
+	user.EncodeFieldsZeroFill(b, mask)
+	zeroFilled := new(FixtureUser)
+	require.NoError(t, zeroFilled.Decode(b.Bytes()))
+	assert.Equal(t, &FixtureUser{
+		Name:      "ada",
+		Home:      &FixtureAddress{City: "London"},
+		Addresses: []*FixtureAddress{{City: "Paris"}},
+		Counters:  FixtureUserCountersMap{"logins": 1},
+		Offices:   FixtureUserOfficesMap{},
+		Statuses:  FixtureUserStatusesMap{},
+		Blobs:     FixtureUserBlobsMap{},
+	}, zeroFilled)
+
+	b.Reset()
+	user.EncodeFieldsZeroFill(b, nil)
+	all := new(FixtureUser)
+	require.NoError(t, all.DecodeFields(b.Bytes(), nil))
+	assert.Equal(t, user, all)
+}
+
+func TestEnumJSON(t *testing.T) {
+	t.Parallel()
+
+	user := new(FixtureUser)
+	require.NoError(t, user.UnmarshalJSON([]byte(`{"status":10,"history":[5,"STATUS_UNKNOWN"]}`)))
+	assert.Equal(t, FixtureSTATUS_DELETED, user.Status)
+	assert.Equal(t, []FixtureStatus{FixtureSTATUS_ACTIVE, FixtureSTATUS_UNKNOWN}, user.History)
+
+	assert.Error(t, user.UnmarshalJSON([]byte(`{"status":2}`)))
+}
+
+func TestValidateIntegerBounds(t *testing.T) {
+	t.Parallel()
+
+	u := testUser("bounds")
+	require.NoError(t, u.Validate())
+
+	u.Offset = 9223372036854774783
+	assert.NoError(t, u.Validate())
+	u.Offset = 9223372036854774784
+	assert.EqualError(t, u.Validate(), "invalid offset: must be less than 9223372036854774784")
+	u.Offset = -9223372036854775807
+	assert.NoError(t, u.Validate())
+	u.Offset = -9223372036854775808
+	assert.EqualError(t, u.Validate(), "invalid offset: must be greater than -9223372036854775808")
+
+	u = testUser("bounds")
+	u.Visits = 18446744073709549568
+	assert.NoError(t, u.Validate())
+	u.Visits = 18446744073709549569
+	assert.EqualError(t, u.Validate(), "invalid visits: must be less than or equal to 18446744073709549568")
+
+	u = testUser("bounds")
+	u.Age = -1
+	assert.EqualError(t, u.Validate(), "invalid age: must be greater than or equal to 0")
+}
@@ -0,0 +1,179 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package lazy
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+	"github.com/loopholelabs/polyglot/v2/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+	"time"
+)
+
+func testUser(name string) *FixtureUser {
+	return &FixtureUser{
+		UserID:    "u-" + name,
+		Name:      name,
+		Balance:   "12.50",
+		Timeout:   time.Second,
+		Admin:     true,
+		Age:       36,
+		Delta:     -3,
+		Logins:    7,
+		Offset:    -1 << 40,
+		Visits:    1 << 50,
+		Flags:     0xff,
+		Ratio:     0.5,
+		Score:     99.5,
+		Avatar:    []byte{1, 2, 3},
+		Status:    FixtureSTATUS_DELETED,
+		Kind:      FixtureUserKIND_ROBOT,
+		Home:      &FixtureAddress{City: "London", Zip: "N1"},
+		Tags:      []string{"a", "b"},
+		Samples:   []int64{-1, 2},
+		Keys:      [][]byte{{1}, {2, 3}},
+		History:   []FixtureStatus{FixtureSTATUS_ACTIVE, FixtureSTATUS_DELETED},
+		Addresses: []*FixtureAddress{{City: "Paris"}},
+		Counters:  FixtureUserCountersMap{"logins": 1},
+		Offices:   FixtureUserOfficesMap{1: {City: "Berlin"}},
+		Statuses:  FixtureUserStatusesMap{"last": FixtureSTATUS_ACTIVE},
+		Blobs:     FixtureUserBlobsMap{"key": {9}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	user := testUser("ada")
+	user.Encode(b)
+	decodedUser := new(FixtureUser)
+	require.NoError(t, decodedUser.Decode(b.Bytes()))
+	require.NoError(t, decodedUser.Load())
+	assert.Equal(t, user, decodedUser)
+
+	b.Reset()
+	page := &FixturePage{Users: []*FixtureUser{testUser("ada"), testUser("bob")}}
+	page.Encode(b)
+	decodedPage := new(FixturePage)
+	require.NoError(t, decodedPage.Decode(b.Bytes()))
+	require.NoError(t, decodedPage.Load())
+	for _, u := range decodedPage.Users {
+		require.NoError(t, u.Load())
+	}
+	assert.Equal(t, page, decodedPage)
+
+	b.Reset()
+	// Nil maps decode as empty maps.
+	leaf := func(name string) *FixtureNode {
+		return &FixtureNode{Name: name, Named: FixtureNodeNamedMap{}}
+	}
+	node := &FixtureNode{
+		Name:     "root",
+		Next:     leaf("next"),
+		Children: []*FixtureNode{leaf("child")},
+		Named:    FixtureNodeNamedMap{"leaf": leaf("leaf")},
+	}
+	node.Encode(b)
+	decodedNode := new(FixtureNode)
+	require.NoError(t, decodedNode.Decode(b.Bytes()))
+	require.NoError(t, decodedNode.Load())
+	require.NoError(t, decodedNode.Next.Load())
+	require.NoError(t, decodedNode.Children[0].Load())
+	require.NoError(t, decodedNode.Named["leaf"].Load())
+	assert.Equal(t, node, decodedNode)
+}
+
+func TestLazyFields(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	testUser("ada").Encode(b)
+	encoded := b.Bytes()
+
+	user := new(FixtureUser)
+	require.NoError(t, user.Decode(encoded))
+	assert.Nil(t, user.Tags)
+	assert.Nil(t, user.Home)
+
+	tags, err := user.GetTags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, tags)
+
+	// Fields that are still encoded are written back as they were.
+	b = polyglot.NewBuffer()
+	user.Encode(b)
+	assert.Equal(t, encoded, b.Bytes())
+
+	// Clearing a field, whether or not it was decoded first, is encoded.
+	user.SetTags(nil)
+	user.SetHome(nil)
+	user.SetCounters(nil)
+	b = polyglot.NewBuffer()
+	user.Encode(b)
+	cleared := new(FixtureUser)
+	require.NoError(t, cleared.Decode(b.Bytes()))
+	require.NoError(t, cleared.Load())
+	assert.Empty(t, cleared.Tags)
+	assert.Nil(t, cleared.Home)
+	assert.Empty(t, cleared.Counters)
+	assert.Equal(t, []int64{-1, 2}, cleared.Samples)
+}
+
+func TestValidateOnDecode(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	user := testUser("")
+	user.Encode(b)
+	err := new(FixtureUser).Decode(b.Bytes())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "name")
+
+	b = polyglot.NewBuffer()
+	user = testUser("ada")
+	user.Tags = make([]string, 9)
+	user.Home.City = ""
+	user.Encode(b)
+	decoded := new(FixtureUser)
+	require.NoError(t, decoded.Decode(b.Bytes()))
+
+	_, err = decoded.GetTags()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tags")
+
+	_, err = decoded.GetHome()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "city")
+
+	assert.Error(t, decoded.Validate())
+}
+
+func TestSharedDescriptor(t *testing.T) {
+	t.Parallel()
+
+	// Both packages are generated from fixture.proto, so linking them
+	// together registers its descriptor twice, and the first one is kept.
+	registered, ok := descriptor.FindMessage("fixture.User")
+	require.True(t, ok)
+	assert.True(t, registered == new(fixture.FixtureUser).Descriptor() || registered == new(FixtureUser).Descriptor())
+	assert.Equal(t, new(fixture.FixtureUser).Descriptor(), new(FixtureUser).Descriptor())
+	assert.Equal(t, new(fixture.FixtureUser).Fingerprint(), new(FixtureUser).Fingerprint())
+}
@@ -0,0 +1,59 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package schema compiles fixture.proto, the schema of the generated fixture
+// packages, for tests that need its protoreflect descriptors.
+package schema
+
+import (
+	"github.com/bufbuild/protocompile"
+	"github.com/loopholelabs/polyglot/v2/options"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"context"
+	_ "embed"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Path is the path fixture.proto is compiled as.
+const Path = "fixture.proto"
+
+//go:embed fixture.proto
+var source string
+
+// File returns the descriptor of fixture.proto.
+var File = sync.OnceValues(func() (protoreflect.FileDescriptor, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+			switch path {
+			case Path:
+				return protocompile.SearchResult{Source: strings.NewReader(source)}, nil
+			case options.File_polyglot_options_proto.Path():
+				return protocompile.SearchResult{Desc: options.File_polyglot_options_proto}, nil
+			default:
+				return protocompile.SearchResult{}, os.ErrNotExist
+			}
+		})),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), Path)
+	if err != nil {
+		return nil, err
+	}
+	return files[0], nil
+})
@@ -0,0 +1,161 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fixture
+
+import (
+	"github.com/loopholelabs/polyglot/v2/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+// directory serves the users it is given, and echoes them back with a
+// "seen-" prefix on their names.
+type directory struct{}
+
+func seen(u *FixtureUser) *FixtureUser {
+	u.Name = "seen-" + u.Name
+	return u
+}
+
+func (directory) Get(_ context.Context, req *FixtureUser) (*FixtureUser, error) {
+	if req.Name == "missing" {
+		return nil, errors.New("user not found")
+	}
+	return seen(req), nil
+}
+
+func (directory) List(req *FixtureUser, stream FixtureDirectoryListServer) error {
+	for i := int32(0); i < req.Age; i++ {
+		if err := stream.Send(seen(testUser(req.Name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (directory) Upload(stream FixtureDirectoryUploadServer) error {
+	page := new(FixturePage)
+	for {
+		u, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(page)
+		}
+		if err != nil {
+			return err
+		}
+		page.Users = append(page.Users, seen(u))
+	}
+}
+
+func (directory) Sync(stream FixtureDirectorySyncServer) error {
+	for {
+		u, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = stream.Send(seen(u)); err != nil {
+			return err
+		}
+	}
+}
+
+func newDirectoryServer(t *testing.T) *rpc.Server {
+	s := rpc.NewServer()
+	require.NoError(t, RegisterFixtureDirectoryServer(s, directory{}))
+	return s
+}
+
+func newConnClient(t *testing.T) FixtureDirectoryClient {
+	client, server := net.Pipe()
+	go func() {
+		_ = newDirectoryServer(t).ServeConn(server)
+	}()
+	transport := rpc.NewConnTransport(client)
+	t.Cleanup(func() {
+		_ = transport.Close()
+	})
+	return NewFixtureDirectoryClient(transport)
+}
+
+func TestServiceUnary(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(newDirectoryServer(t))
+	t.Cleanup(ts.Close)
+
+	for name, client := range map[string]FixtureDirectoryClient{
+		"conn": newConnClient(t),
+		"http": NewFixtureDirectoryClient(rpc.NewHTTPTransport(ts.URL, ts.Client())),
+	} {
+		res, err := client.Get(context.Background(), testUser("ada"))
+		require.NoError(t, err, name)
+		assert.Equal(t, seen(testUser("ada")), res, name)
+
+		_, err = client.Get(context.Background(), testUser("missing"))
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), "user not found", name)
+	}
+}
+
+func TestServiceStreams(t *testing.T) {
+	t.Parallel()
+
+	client := newConnClient(t)
+	ctx := context.Background()
+
+	req := testUser("ada")
+	req.Age = 3
+	list, err := client.List(ctx, req)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		u, err := list.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, seen(testUser("ada")), u)
+	}
+	_, err = list.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+
+	upload, err := client.Upload(ctx)
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(testUser("ada")))
+	require.NoError(t, upload.Send(testUser("bob")))
+	page, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, &FixturePage{Users: []*FixtureUser{seen(testUser("ada")), seen(testUser("bob"))}}, page)
+
+	sync, err := client.Sync(ctx)
+	require.NoError(t, err)
+	for _, name := range []string{"ada", "bob"} {
+		require.NoError(t, sync.Send(testUser(name)))
+		u, err := sync.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, seen(testUser(name)), u)
+	}
+	require.NoError(t, sync.CloseSend())
+	_, err = sync.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
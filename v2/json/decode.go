@@ -0,0 +1,267 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package json
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidObject = errors.New("invalid JSON object")
+	ErrInvalidValue  = errors.New("invalid JSON value")
+)
+
+var (
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// Decoder reads the fields of a single message from a JSON object.
+type Decoder struct {
+	fields map[string]json.RawMessage
+	err    error
+}
+
+// NewDecoder parses b as a JSON object. A JSON null results in a Decoder
+// that has no fields.
+func NewDecoder(b []byte) (*Decoder, error) {
+	d := new(Decoder)
+	if isNull(b) {
+		return d, nil
+	}
+	if err := json.Unmarshal(b, &d.fields); err != nil {
+		return nil, ErrInvalidObject
+	}
+	return d, nil
+}
+
+// Field decodes the field stored under either its JSON name or its original
+// proto name into value, which must be a pointer. Fields that are not present
+// are left untouched, and fields that are null are reset to their zero value.
+func (d *Decoder) Field(jsonName string, protoName string, value interface{}) *Decoder {
+	if d.err != nil {
+		return d
+	}
+	raw, ok := d.fields[jsonName]
+	if !ok {
+		if raw, ok = d.fields[protoName]; !ok {
+			return d
+		}
+	}
+	d.err = Unmarshal(raw, value)
+	return d
+}
+
+// Error returns the first error encountered by Field.
+func (d *Decoder) Error() error {
+	return d.err
+}
+
+// Unmarshal decodes the proto3 JSON encoding in b into value, which must be
+// a pointer to any type accepted by Encoder.Field.
+func Unmarshal(b []byte, value interface{}) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ErrUnsupportedType
+	}
+	return decodeValue(b, v.Elem())
+}
+
+func isNull(b []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(b), null)
+}
+
+func decodeValue(b []byte, v reflect.Value) error {
+	if isNull(b) {
+		v.SetZero()
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if v.Type().Implements(unmarshalerType) {
+			return v.Interface().(json.Unmarshaler).UnmarshalJSON(b)
+		}
+		return decodeValue(b, v.Elem())
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		return v.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(b)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		var value bool
+		if err := json.Unmarshal(b, &value); err != nil {
+			return ErrInvalidValue
+		}
+		v.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(unquoteNumber(b), 10, v.Type().Bits())
+		if err != nil {
+			return ErrInvalidValue
+		}
+		v.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(unquoteNumber(b), 10, v.Type().Bits())
+		if err != nil {
+			return ErrInvalidValue
+		}
+		v.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := parseFloat(b, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(value)
+	case reflect.String:
+		var value string
+		if err := json.Unmarshal(b, &value); err != nil {
+			return ErrInvalidValue
+		}
+		v.SetString(value)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			value, err := decodeBase64(b)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(value)
+			return nil
+		}
+		return decodeSlice(b, v)
+	case reflect.Map:
+		return decodeMap(b, v)
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+func unquoteNumber(b []byte) string {
+	s := string(bytes.TrimSpace(b))
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func parseFloat(b []byte, bits int) (float64, error) {
+	s := unquoteNumber(b)
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
+	value, err := strconv.ParseFloat(s, bits)
+	if err != nil {
+		return 0, ErrInvalidValue
+	}
+	return value, nil
+}
+
+func decodeBase64(b []byte) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, ErrInvalidValue
+	}
+	// Both the standard and URL-safe alphabets are accepted, with or without padding.
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		s = strings.NewReplacer("-", "+", "_", "/").Replace(s)
+	}
+	value, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidValue
+	}
+	return value, nil
+}
+
+func decodeSlice(b []byte, v reflect.Value) error {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(b, &elements); err != nil {
+		return ErrInvalidValue
+	}
+	slice := reflect.MakeSlice(v.Type(), len(elements), len(elements))
+	for i, element := range elements {
+		if err := decodeValue(element, slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+func decodeMap(b []byte, v reflect.Value) error {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return ErrInvalidValue
+	}
+	m := reflect.MakeMapWithSize(v.Type(), len(entries))
+	for key, entry := range entries {
+		k := reflect.New(v.Type().Key()).Elem()
+		if err := parseKey(key, k); err != nil {
+			return err
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		if err := decodeValue(entry, e); err != nil {
+			return err
+		}
+		m.SetMapIndex(k, e)
+	}
+	v.Set(m)
+	return nil
+}
+
+func parseKey(key string, k reflect.Value) error {
+	switch k.Kind() {
+	case reflect.String:
+		k.SetString(key)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(key)
+		if err != nil {
+			return ErrInvalidValue
+		}
+		k.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(key, 10, k.Type().Bits())
+		if err != nil {
+			return ErrInvalidValue
+		}
+		k.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(key, 10, k.Type().Bits())
+		if err != nil {
+			return ErrInvalidValue
+		}
+		k.SetUint(value)
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
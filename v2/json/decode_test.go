@@ -0,0 +1,83 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package json
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"math"
+	"testing"
+)
+
+func TestDecoderRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	m := &testMessage{
+		Name:     "Test String",
+		Count:    math.MaxInt64,
+		Values:   []uint64{0, 32},
+		Data:     []byte{0, 1, 2, 0xff},
+		Ratio:    -922337203685.2345,
+		Kind:     1,
+		Children: []*testMessage{{Name: "child", Count: 1}},
+		Labels:   map[bool]testEnum{true: 1},
+	}
+
+	data, err := m.MarshalJSON()
+	require.NoError(t, err)
+
+	decoded := new(testMessage)
+	require.NoError(t, decoded.UnmarshalJSON(data))
+	assert.Equal(t, m, decoded)
+}
+
+func TestDecoderAlternateForms(t *testing.T) {
+	t.Parallel()
+
+	decoded := new(testMessage)
+	err := decoded.UnmarshalJSON([]byte(`{"name":"a","count":12,"values":["3",4],"data":"_-8","ratio":"NaN","kind":5,"labels":{"false":"FIRST"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "a", decoded.Name)
+	assert.Equal(t, int64(12), decoded.Count)
+	assert.Equal(t, []uint64{3, 4}, decoded.Values)
+	assert.Equal(t, []byte{0xff, 0xef}, decoded.Data)
+	assert.True(t, math.IsNaN(decoded.Ratio))
+	assert.Equal(t, testEnum(1), decoded.Kind)
+	assert.Equal(t, map[bool]testEnum{false: 1}, decoded.Labels)
+
+	require.NoError(t, decoded.UnmarshalJSON([]byte(`{"name":null,"kind":"UNKNOWN"}`)))
+	assert.Equal(t, "", decoded.Name)
+	assert.Equal(t, testEnum(0), decoded.Kind)
+	assert.Equal(t, int64(12), decoded.Count)
+
+	require.NoError(t, decoded.UnmarshalJSON([]byte(`null`)))
+	assert.Equal(t, int64(12), decoded.Count)
+}
+
+func TestDecoderInvalid(t *testing.T) {
+	t.Parallel()
+
+	decoded := new(testMessage)
+	assert.ErrorIs(t, decoded.UnmarshalJSON([]byte(`[]`)), ErrInvalidObject)
+	assert.ErrorIs(t, decoded.UnmarshalJSON([]byte(`{"count":"twelve"}`)), ErrInvalidValue)
+	assert.ErrorIs(t, decoded.UnmarshalJSON([]byte(`{"data":"***"}`)), ErrInvalidValue)
+	assert.ErrorIs(t, decoded.UnmarshalJSON([]byte(`{"kind":"SECOND"}`)), ErrInvalidValue)
+	assert.ErrorIs(t, decoded.UnmarshalJSON([]byte(`{"kind":1}`)), ErrInvalidValue)
+	assert.ErrorIs(t, decoded.UnmarshalJSON([]byte(`{"labels":{"maybe":1}}`)), ErrInvalidValue)
+	assert.ErrorIs(t, Unmarshal([]byte(`1`), 1), ErrUnsupportedType)
+}
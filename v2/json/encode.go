@@ -0,0 +1,247 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package json implements the proto3 JSON mapping for polyglot generated messages.
+//
+// Generated MarshalJSON and UnmarshalJSON methods use an Encoder or a Decoder to
+// write or read one field at a time. Field names are lowerCamelCase, enums are
+// written as their names, 64-bit integers are written as strings and bytes are
+// written as standard base64.
+package json
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type for proto3 JSON mapping")
+)
+
+var (
+	null = []byte("null")
+
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Null returns the JSON encoding of a nil message.
+func Null() []byte {
+	return append([]byte(nil), null...)
+}
+
+// Encoder writes the fields of a single message as a JSON object.
+type Encoder struct {
+	b   []byte
+	err error
+}
+
+func NewEncoder() *Encoder {
+	return &Encoder{
+		b: append(make([]byte, 0, 64), '{'),
+	}
+}
+
+// Field writes the field name and its value, omitting the field entirely
+// if the value is the zero value for its type.
+func (e *Encoder) Field(name string, value interface{}) *Encoder {
+	if e.err != nil {
+		return e
+	}
+	v := reflect.ValueOf(value)
+	if isEmpty(v) {
+		return e
+	}
+	if len(e.b) > 1 {
+		e.b = append(e.b, ',')
+	}
+	e.b = appendString(e.b, name)
+	e.b = append(e.b, ':')
+	e.b, e.err = appendValue(e.b, v)
+	return e
+}
+
+// Bytes closes the JSON object and returns it along with the first error
+// encountered by Field.
+func (e *Encoder) Bytes() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return append(e.b, '}'), nil
+}
+
+// Marshal returns the proto3 JSON encoding of value, which may be any type
+// accepted by Encoder.Field.
+func Marshal(value interface{}) ([]byte, error) {
+	return appendValue(nil, reflect.ValueOf(value))
+}
+
+func isEmpty(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func appendValue(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return append(b, null...), nil
+	}
+
+	if v.Type().Implements(marshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return append(b, null...), nil
+		}
+		data, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return b, err
+		}
+		return append(b, data...), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.AppendBool(b, v.Bool()), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return strconv.AppendInt(b, v.Int(), 10), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return strconv.AppendUint(b, v.Uint(), 10), nil
+	case reflect.Int, reflect.Int64:
+		b = append(b, '"')
+		b = strconv.AppendInt(b, v.Int(), 10)
+		return append(b, '"'), nil
+	case reflect.Uint, reflect.Uint64:
+		b = append(b, '"')
+		b = strconv.AppendUint(b, v.Uint(), 10)
+		return append(b, '"'), nil
+	case reflect.Float32:
+		return appendFloat(b, v.Float(), 32), nil
+	case reflect.Float64:
+		return appendFloat(b, v.Float(), 64), nil
+	case reflect.String:
+		return appendString(b, v.String()), nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return append(b, null...), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b = append(b, '"')
+			b = base64.StdEncoding.AppendEncode(b, v.Bytes())
+			return append(b, '"'), nil
+		}
+		return appendSlice(b, v)
+	case reflect.Map:
+		if v.IsNil() {
+			return append(b, null...), nil
+		}
+		return appendMap(b, v)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return append(b, null...), nil
+		}
+		return appendValue(b, v.Elem())
+	default:
+		return b, ErrUnsupportedType
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	// Marshalling a string never fails.
+	data, _ := json.Marshal(s)
+	return append(b, data...)
+}
+
+func appendFloat(b []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(b, `"Infinity"`...)
+	case math.IsInf(f, -1):
+		return append(b, `"-Infinity"`...)
+	default:
+		return strconv.AppendFloat(b, f, 'g', -1, bits)
+	}
+}
+
+func appendSlice(b []byte, v reflect.Value) ([]byte, error) {
+	var err error
+	b = append(b, '[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b, err = appendValue(b, v.Index(i))
+		if err != nil {
+			return b, err
+		}
+	}
+	return append(b, ']'), nil
+}
+
+func appendMap(b []byte, v reflect.Value) ([]byte, error) {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := formatKey(iter.Key())
+		if err != nil {
+			return b, err
+		}
+		keys = append(keys, key)
+		values[key] = iter.Value()
+	}
+	sort.Strings(keys)
+
+	var err error
+	b = append(b, '{')
+	for i, key := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendString(b, key)
+		b = append(b, ':')
+		b, err = appendValue(b, values[key])
+		if err != nil {
+			return b, err
+		}
+	}
+	return append(b, '}'), nil
+}
+
+func formatKey(k reflect.Value) (string, error) {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(k.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", ErrUnsupportedType
+	}
+}
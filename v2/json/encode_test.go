@@ -0,0 +1,146 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package json
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"math"
+	"testing"
+)
+
+type testEnum uint32
+
+var (
+	testEnumName  = map[testEnum]string{0: "UNKNOWN", 1: "FIRST"}
+	testEnumValue = map[string]testEnum{"UNKNOWN": 0, "FIRST": 1}
+	// FIRST is declared second, with the number 5.
+	testEnumNumber = []int32{0, 5}
+)
+
+func (x testEnum) MarshalJSON() ([]byte, error) {
+	return MarshalEnum(testEnumName, x)
+}
+
+func (x *testEnum) UnmarshalJSON(b []byte) error {
+	return UnmarshalEnum(testEnumValue, testEnumNumber, b, x)
+}
+
+type testMessage struct {
+	Name     string
+	Count    int64
+	Values   []uint64
+	Data     []byte
+	Ratio    float64
+	Kind     testEnum
+	Children []*testMessage
+	Labels   map[bool]testEnum
+}
+
+func (x *testMessage) MarshalJSON() ([]byte, error) {
+	if x == nil {
+		return Null(), nil
+	}
+	return NewEncoder().
+		Field("name", x.Name).
+		Field("count", x.Count).
+		Field("values", x.Values).
+		Field("data", x.Data).
+		Field("ratio", x.Ratio).
+		Field("kind", x.Kind).
+		Field("children", x.Children).
+		Field("labels", x.Labels).
+		Bytes()
+}
+
+func (x *testMessage) UnmarshalJSON(b []byte) error {
+	d, err := NewDecoder(b)
+	if err != nil {
+		return err
+	}
+	return d.Field("name", "name", &x.Name).
+		Field("count", "count", &x.Count).
+		Field("values", "values", &x.Values).
+		Field("data", "data", &x.Data).
+		Field("ratio", "ratio", &x.Ratio).
+		Field("kind", "kind", &x.Kind).
+		Field("children", "children", &x.Children).
+		Field("labels", "labels", &x.Labels).
+		Error()
+}
+
+func TestEncoderEmpty(t *testing.T) {
+	t.Parallel()
+
+	data, err := (&testMessage{}).MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+
+	data, err = (*testMessage)(nil).MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+}
+
+func TestEncoderFields(t *testing.T) {
+	t.Parallel()
+
+	m := &testMessage{
+		Name:     "Test \"String\"",
+		Count:    math.MinInt64,
+		Values:   []uint64{1, math.MaxUint64},
+		Data:     []byte("Test String"),
+		Ratio:    math.Inf(-1),
+		Kind:     1,
+		Children: []*testMessage{{Name: "child"}, nil},
+		Labels:   map[bool]testEnum{true: 1, false: 0},
+	}
+
+	data, err := m.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Test \"String\"","count":"-9223372036854775808","values":["1","18446744073709551615"],`+
+		`"data":"VGVzdCBTdHJpbmc=","ratio":"-Infinity","kind":"FIRST","children":[{"name":"child"},null],`+
+		`"labels":{"false":"UNKNOWN","true":"FIRST"}}`, string(data))
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := Marshal(struct{}{})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Marshal(map[float64]string{1: "one"})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestEnumNumbers(t *testing.T) {
+	t.Parallel()
+
+	data, err := testEnum(1).MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `"FIRST"`, string(data))
+	_, err = testEnum(2).MarshalJSON()
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	var value testEnum
+	require.NoError(t, value.UnmarshalJSON([]byte(`5`)))
+	assert.Equal(t, testEnum(1), value)
+	require.NoError(t, value.UnmarshalJSON([]byte(`"0"`)))
+	assert.Equal(t, testEnum(0), value)
+	assert.ErrorIs(t, value.UnmarshalJSON([]byte(`1`)), ErrInvalidValue)
+	assert.ErrorIs(t, value.UnmarshalJSON([]byte(`-5`)), ErrInvalidValue)
+}
@@ -0,0 +1,60 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package json
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// MarshalEnum writes an enum value as its name. Every value that the enum
+// declares has a name, so values without one are rejected.
+func MarshalEnum[E ~uint32](names map[E]string, value E) ([]byte, error) {
+	if name, ok := names[value]; ok {
+		return appendString(nil, name), nil
+	}
+	return nil, ErrInvalidValue
+}
+
+// UnmarshalEnum reads an enum value from either its name or its proto number.
+// Enum values are the index of their declaration, and numbers holds the
+// number declared at each index, so numbers are mapped to the first value
+// declared with them, and numbers that are not declared are rejected.
+func UnmarshalEnum[E ~uint32](values map[string]E, numbers []int32, b []byte, value *E) error {
+	if isNull(b) {
+		*value = 0
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		if v, ok := values[name]; ok {
+			*value = v
+			return nil
+		}
+	}
+	number, err := strconv.ParseInt(unquoteNumber(b), 10, 32)
+	if err != nil {
+		return ErrInvalidValue
+	}
+	for i, n := range numbers {
+		if int64(n) == number {
+			*value = E(i)
+			return nil
+		}
+	}
+	return ErrInvalidValue
+}
@@ -0,0 +1,74 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package options
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Field returns the polyglot options of a field. Fields without options get
+// an empty FieldOptions.
+func Field(field protoreflect.FieldDescriptor) *FieldOptions {
+	if opts, ok := extension(field.Options(), E_Field).(*FieldOptions); ok && opts != nil {
+		return opts
+	}
+	return new(FieldOptions)
+}
+
+// Message returns the polyglot options of a message. Messages without options
+// get an empty MessageOptions.
+func Message(message protoreflect.MessageDescriptor) *MessageOptions {
+	if opts, ok := extension(message.Options(), E_Message).(*MessageOptions); ok && opts != nil {
+		return opts
+	}
+	return new(MessageOptions)
+}
+
+// Omitted reports whether a field is left out of generated code.
+func Omitted(field protoreflect.FieldDescriptor) bool {
+	return Field(field).GetOmit()
+}
+
+func extension(opts proto.Message, xt protoreflect.ExtensionType) interface{} {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nil
+	}
+	m := opts.ProtoReflect()
+	if m.Has(xt.TypeDescriptor()) {
+		if value := m.Get(xt.TypeDescriptor()); xt.IsValidValue(value) {
+			return xt.InterfaceOf(value)
+		}
+	}
+
+	// Options that were parsed before this package was linked in, or by a
+	// compiler with its own extension types, hold the extension as unknown
+	// or dynamic fields, so they are re-parsed with the registered types.
+	b, err := proto.Marshal(opts)
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	parsed := opts.ProtoReflect().Type().New().Interface()
+	if err = (proto.UnmarshalOptions{Resolver: protoregistry.GlobalTypes}).Unmarshal(b, parsed); err != nil {
+		return nil
+	}
+	if !proto.HasExtension(parsed, xt) {
+		return nil
+	}
+	return proto.GetExtension(parsed, xt)
+}
@@ -0,0 +1,451 @@
+// Copyright 2023 Loophole Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Custom options read by the polyglot code generators. Import this file with
+// -I pointing at the v2/proto directory, for example:
+//
+//   import "polyglot/options.proto";
+//
+//   message User {
+//     option (polyglot.message).go_tags = "json:\"{json}\"";
+//
+//     string id = 1 [(polyglot.field) = {go_type: "UserID", go_tags: "db:\"id\""}];
+//     string password_hash = 2 [(polyglot.field).omit = true];
+//   }
+//
+// These options are currently only honored by the Go generator.
+// Validation rules are set on fields with (polyglot.field).rules, for example:
+//
+//   string email = 3 [(polyglot.field).rules = {required: true, max_len: 254}];
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: polyglot/options.proto
+
+package options
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FieldOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Go struct tags added to the field, for example "db:\"user_id\"".
+	GoTags string `protobuf:"bytes,1,opt,name=go_tags,json=goTags,proto3" json:"go_tags,omitempty"`
+	// Go type of the field. Only singular scalar fields support this, and the
+	// type must have the field's Go type as its underlying type. Types outside
+	// of the generated package are qualified with their import path, as in
+	// "example.com/ids.UserID".
+	GoType string `protobuf:"bytes,2,opt,name=go_type,json=goType,proto3" json:"go_type,omitempty"`
+	// Name of the Go struct field, replacing the CamelCase field name.
+	GoName string `protobuf:"bytes,3,opt,name=go_name,json=goName,proto3" json:"go_name,omitempty"`
+	// Leaves the field out of the generated struct and its encoding. Omitting
+	// a field changes the encoded layout of the message.
+	Omit bool `protobuf:"varint,4,opt,name=omit,proto3" json:"omit,omitempty"`
+	// Constraints checked by the generated Validate method.
+	Rules         *Rules `protobuf:"bytes,5,opt,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldOptions) Reset() {
+	*x = FieldOptions{}
+	mi := &file_polyglot_options_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldOptions) ProtoMessage() {}
+
+func (x *FieldOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_polyglot_options_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldOptions.ProtoReflect.Descriptor instead.
+func (*FieldOptions) Descriptor() ([]byte, []int) {
+	return file_polyglot_options_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FieldOptions) GetGoTags() string {
+	if x != nil {
+		return x.GoTags
+	}
+	return ""
+}
+
+func (x *FieldOptions) GetGoType() string {
+	if x != nil {
+		return x.GoType
+	}
+	return ""
+}
+
+func (x *FieldOptions) GetGoName() string {
+	if x != nil {
+		return x.GoName
+	}
+	return ""
+}
+
+func (x *FieldOptions) GetOmit() bool {
+	if x != nil {
+		return x.Omit
+	}
+	return false
+}
+
+func (x *FieldOptions) GetRules() *Rules {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// Rules are the constraints of a single field. Each rule only applies to the
+// kinds of fields listed next to it.
+type Rules struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Any singular field: messages must be set, and other values must not be
+	// the zero value. Repeated and map fields must not be empty.
+	Required bool `protobuf:"varint,1,opt,name=required,proto3" json:"required,omitempty"`
+	// Strings and bytes: bounds on the length in characters for strings, and
+	// in bytes for bytes fields.
+	MinLen *uint64 `protobuf:"varint,2,opt,name=min_len,json=minLen,proto3,oneof" json:"min_len,omitempty"`
+	MaxLen *uint64 `protobuf:"varint,3,opt,name=max_len,json=maxLen,proto3,oneof" json:"max_len,omitempty"`
+	// Strings: a regular expression in RE2 syntax the value must match.
+	Pattern string `protobuf:"bytes,4,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	// Numbers: bounds on the value. Integer fields are compared exactly in their
+	// own type, with bounds that are not whole numbers rounded accordingly.
+	Gt  *float64 `protobuf:"fixed64,5,opt,name=gt,proto3,oneof" json:"gt,omitempty"`
+	Gte *float64 `protobuf:"fixed64,6,opt,name=gte,proto3,oneof" json:"gte,omitempty"`
+	Lt  *float64 `protobuf:"fixed64,7,opt,name=lt,proto3,oneof" json:"lt,omitempty"`
+	Lte *float64 `protobuf:"fixed64,8,opt,name=lte,proto3,oneof" json:"lte,omitempty"`
+	// Repeated and map fields: bounds on the number of items.
+	MinItems *uint64 `protobuf:"varint,9,opt,name=min_items,json=minItems,proto3,oneof" json:"min_items,omitempty"`
+	MaxItems *uint64 `protobuf:"varint,10,opt,name=max_items,json=maxItems,proto3,oneof" json:"max_items,omitempty"`
+	// Enums, including repeated enums: the value must be declared in the enum.
+	DefinedOnly   bool `protobuf:"varint,11,opt,name=defined_only,json=definedOnly,proto3" json:"defined_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rules) Reset() {
+	*x = Rules{}
+	mi := &file_polyglot_options_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rules) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rules) ProtoMessage() {}
+
+func (x *Rules) ProtoReflect() protoreflect.Message {
+	mi := &file_polyglot_options_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rules.ProtoReflect.Descriptor instead.
+func (*Rules) Descriptor() ([]byte, []int) {
+	return file_polyglot_options_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Rules) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *Rules) GetMinLen() uint64 {
+	if x != nil && x.MinLen != nil {
+		return *x.MinLen
+	}
+	return 0
+}
+
+func (x *Rules) GetMaxLen() uint64 {
+	if x != nil && x.MaxLen != nil {
+		return *x.MaxLen
+	}
+	return 0
+}
+
+func (x *Rules) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *Rules) GetGt() float64 {
+	if x != nil && x.Gt != nil {
+		return *x.Gt
+	}
+	return 0
+}
+
+func (x *Rules) GetGte() float64 {
+	if x != nil && x.Gte != nil {
+		return *x.Gte
+	}
+	return 0
+}
+
+func (x *Rules) GetLt() float64 {
+	if x != nil && x.Lt != nil {
+		return *x.Lt
+	}
+	return 0
+}
+
+func (x *Rules) GetLte() float64 {
+	if x != nil && x.Lte != nil {
+		return *x.Lte
+	}
+	return 0
+}
+
+func (x *Rules) GetMinItems() uint64 {
+	if x != nil && x.MinItems != nil {
+		return *x.MinItems
+	}
+	return 0
+}
+
+func (x *Rules) GetMaxItems() uint64 {
+	if x != nil && x.MaxItems != nil {
+		return *x.MaxItems
+	}
+	return 0
+}
+
+func (x *Rules) GetDefinedOnly() bool {
+	if x != nil {
+		return x.DefinedOnly
+	}
+	return false
+}
+
+type MessageOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Go struct tags added to every field of the message. "{name}" and
+	// "{json}" are replaced with the proto and JSON names of each field.
+	GoTags        string `protobuf:"bytes,1,opt,name=go_tags,json=goTags,proto3" json:"go_tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageOptions) Reset() {
+	*x = MessageOptions{}
+	mi := &file_polyglot_options_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageOptions) ProtoMessage() {}
+
+func (x *MessageOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_polyglot_options_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageOptions.ProtoReflect.Descriptor instead.
+func (*MessageOptions) Descriptor() ([]byte, []int) {
+	return file_polyglot_options_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MessageOptions) GetGoTags() string {
+	if x != nil {
+		return x.GoTags
+	}
+	return ""
+}
+
+var file_polyglot_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*FieldOptions)(nil),
+		Field:         50470,
+		Name:          "polyglot.field",
+		Tag:           "bytes,50470,opt,name=field",
+		Filename:      "polyglot/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
+		ExtensionType: (*MessageOptions)(nil),
+		Field:         50470,
+		Name:          "polyglot.message",
+		Tag:           "bytes,50470,opt,name=message",
+		Filename:      "polyglot/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional polyglot.FieldOptions field = 50470;
+	E_Field = &file_polyglot_options_proto_extTypes[0]
+)
+
+// Extension fields to descriptorpb.MessageOptions.
+var (
+	// optional polyglot.MessageOptions message = 50470;
+	E_Message = &file_polyglot_options_proto_extTypes[1]
+)
+
+var File_polyglot_options_proto protoreflect.FileDescriptor
+
+const file_polyglot_options_proto_rawDesc = "" +
+	"\n" +
+	"\x16polyglot/options.proto\x12\bpolyglot\x1a google/protobuf/descriptor.proto\"\x94\x01\n" +
+	"\fFieldOptions\x12\x17\n" +
+	"\ago_tags\x18\x01 \x01(\tR\x06goTags\x12\x17\n" +
+	"\ago_type\x18\x02 \x01(\tR\x06goType\x12\x17\n" +
+	"\ago_name\x18\x03 \x01(\tR\x06goName\x12\x12\n" +
+	"\x04omit\x18\x04 \x01(\bR\x04omit\x12%\n" +
+	"\x05rules\x18\x05 \x01(\v2\x0f.polyglot.RulesR\x05rules\"\x8a\x03\n" +
+	"\x05Rules\x12\x1a\n" +
+	"\brequired\x18\x01 \x01(\bR\brequired\x12\x1c\n" +
+	"\amin_len\x18\x02 \x01(\x04H\x00R\x06minLen\x88\x01\x01\x12\x1c\n" +
+	"\amax_len\x18\x03 \x01(\x04H\x01R\x06maxLen\x88\x01\x01\x12\x18\n" +
+	"\apattern\x18\x04 \x01(\tR\apattern\x12\x13\n" +
+	"\x02gt\x18\x05 \x01(\x01H\x02R\x02gt\x88\x01\x01\x12\x15\n" +
+	"\x03gte\x18\x06 \x01(\x01H\x03R\x03gte\x88\x01\x01\x12\x13\n" +
+	"\x02lt\x18\a \x01(\x01H\x04R\x02lt\x88\x01\x01\x12\x15\n" +
+	"\x03lte\x18\b \x01(\x01H\x05R\x03lte\x88\x01\x01\x12 \n" +
+	"\tmin_items\x18\t \x01(\x04H\x06R\bminItems\x88\x01\x01\x12 \n" +
+	"\tmax_items\x18\n" +
+	" \x01(\x04H\aR\bmaxItems\x88\x01\x01\x12!\n" +
+	"\fdefined_only\x18\v \x01(\bR\vdefinedOnlyB\n" +
+	"\n" +
+	"\b_min_lenB\n" +
+	"\n" +
+	"\b_max_lenB\x05\n" +
+	"\x03_gtB\x06\n" +
+	"\x04_gteB\x05\n" +
+	"\x03_ltB\x06\n" +
+	"\x04_lteB\f\n" +
+	"\n" +
+	"_min_itemsB\f\n" +
+	"\n" +
+	"_max_items\")\n" +
+	"\x0eMessageOptions\x12\x17\n" +
+	"\ago_tags\x18\x01 \x01(\tR\x06goTags:M\n" +
+	"\x05field\x12\x1d.google.protobuf.FieldOptions\x18\xa6\x8a\x03 \x01(\v2\x16.polyglot.FieldOptionsR\x05field:U\n" +
+	"\amessage\x12\x1f.google.protobuf.MessageOptions\x18\xa6\x8a\x03 \x01(\v2\x18.polyglot.MessageOptionsR\amessageB-Z+github.com/loopholelabs/polyglot/v2/optionsb\x06proto3"
+
+var (
+	file_polyglot_options_proto_rawDescOnce sync.Once
+	file_polyglot_options_proto_rawDescData []byte
+)
+
+func file_polyglot_options_proto_rawDescGZIP() []byte {
+	file_polyglot_options_proto_rawDescOnce.Do(func() {
+		file_polyglot_options_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_polyglot_options_proto_rawDesc), len(file_polyglot_options_proto_rawDesc)))
+	})
+	return file_polyglot_options_proto_rawDescData
+}
+
+var file_polyglot_options_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_polyglot_options_proto_goTypes = []any{
+	(*FieldOptions)(nil),                // 0: polyglot.FieldOptions
+	(*Rules)(nil),                       // 1: polyglot.Rules
+	(*MessageOptions)(nil),              // 2: polyglot.MessageOptions
+	(*descriptorpb.FieldOptions)(nil),   // 3: google.protobuf.FieldOptions
+	(*descriptorpb.MessageOptions)(nil), // 4: google.protobuf.MessageOptions
+}
+var file_polyglot_options_proto_depIdxs = []int32{
+	1, // 0: polyglot.FieldOptions.rules:type_name -> polyglot.Rules
+	3, // 1: polyglot.field:extendee -> google.protobuf.FieldOptions
+	4, // 2: polyglot.message:extendee -> google.protobuf.MessageOptions
+	0, // 3: polyglot.field:type_name -> polyglot.FieldOptions
+	2, // 4: polyglot.message:type_name -> polyglot.MessageOptions
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	3, // [3:5] is the sub-list for extension type_name
+	1, // [1:3] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_polyglot_options_proto_init() }
+func file_polyglot_options_proto_init() {
+	if File_polyglot_options_proto != nil {
+		return
+	}
+	file_polyglot_options_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_polyglot_options_proto_rawDesc), len(file_polyglot_options_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_polyglot_options_proto_goTypes,
+		DependencyIndexes: file_polyglot_options_proto_depIdxs,
+		MessageInfos:      file_polyglot_options_proto_msgTypes,
+		ExtensionInfos:    file_polyglot_options_proto_extTypes,
+	}.Build()
+	File_polyglot_options_proto = out.File
+	file_polyglot_options_proto_goTypes = nil
+	file_polyglot_options_proto_depIdxs = nil
+}
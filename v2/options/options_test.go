@@ -0,0 +1,80 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package options
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	fieldOptions := new(descriptorpb.FieldOptions)
+	proto.SetExtension(fieldOptions, E_Field, &FieldOptions{GoName: "ID", Omit: true})
+
+	// The message options are only present as unknown fields, as they are
+	// when they were parsed without this package being linked in.
+	value, err := proto.Marshal(&MessageOptions{GoTags: `json:"{json}"`})
+	require.NoError(t, err)
+	unknown := protowire.AppendTag(nil, E_Message.TypeDescriptor().Number(), protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, value)
+	messageOptions := new(descriptorpb.MessageOptions)
+	messageOptions.ProtoReflect().SetUnknown(unknown)
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("options_test.proto"),
+		Package: proto.String("options"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:    proto.String("Test"),
+			Options: messageOptions,
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:    proto.String("id"),
+					Number:  proto.Int32(1),
+					Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Label:   descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Options: fieldOptions,
+				},
+				{
+					Name:   proto.String("name"),
+					Number: proto.Int32(2),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				},
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+
+	message := file.Messages().Get(0)
+	id := message.Fields().ByName("id")
+	name := message.Fields().ByName("name")
+
+	assert.Equal(t, "ID", Field(id).GetGoName())
+	assert.True(t, Omitted(id))
+	assert.Empty(t, Field(name).GetGoName())
+	assert.False(t, Omitted(name))
+	assert.Equal(t, `json:"{json}"`, Message(message).GetGoTags())
+}
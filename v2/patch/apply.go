@@ -0,0 +1,173 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package patch
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+
+	"bytes"
+)
+
+// Apply applies the changes of p to m, which must be of the type p was
+// computed for. A nil patch has no changes and leaves m as it is.
+func Apply(m Message, p *Patch) error {
+	if p == nil {
+		return nil
+	}
+	desc := m.Descriptor()
+	if p.Message != desc.FullName {
+		return ErrTypeMismatch
+	}
+	if p.Empty() {
+		return nil
+	}
+	b := polyglot.NewBuffer()
+	m.Encode(b)
+	data := b.Bytes()
+	for i := range p.Changes {
+		var err error
+		if data, err = apply(desc, data, &p.Changes[i], 0); err != nil {
+			return err
+		}
+	}
+	return m.Decode(data)
+}
+
+func apply(m *descriptor.Message, data []byte, c *Change, depth int) ([]byte, error) {
+	if depth >= len(c.Path) {
+		return nil, ErrInvalidChange
+	}
+	values, err := split(m, data)
+	if err != nil {
+		return nil, err
+	}
+	i, f := fieldByNumber(m, c.Path[depth])
+	if f == nil {
+		return nil, ErrUnknownField
+	}
+
+	if depth < len(c.Path)-1 {
+		if f.IsRepeated() || f.IsMap() || !f.Value.IsMessage() {
+			return nil, ErrInvalidChange
+		}
+		nested, err := findMessage(f.Value.Message)
+		if err != nil {
+			return nil, err
+		}
+		if values[i], err = apply(nested, values[i], c, depth+1); err != nil {
+			return nil, err
+		}
+		return join(values), nil
+	}
+
+	switch c.Op {
+	case OpSet:
+		if n, err := fieldExtent(f, c.Value); err != nil || n != len(c.Value) {
+			return nil, ErrInvalidChange
+		}
+		values[i] = c.Value
+	case OpClear:
+		values[i] = zero(f)
+	case OpInsert, OpRemove:
+		if !f.IsRepeated() {
+			return nil, ErrInvalidChange
+		}
+		if values[i], err = applySlice(f, values[i], c); err != nil {
+			return nil, err
+		}
+	case OpPut, OpDelete:
+		if !f.IsMap() {
+			return nil, ErrInvalidChange
+		}
+		if values[i], err = applyMap(f, values[i], c); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrInvalidChange
+	}
+	return join(values), nil
+}
+
+func fieldByNumber(m *descriptor.Message, number int32) (int, *descriptor.Field) {
+	for i, f := range layout(m) {
+		if f.Number == number {
+			return i, f
+		}
+	}
+	return -1, nil
+}
+
+func applySlice(f *descriptor.Field, data []byte, c *Change) ([]byte, error) {
+	_, elements, err := sliceElements(f, data)
+	if err != nil {
+		return nil, err
+	}
+	index := int(c.Index)
+	switch c.Op {
+	case OpInsert:
+		if index > len(elements) {
+			return nil, ErrInvalidChange
+		}
+		if n, err := valueExtent(f.Value, c.Value); err != nil || n != len(c.Value) {
+			return nil, ErrInvalidChange
+		}
+		elements = append(elements[:index], append([][]byte{c.Value}, elements[index:]...)...)
+	case OpRemove:
+		if index >= len(elements) {
+			return nil, ErrInvalidChange
+		}
+		elements = append(elements[:index], elements[index+1:]...)
+	}
+	return encodeSlice(f, elements), nil
+}
+
+func applyMap(f *descriptor.Field, data []byte, c *Change) ([]byte, error) {
+	if isNil(data) {
+		data = zero(f)
+	}
+	_, entries, err := mapEntries(f, data)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := valueExtent(f.Key, c.Key); err != nil || n != len(c.Key) {
+		return nil, ErrInvalidChange
+	}
+	index := -1
+	for i, e := range entries {
+		if bytes.Equal(e.key, c.Key) {
+			index = i
+			break
+		}
+	}
+	switch c.Op {
+	case OpPut:
+		if n, err := valueExtent(f.Value, c.Value); err != nil || n != len(c.Value) {
+			return nil, ErrInvalidChange
+		}
+		if index < 0 {
+			entries = append(entries, entry{key: c.Key, value: c.Value})
+		} else {
+			entries[index].value = c.Value
+		}
+	case OpDelete:
+		if index >= 0 {
+			entries = append(entries[:index], entries[index+1:]...)
+		}
+	}
+	return encodeMap(f, entries), nil
+}
@@ -0,0 +1,225 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package patch
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+
+	"bytes"
+)
+
+const (
+	// maxEditCells bounds the size of the table used to find the smallest
+	// set of inserts and removes between two repeated fields. Larger fields
+	// are replaced as a whole past their common prefix and suffix.
+	maxEditCells = 1 << 20
+)
+
+// Diff returns the changes that turn from into to. Both messages must be of
+// the same type.
+func Diff(from Message, to Message) (*Patch, error) {
+	desc := from.Descriptor()
+	if to.Descriptor().FullName != desc.FullName {
+		return nil, ErrTypeMismatch
+	}
+	fb := polyglot.NewBuffer()
+	from.Encode(fb)
+	tb := polyglot.NewBuffer()
+	to.Encode(tb)
+
+	p := &Patch{Message: desc.FullName}
+	if err := p.diffMessage(nil, desc, fb.Bytes(), tb.Bytes()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Patch) add(op Op, path []int32, c Change) {
+	c.Op = op
+	c.Path = path
+	p.Changes = append(p.Changes, c)
+}
+
+func appendPath(path []int32, number int32) []int32 {
+	return append(path[:len(path):len(path)], number)
+}
+
+func (p *Patch) diffMessage(path []int32, m *descriptor.Message, from []byte, to []byte) error {
+	fromValues, err := split(m, from)
+	if err != nil {
+		return err
+	}
+	toValues, err := split(m, to)
+	if err != nil {
+		return err
+	}
+	for i, f := range layout(m) {
+		if bytes.Equal(fromValues[i], toValues[i]) {
+			continue
+		}
+		fieldPath := appendPath(path, f.Number)
+		switch {
+		case f.IsRepeated():
+			err = p.diffSlice(fieldPath, f, fromValues[i], toValues[i])
+		case f.IsMap():
+			err = p.diffMap(fieldPath, f, fromValues[i], toValues[i])
+		case f.Value.IsMessage():
+			switch {
+			case isNil(toValues[i]):
+				p.add(OpClear, fieldPath, Change{})
+			case isNil(fromValues[i]):
+				p.add(OpSet, fieldPath, Change{Value: toValues[i]})
+			default:
+				var nested *descriptor.Message
+				if nested, err = findMessage(f.Value.Message); err == nil {
+					err = p.diffMessage(fieldPath, nested, fromValues[i], toValues[i])
+				}
+			}
+		default:
+			if bytes.Equal(toValues[i], zero(f)) {
+				p.add(OpClear, fieldPath, Change{})
+			} else {
+				p.add(OpSet, fieldPath, Change{Value: toValues[i]})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Patch) diffSlice(path []int32, f *descriptor.Field, from []byte, to []byte) error {
+	_, a, err := sliceElements(f, from)
+	if err != nil {
+		return err
+	}
+	_, b, err := sliceElements(f, to)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(b) == 0:
+		p.add(OpClear, path, Change{})
+		return nil
+	case len(a) == 0:
+		p.add(OpSet, path, Change{Value: to})
+		return nil
+	}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && bytes.Equal(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && bytes.Equal(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+	edits := edit(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if len(edits) >= len(b) {
+		// Sending the whole field is no larger than the edits.
+		p.add(OpSet, path, Change{Value: to})
+		return nil
+	}
+	for _, e := range edits {
+		e.Index += uint32(prefix)
+		p.add(e.Op, path, e)
+	}
+	return nil
+}
+
+// edit returns the inserts and removes that turn a into b, using the longest
+// common subsequence of their elements when it is cheap enough to find.
+func edit(a [][]byte, b [][]byte) []Change {
+	var edits []Change
+	if len(a)*len(b) > maxEditCells {
+		for range a {
+			edits = append(edits, Change{Op: OpRemove})
+		}
+		for j := range b {
+			edits = append(edits, Change{Op: OpInsert, Index: uint32(j), Value: b[j]})
+		}
+		return edits
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if bytes.Equal(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j, index := 0, 0, uint32(0)
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && bytes.Equal(a[i], b[j]):
+			i++
+			j++
+			index++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, Change{Op: OpRemove, Index: index})
+			i++
+		default:
+			edits = append(edits, Change{Op: OpInsert, Index: index, Value: b[j]})
+			j++
+			index++
+		}
+	}
+	return edits
+}
+
+func (p *Patch) diffMap(path []int32, f *descriptor.Field, from []byte, to []byte) error {
+	_, a, err := mapEntries(f, from)
+	if err != nil {
+		return err
+	}
+	_, b, err := mapEntries(f, to)
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		p.add(OpClear, path, Change{})
+		return nil
+	}
+
+	values := make(map[string][]byte, len(a))
+	for _, e := range a {
+		values[string(e.key)] = e.value
+	}
+	keys := make(map[string]struct{}, len(b))
+	for _, e := range b {
+		keys[string(e.key)] = struct{}{}
+		if value, ok := values[string(e.key)]; !ok || !bytes.Equal(value, e.value) {
+			p.add(OpPut, path, Change{Key: e.key, Value: e.value})
+		}
+	}
+	for _, e := range a {
+		if _, ok := keys[string(e.key)]; !ok {
+			p.add(OpDelete, path, Change{Key: e.key})
+		}
+	}
+	return nil
+}
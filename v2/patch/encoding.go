@@ -0,0 +1,272 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package patch
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+
+	"sync"
+)
+
+var (
+	layouts sync.Map
+)
+
+// layout returns the fields of a message in encoding order.
+func layout(m *descriptor.Message) []*descriptor.Field {
+	if fields, ok := layouts.Load(m); ok {
+		return fields.([]*descriptor.Field)
+	}
+	fields := m.EncodingOrder()
+	layouts.Store(m, fields)
+	return fields
+}
+
+func findMessage(name string) (*descriptor.Message, error) {
+	m, ok := descriptor.FindMessage(name)
+	if !ok {
+		return nil, ErrUnknownMessage
+	}
+	return m, nil
+}
+
+func isNil(b []byte) bool {
+	return len(b) > 0 && b[0] == polyglot.NilRawKind
+}
+
+// split returns the encoded value of every field of an encoded message, in
+// encoding order. The fields of a nil message have their zero values.
+func split(m *descriptor.Message, b []byte) ([][]byte, error) {
+	fields := layout(m)
+	values := make([][]byte, len(fields))
+	if isNil(b) {
+		if len(b) != 1 {
+			return nil, polyglot.ErrInvalidValue
+		}
+		for i, f := range fields {
+			values[i] = zero(f)
+		}
+		return values, nil
+	}
+	for i, f := range fields {
+		n, err := fieldExtent(f, b)
+		if err != nil {
+			return nil, err
+		}
+		values[i], b = b[:n:n], b[n:]
+	}
+	if len(b) != 0 {
+		return nil, polyglot.ErrInvalidValue
+	}
+	return values, nil
+}
+
+func join(values [][]byte) []byte {
+	size := 0
+	for _, v := range values {
+		size += len(v)
+	}
+	b := make([]byte, 0, size)
+	for _, v := range values {
+		b = append(b, v...)
+	}
+	return b
+}
+
+func messageExtent(name string, b []byte) (int, error) {
+	if isNil(b) {
+		return 1, nil
+	}
+	m, err := findMessage(name)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, f := range layout(m) {
+		size, err := fieldExtent(f, b[n:])
+		if err != nil {
+			return 0, err
+		}
+		n += size
+	}
+	return n, nil
+}
+
+func valueExtent(t descriptor.Type, b []byte) (int, error) {
+	if t.IsMessage() {
+		return messageExtent(t.Message, b)
+	}
+	return polyglot.Extent(b)
+}
+
+func fieldExtent(f *descriptor.Field, b []byte) (int, error) {
+	switch {
+	case f.IsRepeated():
+		header, elements, err := sliceElements(f, b)
+		if err != nil {
+			return 0, err
+		}
+		return header + totalSize(elements), nil
+	case f.IsMap():
+		if isNil(b) {
+			return 1, nil
+		}
+		header, entries, err := mapEntries(f, b)
+		if err != nil {
+			return 0, err
+		}
+		n := header
+		for _, e := range entries {
+			n += len(e.key) + len(e.value)
+		}
+		return n, nil
+	default:
+		return valueExtent(f.Value, b)
+	}
+}
+
+func totalSize(values [][]byte) int {
+	n := 0
+	for _, v := range values {
+		n += len(v)
+	}
+	return n
+}
+
+// sliceElements returns the size of the header of an encoded repeated field
+// and the encoded value of each of its elements.
+func sliceElements(f *descriptor.Field, b []byte) (int, [][]byte, error) {
+	d := polyglot.Decoder(b)
+	size, err := d.Slice(f.Value.Kind)
+	if err != nil {
+		return 0, nil, err
+	}
+	header := len(b) - len(*d)
+	rest := []byte(*d)
+	if uint64(size) > uint64(len(rest)) {
+		return 0, nil, polyglot.ErrInvalidValue
+	}
+	elements := make([][]byte, size)
+	for i := range elements {
+		n, err := valueExtent(f.Value, rest)
+		if err != nil {
+			return 0, nil, err
+		}
+		elements[i], rest = rest[:n:n], rest[n:]
+	}
+	return header, elements, nil
+}
+
+type entry struct {
+	key   []byte
+	value []byte
+}
+
+// mapEntries returns the size of the header of an encoded map field and the
+// encoded key and value of each of its entries.
+func mapEntries(f *descriptor.Field, b []byte) (int, []entry, error) {
+	d := polyglot.Decoder(b)
+	size, err := d.Map(f.Key.Kind, f.Value.Kind)
+	if err != nil {
+		return 0, nil, err
+	}
+	header := len(b) - len(*d)
+	rest := []byte(*d)
+	if uint64(size) > uint64(len(rest)) {
+		return 0, nil, polyglot.ErrInvalidValue
+	}
+	entries := make([]entry, size)
+	for i := range entries {
+		n, err := valueExtent(f.Key, rest)
+		if err != nil {
+			return 0, nil, err
+		}
+		entries[i].key, rest = rest[:n:n], rest[n:]
+		n, err = valueExtent(f.Value, rest)
+		if err != nil {
+			return 0, nil, err
+		}
+		entries[i].value, rest = rest[:n:n], rest[n:]
+	}
+	return header, entries, nil
+}
+
+func encodeSlice(f *descriptor.Field, elements [][]byte) []byte {
+	b := polyglot.NewBufferSize(16 + totalSize(elements))
+	polyglot.Encoder(b).Slice(uint32(len(elements)), f.Value.Kind)
+	for _, e := range elements {
+		b.Write(e)
+	}
+	return b.Bytes()
+}
+
+func encodeMap(f *descriptor.Field, entries []entry) []byte {
+	size := 16
+	for _, e := range entries {
+		size += len(e.key) + len(e.value)
+	}
+	b := polyglot.NewBufferSize(size)
+	polyglot.Encoder(b).Map(uint32(len(entries)), f.Key.Kind, f.Value.Kind)
+	for _, e := range entries {
+		b.Write(e.key)
+		b.Write(e.value)
+	}
+	return b.Bytes()
+}
+
+// zero returns the encoding of the zero value of a field.
+func zero(f *descriptor.Field) []byte {
+	b := polyglot.NewBufferSize(16)
+	e := polyglot.Encoder(b)
+	switch {
+	case f.IsRepeated():
+		e.Slice(0, f.Value.Kind)
+	case f.IsMap():
+		e.Map(0, f.Key.Kind, f.Value.Kind)
+	case f.Value.IsMessage():
+		e.Nil()
+	default:
+		switch f.Value.Kind {
+		case polyglot.BoolKind:
+			e.Bool(false)
+		case polyglot.StringKind:
+			e.String("")
+		case polyglot.BytesKind:
+			e.Bytes(nil)
+		case polyglot.Uint8Kind:
+			e.Uint8(0)
+		case polyglot.Uint16Kind:
+			e.Uint16(0)
+		case polyglot.Uint32Kind:
+			e.Uint32(0)
+		case polyglot.Uint64Kind:
+			e.Uint64(0)
+		case polyglot.Int32Kind:
+			e.Int32(0)
+		case polyglot.Int64Kind:
+			e.Int64(0)
+		case polyglot.Float32Kind:
+			e.Float32(0)
+		case polyglot.Float64Kind:
+			e.Float64(0)
+		default:
+			e.Nil()
+		}
+	}
+	return b.Bytes()
+}
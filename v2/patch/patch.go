@@ -0,0 +1,174 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package patch computes the changes between two generated messages of the
+// same type, and applies them to other messages of that type. Patches are
+// computed on the polyglot encoding of the messages using the descriptors
+// embedded in generated code, so they work with any generated message.
+package patch
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+
+	"errors"
+)
+
+var (
+	ErrDecodeNil      = errors.New("cannot decode into a nil patch")
+	ErrTypeMismatch   = errors.New("messages are of different types")
+	ErrUnknownMessage = errors.New("unknown message type")
+	ErrUnknownField   = errors.New("unknown field")
+	ErrInvalidChange  = errors.New("invalid change")
+)
+
+// Message is implemented by generated messages.
+type Message interface {
+	Encode(b *polyglot.Buffer)
+	Decode(b []byte) error
+	Descriptor() *descriptor.Message
+}
+
+// Op is the kind of a Change.
+type Op uint8
+
+const (
+	// OpSet replaces the value of a field with Value.
+	OpSet Op = iota + 1
+	// OpClear resets a field to its zero value.
+	OpClear
+	// OpInsert inserts Value into a repeated field at Index.
+	OpInsert
+	// OpRemove removes the element at Index from a repeated field.
+	OpRemove
+	// OpPut adds Key to a map field with Value, or replaces its value.
+	OpPut
+	// OpDelete removes Key from a map field.
+	OpDelete
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpSet:
+		return "set"
+	case OpClear:
+		return "clear"
+	case OpInsert:
+		return "insert"
+	case OpRemove:
+		return "remove"
+	case OpPut:
+		return "put"
+	case OpDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a single change to a field. Path holds the numbers of the
+// message fields leading from the root message to the changed field, which
+// is last. Key and Value hold polyglot encoded values.
+type Change struct {
+	Op    Op
+	Path  []int32
+	Index uint32
+	Key   []byte
+	Value []byte
+}
+
+// Patch is an ordered list of changes to a message of type Message. The Index
+// of an OpInsert or OpRemove change refers to the repeated field as left by
+// the changes before it.
+type Patch struct {
+	Message string
+	Changes []Change
+}
+
+// Empty reports whether the patch has no changes.
+func (p *Patch) Empty() bool {
+	return p == nil || len(p.Changes) == 0
+}
+
+func (p *Patch) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(p.Message).Slice(uint32(len(p.Changes)), polyglot.AnyKind)
+	for _, c := range p.Changes {
+		polyglot.Encoder(b).Uint8(uint8(c.Op)).Slice(uint32(len(c.Path)), polyglot.Int32Kind)
+		for _, number := range c.Path {
+			polyglot.Encoder(b).Int32(number)
+		}
+		polyglot.Encoder(b).Uint32(c.Index).Bytes(c.Key).Bytes(c.Value)
+	}
+}
+
+func (p *Patch) Decode(b []byte) error {
+	if p == nil {
+		return ErrDecodeNil
+	}
+	d := polyglot.Decoder(b)
+
+	var err error
+	p.Message, err = d.String()
+	if err != nil {
+		return err
+	}
+	size, err := d.Slice(polyglot.AnyKind)
+	if err != nil {
+		return err
+	}
+	// Every encoded value takes at least one byte, so sizes larger than the
+	// rest of the buffer are invalid and must not be allocated.
+	if int(size) > len(*d) {
+		return polyglot.ErrInvalidSlice
+	}
+	p.Changes = make([]Change, size)
+	for i := range p.Changes {
+		c := &p.Changes[i]
+		var op uint8
+		op, err = d.Uint8()
+		if err != nil {
+			return err
+		}
+		c.Op = Op(op)
+		size, err = d.Slice(polyglot.Int32Kind)
+		if err != nil {
+			return err
+		}
+		if int(size) > len(*d) {
+			return polyglot.ErrInvalidSlice
+		}
+		c.Path = make([]int32, size)
+		for j := range c.Path {
+			c.Path[j], err = d.Int32()
+			if err != nil {
+				return err
+			}
+		}
+		c.Index, err = d.Uint32()
+		if err != nil {
+			return err
+		}
+		c.Key, err = d.Bytes(nil)
+		if err != nil {
+			return err
+		}
+		c.Value, err = d.Bytes(nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
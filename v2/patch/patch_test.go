@@ -0,0 +1,294 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package patch
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/loopholelabs/polyglot/v2/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+var testFile = descriptor.RegisterEmbedded(func() []byte {
+	b := polyglot.NewBuffer()
+	(&descriptor.File{
+		Path:    "patch_test.proto",
+		Package: "patchtest",
+		Messages: []*descriptor.Message{
+			{
+				FullName: "patchtest.User",
+				Fields: []*descriptor.Field{
+					{Name: "name", Number: 1, Kind: polyglot.StringKind, Value: descriptor.Type{Kind: polyglot.StringKind}},
+					{Name: "age", Number: 2, Kind: polyglot.Uint32Kind, Value: descriptor.Type{Kind: polyglot.Uint32Kind}},
+					{Name: "tags", Number: 3, Kind: polyglot.SliceKind, Value: descriptor.Type{Kind: polyglot.StringKind}},
+					{Name: "address", Number: 4, Kind: polyglot.AnyKind, Value: descriptor.Type{Kind: polyglot.AnyKind, Message: "patchtest.Address"}},
+					{Name: "labels", Number: 5, Kind: polyglot.MapKind, Key: descriptor.Type{Kind: polyglot.StringKind}, Value: descriptor.Type{Kind: polyglot.StringKind}},
+					{Name: "friends", Number: 6, Kind: polyglot.SliceKind, Value: descriptor.Type{Kind: polyglot.AnyKind, Message: "patchtest.Address"}},
+				},
+			},
+			{
+				FullName: "patchtest.Address",
+				Fields: []*descriptor.Field{
+					{Name: "city", Number: 1, Kind: polyglot.StringKind, Value: descriptor.Type{Kind: polyglot.StringKind}},
+					{Name: "zip", Number: 2, Kind: polyglot.Uint32Kind, Value: descriptor.Type{Kind: polyglot.Uint32Kind}},
+				},
+			},
+		},
+	}).Encode(b)
+	return b.Bytes()
+}())
+
+type testAddress struct {
+	City string
+	Zip  uint32
+}
+
+func (x *testAddress) Descriptor() *descriptor.Message {
+	return testFile.Messages[1]
+}
+
+func (x *testAddress) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Nil()
+		return
+	}
+	polyglot.Encoder(b).String(x.City).Uint32(x.Zip)
+}
+
+func (x *testAddress) Decode(b []byte) error {
+	return x.decode(polyglot.Decoder(b))
+}
+
+func (x *testAddress) decode(d *polyglot.BufferDecoder) (err error) {
+	if d.Nil() {
+		return nil
+	}
+	if x.City, err = d.String(); err != nil {
+		return err
+	}
+	x.Zip, err = d.Uint32()
+	return err
+}
+
+type testUser struct {
+	Name    string
+	Age     uint32
+	Tags    []string
+	Address *testAddress
+	Labels  map[string]string
+	Friends []*testAddress
+}
+
+func (x *testUser) Descriptor() *descriptor.Message {
+	return testFile.Messages[0]
+}
+
+func (x *testUser) Encode(b *polyglot.Buffer) {
+	if x == nil {
+		polyglot.Encoder(b).Nil()
+		return
+	}
+	polyglot.Encoder(b).String(x.Name).Uint32(x.Age).Slice(uint32(len(x.Tags)), polyglot.StringKind)
+	for _, v := range x.Tags {
+		polyglot.Encoder(b).String(v)
+	}
+	polyglot.Encoder(b).Slice(uint32(len(x.Friends)), polyglot.AnyKind)
+	for _, v := range x.Friends {
+		v.Encode(b)
+	}
+	x.Address.Encode(b)
+	polyglot.Encoder(b).Map(uint32(len(x.Labels)), polyglot.StringKind, polyglot.StringKind)
+	for k, v := range x.Labels {
+		polyglot.Encoder(b).String(k).String(v)
+	}
+}
+
+func (x *testUser) Decode(b []byte) (err error) {
+	d := polyglot.Decoder(b)
+	*x = testUser{}
+	if d.Nil() {
+		return nil
+	}
+	if x.Name, err = d.String(); err != nil {
+		return err
+	}
+	if x.Age, err = d.Uint32(); err != nil {
+		return err
+	}
+	size, err := d.Slice(polyglot.StringKind)
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < size; i++ {
+		v, err := d.String()
+		if err != nil {
+			return err
+		}
+		x.Tags = append(x.Tags, v)
+	}
+	if size, err = d.Slice(polyglot.AnyKind); err != nil {
+		return err
+	}
+	for i := uint32(0); i < size; i++ {
+		v := new(testAddress)
+		if err = v.decode(d); err != nil {
+			return err
+		}
+		x.Friends = append(x.Friends, v)
+	}
+	if !d.Nil() {
+		x.Address = new(testAddress)
+		if err = x.Address.decode(d); err != nil {
+			return err
+		}
+	}
+	if size, err = d.Map(polyglot.StringKind, polyglot.StringKind); err != nil {
+		return err
+	}
+	for i := uint32(0); i < size; i++ {
+		k, err := d.String()
+		if err != nil {
+			return err
+		}
+		v, err := d.String()
+		if err != nil {
+			return err
+		}
+		if x.Labels == nil {
+			x.Labels = make(map[string]string)
+		}
+		x.Labels[k] = v
+	}
+	return nil
+}
+
+func TestDiffApply(t *testing.T) {
+	t.Parallel()
+
+	from := &testUser{
+		Name:    "alice",
+		Age:     30,
+		Tags:    []string{"a", "b", "c", "d"},
+		Address: &testAddress{City: "Toronto", Zip: 1},
+		Labels:  map[string]string{"team": "core", "role": "dev"},
+		Friends: []*testAddress{{City: "x"}, {City: "y"}},
+	}
+	to := &testUser{
+		Name:    "alice",
+		Tags:    []string{"a", "c", "e", "d"},
+		Address: &testAddress{City: "Ottawa", Zip: 1},
+		Labels:  map[string]string{"team": "infra", "lang": "go"},
+		Friends: []*testAddress{{City: "x"}, {City: "y"}},
+	}
+
+	p, err := Diff(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, "patchtest.User", p.Message)
+
+	ops := make(map[Op]int)
+	for _, c := range p.Changes {
+		ops[c.Op]++
+	}
+	assert.Equal(t, map[Op]int{OpClear: 1, OpSet: 1, OpRemove: 1, OpInsert: 1, OpPut: 2, OpDelete: 1}, ops)
+	for _, c := range p.Changes {
+		if c.Op == OpSet {
+			assert.Equal(t, []int32{4, 1}, c.Path)
+		}
+	}
+
+	b := polyglot.NewBuffer()
+	p.Encode(b)
+	decoded := new(Patch)
+	require.NoError(t, decoded.Decode(b.Bytes()))
+	assert.Len(t, decoded.Changes, len(p.Changes))
+
+	require.NoError(t, Apply(from, decoded))
+	assert.Equal(t, to, from)
+
+	p, err = Diff(from, to)
+	require.NoError(t, err)
+	assert.True(t, p.Empty())
+}
+
+func TestDiffWhole(t *testing.T) {
+	t.Parallel()
+
+	from := &testUser{Tags: []string{"a"}, Labels: map[string]string{"k": "v"}}
+	to := &testUser{Address: &testAddress{City: "Ottawa"}, Tags: []string{"x", "y", "z"}, Friends: []*testAddress{{Zip: 2}}}
+
+	p, err := Diff(from, to)
+	require.NoError(t, err)
+	for _, c := range p.Changes {
+		assert.Contains(t, []Op{OpSet, OpClear}, c.Op)
+	}
+	require.NoError(t, Apply(from, p))
+	assert.Equal(t, to, from)
+
+	p, err = Diff(to, new(testUser))
+	require.NoError(t, err)
+	for _, c := range p.Changes {
+		assert.Equal(t, OpClear, c.Op)
+	}
+	require.NoError(t, Apply(to, p))
+	assert.Equal(t, &testUser{}, to)
+}
+
+func TestApplyNil(t *testing.T) {
+	t.Parallel()
+
+	u := &testUser{Tags: []string{"a"}}
+	assert.NoError(t, Apply(u, nil))
+	assert.NoError(t, Apply(u, &Patch{Message: "patchtest.User"}))
+	assert.Equal(t, &testUser{Tags: []string{"a"}}, u)
+}
+
+func TestApplyErrors(t *testing.T) {
+	t.Parallel()
+
+	u := &testUser{Tags: []string{"a"}}
+	_, err := Diff(u, new(testAddress))
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorIs(t, Apply(u, &Patch{Message: "patchtest.Address", Changes: []Change{{Op: OpClear, Path: []int32{1}}}}), ErrTypeMismatch)
+
+	for name, c := range map[string]Change{
+		"unknown field":   {Op: OpClear, Path: []int32{9}},
+		"remove past end": {Op: OpRemove, Path: []int32{3}, Index: 1},
+		"insert scalar":   {Op: OpInsert, Path: []int32{1}},
+		"put into slice":  {Op: OpPut, Path: []int32{3}},
+		"invalid value":   {Op: OpSet, Path: []int32{1}, Value: []byte{polyglot.StringRawKind}},
+		"path into slice": {Op: OpClear, Path: []int32{3, 1}},
+		"empty path":      {Op: OpClear},
+	} {
+		err := Apply(u, &Patch{Message: "patchtest.User", Changes: []Change{c}})
+		assert.Error(t, err, name)
+	}
+	assert.Equal(t, []string{"a"}, u.Tags)
+}
+
+func TestDecodeOversizedSlice(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	polyglot.Encoder(b).String("patchtest.User").Slice(1<<31, polyglot.AnyKind)
+	assert.ErrorIs(t, new(Patch).Decode(b.Bytes()), polyglot.ErrInvalidSlice)
+
+	b.Reset()
+	polyglot.Encoder(b).String("patchtest.User").Slice(1, polyglot.AnyKind).Uint8(uint8(OpClear)).Slice(1<<31, polyglot.Int32Kind)
+	assert.ErrorIs(t, new(Patch).Decode(b.Bytes()), polyglot.ErrInvalidSlice)
+}
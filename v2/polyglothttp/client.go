@@ -0,0 +1,134 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglothttp
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client calls endpoints served by Handler, or any endpoint that speaks the
+// same content types.
+type Client struct {
+	client      *http.Client
+	contentType string
+}
+
+// NewClient returns a client that sends and accepts contentType, which is
+// ContentTypePolyglot or ContentTypeJSON. If client is nil,
+// http.DefaultClient is used, and if contentType is empty, polyglot is used.
+func NewClient(client *http.Client, contentType string) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if contentType == "" {
+		contentType = ContentTypePolyglot
+	}
+	return &Client{
+		client:      client,
+		contentType: contentType,
+	}
+}
+
+// Do sends req, which may be nil for requests without a body, and decodes
+// the response into res, which may be nil to ignore the response body.
+// Responses with a non-2xx status are returned as a *StatusError.
+func (c *Client) Do(ctx context.Context, method string, url string, req Message, res Message) error {
+	var body io.Reader
+	if req != nil {
+		data, err := c.encode(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if req != nil {
+		httpReq.Header.Set("Content-Type", c.contentType)
+	}
+	httpReq.Header.Set("Accept", c.contentType)
+
+	httpRes, err := c.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpRes.Body, DefaultMaxBodySize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > DefaultMaxBodySize {
+		return ErrBodyTooLarge
+	}
+
+	contentType := supported(httpRes.Header.Get("Content-Type"))
+	if httpRes.StatusCode < 200 || httpRes.StatusCode > 299 {
+		return &StatusError{Status: httpRes.StatusCode, Err: decodeError(contentType, data)}
+	}
+	if res == nil {
+		return nil
+	}
+	switch contentType {
+	case ContentTypePolyglot:
+		return res.Decode(data)
+	case ContentTypeJSON:
+		return res.UnmarshalJSON(data)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedContentType, httpRes.Header.Get("Content-Type"))
+	}
+}
+
+func (c *Client) encode(m Message) ([]byte, error) {
+	if c.contentType == ContentTypeJSON {
+		return m.MarshalJSON()
+	}
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	m.Encode(b)
+	data := make([]byte, b.Len())
+	copy(data, b.Bytes())
+	return data, nil
+}
+
+// decodeError returns the error in the body of an error response, falling
+// back to the body as text.
+func decodeError(contentType string, data []byte) error {
+	switch contentType {
+	case ContentTypePolyglot:
+		if value, err := polyglot.Decoder(data).Error(); err == nil {
+			return value
+		}
+	case ContentTypeJSON:
+		var body errorBody
+		if err := json.Unmarshal(data, &body); err == nil && body.Error != "" {
+			return errors.New(body.Error)
+		}
+	}
+	return errors.New(strings.TrimSpace(string(data)))
+}
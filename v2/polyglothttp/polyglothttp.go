@@ -0,0 +1,256 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package polyglothttp serves and calls HTTP endpoints that exchange
+// generated messages, encoded as polyglot or as JSON depending on the
+// Content-Type and Accept headers of the request.
+package polyglothttp
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentTypePolyglot = "application/x-polyglot"
+	ContentTypeJSON     = "application/json"
+)
+
+// DefaultMaxBodySize is the size limit of request bodies used when a
+// handler is given a limit of zero.
+const DefaultMaxBodySize = 4 << 20
+
+var (
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	ErrNotAcceptable          = errors.New("no acceptable content type")
+	ErrBodyTooLarge           = errors.New("body is too large")
+)
+
+// Message is implemented by every generated message.
+type Message interface {
+	Encode(b *polyglot.Buffer)
+	Decode(b []byte) error
+	MarshalJSON() ([]byte, error)
+	UnmarshalJSON(b []byte) error
+}
+
+// StatusError is an error with the HTTP status it is written with. Handlers
+// return it to choose the status of an error response, and clients return
+// it for responses with a non-2xx status.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+// Error returns a StatusError for err with the given status.
+func Error(status int, err error) error {
+	return &StatusError{Status: status, Err: err}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d %s: %v", e.Status, http.StatusText(e.Status), e.Err)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// errorBody is the JSON form of an error response.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// supported returns the supported content type named by a Content-Type
+// header, or an empty string.
+func supported(header string) string {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case ContentTypePolyglot, ContentTypeJSON:
+		return mediaType
+	default:
+		return ""
+	}
+}
+
+// Negotiate returns the content type a response to r should be written
+// with. The Accept header decides between polyglot and JSON, and ties and
+// wildcards fall back to the content type of the request body, then to
+// JSON. It returns an empty string if r accepts neither.
+func Negotiate(r *http.Request) string {
+	fallback := supported(r.Header.Get("Content-Type"))
+	if fallback == "" {
+		fallback = ContentTypeJSON
+	}
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return fallback
+	}
+
+	quality := map[string]float64{}
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if value, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(value, 64); err != nil {
+					continue
+				}
+			}
+			switch mediaType {
+			case "*/*", "application/*":
+				for _, contentType := range []string{ContentTypePolyglot, ContentTypeJSON} {
+					if _, ok := quality[contentType]; !ok {
+						quality[contentType] = q
+					}
+				}
+			case ContentTypePolyglot, ContentTypeJSON:
+				quality[mediaType] = q
+			}
+		}
+	}
+
+	best, bestQuality := "", 0.0
+	for _, contentType := range []string{fallback, ContentTypePolyglot, ContentTypeJSON} {
+		if q := quality[contentType]; q > bestQuality {
+			best, bestQuality = contentType, q
+		}
+	}
+	return best
+}
+
+// ReadRequest decodes the body of r into m according to its Content-Type,
+// reading at most maxBodySize bytes.
+func ReadRequest(w http.ResponseWriter, r *http.Request, m Message, maxBodySize int64) error {
+	contentType := supported(r.Header.Get("Content-Type"))
+	if contentType == "" {
+		return fmt.Errorf("%w: %q", ErrUnsupportedContentType, r.Header.Get("Content-Type"))
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return ErrBodyTooLarge
+		}
+		return err
+	}
+	if contentType == ContentTypeJSON {
+		return m.UnmarshalJSON(body)
+	}
+	return m.Decode(body)
+}
+
+// WriteResponse writes m with the given status, encoded with the content
+// type negotiated for r.
+func WriteResponse(w http.ResponseWriter, r *http.Request, status int, m Message) error {
+	w.Header().Add("Vary", "Accept")
+	switch Negotiate(r) {
+	case ContentTypePolyglot:
+		b := polyglot.GetBuffer()
+		defer polyglot.PutBuffer(b)
+		m.Encode(b)
+		return write(w, status, ContentTypePolyglot, b.Bytes())
+	case ContentTypeJSON:
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		return write(w, status, ContentTypeJSON, data)
+	default:
+		http.Error(w, ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+}
+
+// WriteError writes err with the status of a StatusError, or 500. Polyglot
+// responses carry the error as the polyglot error kind, and JSON responses
+// as an object with an "error" field.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) error {
+	status := http.StatusInternalServerError
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		status, err = statusErr.Status, statusErr.Err
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if Negotiate(r) == ContentTypePolyglot {
+		b := polyglot.GetBuffer()
+		defer polyglot.PutBuffer(b)
+		polyglot.Encoder(b).Error(err)
+		return write(w, status, ContentTypePolyglot, b.Bytes())
+	}
+	data, err := json.Marshal(errorBody{Error: err.Error()})
+	if err != nil {
+		return err
+	}
+	return write(w, status, ContentTypeJSON, data)
+}
+
+func write(w http.ResponseWriter, status int, contentType string, data []byte) error {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	_, err := w.Write(data)
+	return err
+}
+
+// Handler returns an http.Handler that decodes each request body into a
+// new Req, calls fn and writes the message or error it returns. Bodies
+// larger than maxBodySize are rejected, and a limit of zero uses
+// DefaultMaxBodySize.
+func Handler[Req any, Res Message, PReq interface {
+	*Req
+	Message
+}](maxBodySize int64, fn func(ctx context.Context, req PReq) (Res, error)) http.Handler {
+	if maxBodySize == 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := PReq(new(Req))
+		if err := ReadRequest(w, r, req, maxBodySize); err != nil {
+			switch {
+			case errors.Is(err, ErrUnsupportedContentType):
+				err = Error(http.StatusUnsupportedMediaType, err)
+			case errors.Is(err, ErrBodyTooLarge):
+				err = Error(http.StatusRequestEntityTooLarge, err)
+			default:
+				err = Error(http.StatusBadRequest, err)
+			}
+			_ = WriteError(w, r, err)
+			return
+		}
+		res, err := fn(r.Context(), req)
+		if err != nil {
+			_ = WriteError(w, r, err)
+			return
+		}
+		_ = WriteResponse(w, r, http.StatusOK, res)
+	})
+}
@@ -0,0 +1,148 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglothttp
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testMessage struct {
+	Value string `json:"value"`
+}
+
+func (x *testMessage) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(x.Value)
+}
+
+func (x *testMessage) Decode(b []byte) (err error) {
+	x.Value, err = polyglot.Decoder(b).String()
+	return
+}
+
+func (x *testMessage) MarshalJSON() ([]byte, error) {
+	type plain testMessage
+	return json.Marshal((*plain)(x))
+}
+
+func (x *testMessage) UnmarshalJSON(b []byte) error {
+	type plain testMessage
+	return json.Unmarshal(b, (*plain)(x))
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		contentType string
+		accept      string
+		expected    string
+	}{
+		{"", "", ContentTypeJSON},
+		{ContentTypePolyglot, "", ContentTypePolyglot},
+		{ContentTypeJSON, ContentTypePolyglot, ContentTypePolyglot},
+		{ContentTypePolyglot, "*/*", ContentTypePolyglot},
+		{"", "application/*", ContentTypeJSON},
+		{"", "application/json;q=0.5, application/x-polyglot", ContentTypePolyglot},
+		{ContentTypePolyglot, "application/x-polyglot;q=0, */*", ContentTypeJSON},
+		{"", "text/html", ""},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		assert.Equal(t, test.expected, Negotiate(r), "Content-Type %q, Accept %q", test.contentType, test.accept)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := Handler(64, func(_ context.Context, req *testMessage) (*testMessage, error) {
+		switch req.Value {
+		case "":
+			return nil, Error(http.StatusUnprocessableEntity, errors.New("value is required"))
+		case "fail":
+			return nil, errors.New("failed")
+		}
+		return &testMessage{Value: strings.ToUpper(req.Value)}, nil
+	})
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	ctx := context.Background()
+
+	for _, contentType := range []string{ContentTypePolyglot, ContentTypeJSON} {
+		client := NewClient(ts.Client(), contentType)
+
+		res := new(testMessage)
+		require.NoError(t, client.Do(ctx, http.MethodPost, ts.URL, &testMessage{Value: "polyglot"}, res))
+		assert.Equal(t, "POLYGLOT", res.Value)
+
+		var statusErr *StatusError
+		err := client.Do(ctx, http.MethodPost, ts.URL, &testMessage{}, res)
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, statusErr.Status)
+		assert.EqualError(t, statusErr.Err, "value is required")
+
+		err = client.Do(ctx, http.MethodPost, ts.URL, &testMessage{Value: "fail"}, res)
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusInternalServerError, statusErr.Status)
+		assert.EqualError(t, statusErr.Err, "failed")
+
+		err = client.Do(ctx, http.MethodPost, ts.URL, &testMessage{Value: strings.Repeat("x", 128)}, res)
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, statusErr.Status)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("polyglot"))
+	r.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.JSONEq(t, `{"error":"unsupported content type: \"text/plain\""}`, w.Body.String())
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"value":"json"}`))
+	r.Header.Set("Content-Type", ContentTypeJSON)
+	r.Header.Set("Accept", ContentTypePolyglot)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ContentTypePolyglot, w.Header().Get("Content-Type"))
+	res := new(testMessage)
+	require.NoError(t, res.Decode(w.Body.Bytes()))
+	assert.Equal(t, "JSON", res.Value)
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"value":"json"}`))
+	r.Header.Set("Content-Type", ContentTypeJSON)
+	r.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
@@ -0,0 +1,156 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package recordlog
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"iter"
+	"os"
+	"sort"
+)
+
+type segment struct {
+	base    uint64
+	data    []byte
+	offsets []int64
+}
+
+// Reader reads the records of a log through memory mapped segments. The
+// records it returns share the memory of the mappings and are only valid
+// until the Reader is closed.
+type Reader struct {
+	segments []*segment
+	first    uint64
+	next     uint64
+}
+
+// OpenReader maps the segments of the log in dir. Records appended after
+// the Reader is opened are not visible to it, and torn records at the end
+// of unsealed segments are ignored.
+func OpenReader(dir string) (*Reader, error) {
+	bases, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	r := new(Reader)
+	for i, base := range bases {
+		if i > 0 && base != r.next {
+			_ = r.Close()
+			return nil, ErrCorrupt
+		}
+		name := segmentName(dir, base)
+		if i == len(bases)-1 {
+			// The log may have crashed while creating its last segment.
+			if info, err := os.Stat(name); err == nil && info.Size() < headerSize {
+				break
+			}
+		}
+		s, err := openSegment(name, base)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		if i == 0 {
+			r.first = base
+		}
+		r.segments = append(r.segments, s)
+		r.next = base + uint64(len(s.offsets))
+	}
+	return r, nil
+}
+
+func openSegment(name string, base uint64) (*segment, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := mmap(f)
+	if err != nil {
+		return nil, err
+	}
+	s := &segment{base: base, data: data}
+	if b, err := parseHeader(data); err != nil || b != base {
+		_ = munmap(data)
+		return nil, ErrInvalidSegment
+	}
+	var ok bool
+	if s.offsets, ok = footer(data); !ok {
+		s.offsets, _ = scan(data, int(^uint32(0)>>1))
+	}
+	return s, nil
+}
+
+// First returns the sequence number of the first record.
+func (r *Reader) First() uint64 {
+	return r.first
+}
+
+// Next returns the sequence number after the last record.
+func (r *Reader) Next() uint64 {
+	return r.next
+}
+
+// Read returns the record with the given sequence number after checking its
+// checksum.
+func (r *Reader) Read(seq uint64) ([]byte, error) {
+	if seq < r.first || seq >= r.next {
+		return nil, ErrNotFound
+	}
+	i := sort.Search(len(r.segments), func(i int) bool {
+		return r.segments[i].base > seq
+	}) - 1
+	s := r.segments[i]
+	return record(s.data, s.offsets[seq-s.base])
+}
+
+// Decoder returns a decoder reading the record with the given sequence
+// number in place.
+func (r *Reader) Decoder(seq uint64) (*polyglot.BufferDecoder, error) {
+	data, err := r.Read(seq)
+	if err != nil {
+		return nil, err
+	}
+	return polyglot.Decoder(data), nil
+}
+
+// All returns the records in order, stopping after the first error.
+func (r *Reader) All() iter.Seq2[uint64, []byte] {
+	return func(yield func(uint64, []byte) bool) {
+		for _, s := range r.segments {
+			for i, offset := range s.offsets {
+				data, err := record(s.data, offset)
+				if err != nil || !yield(s.base+uint64(i), data) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close unmaps the segments of the Reader.
+func (r *Reader) Close() error {
+	var err error
+	for _, s := range r.segments {
+		if unmapErr := munmap(s.data); err == nil {
+			err = unmapErr
+		}
+	}
+	r.segments = nil
+	return err
+}
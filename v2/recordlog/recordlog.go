@@ -0,0 +1,300 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package recordlog persists sequences of encoded polyglot messages in an
+// append-only log of segment files.
+//
+// Records are numbered from zero in the order they are appended. Each is
+// stored with its length and a CRC-32C checksum, and segments are sealed
+// with a footer indexing their records once they reach their maximum size
+// or the log is closed. Opening a log truncates records torn by a crash from
+// the end of its segments, and Reader maps segments into memory to return
+// records without copying them.
+package recordlog
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	ErrClosed         = errors.New("record log is closed")
+	ErrCorrupt        = errors.New("record log is corrupt")
+	ErrInvalidSegment = errors.New("invalid segment header")
+	ErrNotFound       = errors.New("record not found")
+	ErrRecordTooLarge = errors.New("record is too large")
+)
+
+const (
+	DefaultMaxSegmentSize = 64 << 20
+	DefaultMaxRecordSize  = 16 << 20
+)
+
+// Options configures a Log. The zero value uses the defaults.
+type Options struct {
+	// MaxSegmentSize is the size past which a new segment is started.
+	MaxSegmentSize int64
+
+	// MaxRecordSize is the size of the largest record that can be appended.
+	MaxRecordSize int
+}
+
+func (o *Options) defaults() Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.MaxSegmentSize <= 0 {
+		opts.MaxSegmentSize = DefaultMaxSegmentSize
+	}
+	if opts.MaxRecordSize <= 0 {
+		opts.MaxRecordSize = DefaultMaxRecordSize
+	}
+	return opts
+}
+
+// Encoder is implemented by generated messages.
+type Encoder interface {
+	Encode(b *polyglot.Buffer)
+}
+
+// Log appends records to the segments in a directory. It is safe for
+// concurrent use.
+type Log struct {
+	dir  string
+	opts Options
+
+	mu      sync.Mutex
+	next    uint64
+	active  *os.File
+	base    uint64
+	size    int64
+	offsets []int64
+	frame   []byte
+	closed  bool
+}
+
+// Open opens the log in dir, creating the directory if needed. Records torn
+// by a crash are truncated, and segments left without a footer are sealed,
+// except for the last one, which new records are appended to.
+func Open(dir string, opts *Options) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	l := &Log{
+		dir:  dir,
+		opts: opts.defaults(),
+	}
+	bases, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, base := range bases {
+		if base != l.next && i > 0 {
+			return nil, ErrCorrupt
+		}
+		last := i == len(bases)-1
+		count, err := l.recover(base, last)
+		if err != nil {
+			return nil, err
+		}
+		l.next = base + count
+	}
+	return l, nil
+}
+
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	return segmentBases(names), nil
+}
+
+// recover checks the segment starting at base and returns its number of
+// records. Unsealed segments are truncated after their last valid record,
+// and sealed unless they are the last, active, segment.
+func (l *Log) recover(base uint64, last bool) (uint64, error) {
+	name := segmentName(l.dir, base)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	if last && len(data) < headerSize {
+		// The log crashed while creating the segment.
+		return 0, os.Remove(name)
+	}
+	if b, err := parseHeader(data); err != nil || b != base {
+		return 0, ErrInvalidSegment
+	}
+	if offsets, ok := footer(data); ok {
+		if last && len(offsets) == 0 {
+			return 0, os.Remove(name)
+		}
+		return uint64(len(offsets)), nil
+	}
+
+	offsets, end := scan(data, l.opts.MaxRecordSize)
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err = f.Truncate(end); err == nil {
+		_, err = f.Seek(end, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		return 0, err
+	}
+	if last {
+		l.active, l.base, l.size, l.offsets = f, base, end, offsets
+		return uint64(len(offsets)), nil
+	}
+	if err = seal(f, end, offsets); err != nil {
+		return 0, err
+	}
+	return uint64(len(offsets)), nil
+}
+
+// seal writes the footer of a segment, syncs and closes it.
+func seal(f *os.File, size int64, offsets []int64) error {
+	_, err := f.Write(appendFooter(nil, size, offsets))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Append appends a record and returns its sequence number.
+func (l *Log) Append(record []byte) (uint64, error) {
+	if len(record) > l.opts.MaxRecordSize {
+		return 0, ErrRecordTooLarge
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+
+	frame := int64(frameSize + len(record))
+	if l.active != nil && len(l.offsets) > 0 && l.size+frame > l.opts.MaxSegmentSize {
+		err := seal(l.active, l.size, l.offsets)
+		l.active = nil
+		if err != nil {
+			return 0, err
+		}
+	}
+	if l.active == nil {
+		if err := l.create(); err != nil {
+			return 0, err
+		}
+	}
+
+	l.frame = appendFrame(l.frame[:0], record)
+	if _, err := l.active.Write(l.frame); err != nil {
+		// Drop whatever part of the record was written, so the segment
+		// stays valid.
+		if truncateErr := l.active.Truncate(l.size); truncateErr == nil {
+			_, _ = l.active.Seek(l.size, io.SeekStart)
+		}
+		return 0, err
+	}
+	l.offsets = append(l.offsets, l.size)
+	l.size += frame
+	seq := l.next
+	l.next++
+	return seq, nil
+}
+
+// AppendMessage appends the encoding of m and returns its sequence number.
+func (l *Log) AppendMessage(m Encoder) (uint64, error) {
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	m.Encode(b)
+	return l.Append(b.Bytes())
+}
+
+func (l *Log) create() error {
+	f, err := os.OpenFile(segmentName(l.dir, l.next), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(appendHeader(nil, l.next)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.active, l.base, l.size, l.offsets = f, l.next, headerSize, nil
+	return nil
+}
+
+// Next returns the sequence number of the next record.
+func (l *Log) Next() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next
+}
+
+// Sync commits the records appended so far to stable storage.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	if l.active == nil {
+		return nil
+	}
+	return l.active.Sync()
+}
+
+// Close seals the active segment and closes the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	l.closed = true
+	if l.active == nil {
+		return nil
+	}
+	if len(l.offsets) == 0 {
+		// Empty segments are removed so their name is free for the next
+		// segment.
+		err := l.active.Close()
+		if removeErr := os.Remove(l.active.Name()); err == nil {
+			err = removeErr
+		}
+		l.active = nil
+		return err
+	}
+	err := seal(l.active, l.size, l.offsets)
+	l.active = nil
+	return err
+}
@@ -0,0 +1,205 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package recordlog
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testMessage struct {
+	Name string
+	Seq  uint64
+}
+
+func (m *testMessage) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(m.Name).Uint64(m.Seq)
+}
+
+func (m *testMessage) decode(d *polyglot.BufferDecoder) (err error) {
+	if m.Name, err = d.String(); err != nil {
+		return err
+	}
+	m.Seq, err = d.Uint64()
+	return err
+}
+
+func testRecord(i int) []byte {
+	return []byte(fmt.Sprintf("record-%04d", i))
+}
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l, err := Open(dir, &Options{MaxSegmentSize: 256})
+	require.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		seq, err := l.AppendMessage(&testMessage{Name: "message", Seq: uint64(i)})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i), seq)
+	}
+	require.NoError(t, l.Close())
+	assert.ErrorIs(t, l.Close(), ErrClosed)
+	_, err = l.Append(nil)
+	assert.ErrorIs(t, err, ErrClosed)
+
+	segments, err := listSegments(dir)
+	require.NoError(t, err)
+	assert.Greater(t, len(segments), 3)
+
+	l, err = Open(dir, &Options{MaxSegmentSize: 256})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(50), l.Next())
+	seq, err := l.AppendMessage(&testMessage{Name: "message", Seq: 50})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(50), seq)
+	require.NoError(t, l.Sync())
+
+	// Readers see the records of the active segment as well.
+	r, err := OpenReader(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r.First())
+	assert.Equal(t, uint64(51), r.Next())
+	for _, i := range []uint64{0, 17, 49, 50, 33} {
+		d, err := r.Decoder(i)
+		require.NoError(t, err)
+		m := new(testMessage)
+		require.NoError(t, m.decode(d))
+		assert.Equal(t, &testMessage{Name: "message", Seq: i}, m)
+	}
+	_, err = r.Read(51)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	next := uint64(0)
+	for seq, data := range r.All() {
+		assert.Equal(t, next, seq)
+		m := new(testMessage)
+		require.NoError(t, m.decode(polyglot.Decoder(data)))
+		assert.Equal(t, seq, m.Seq)
+		next++
+	}
+	assert.Equal(t, uint64(51), next)
+	require.NoError(t, r.Close())
+	require.NoError(t, l.Close())
+}
+
+func TestRecovery(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l, err := Open(dir, nil)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err = l.Append(testRecord(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, l.Sync())
+	name := l.active.Name()
+	size := l.size
+
+	// Crash without sealing the segment, leaving part of a record behind.
+	require.NoError(t, l.active.Close())
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.Write(appendFrame(nil, testRecord(10))[:10])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	r, err := OpenReader(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), r.Next())
+	require.NoError(t, r.Close())
+
+	l, err = Open(dir, nil)
+	require.NoError(t, err)
+	info, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, size, info.Size())
+	assert.Equal(t, uint64(10), l.Next())
+	seq, err := l.Append(testRecord(10))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), seq)
+	require.NoError(t, l.Close())
+
+	r, err = OpenReader(dir)
+	require.NoError(t, err)
+	for i := 0; i <= 10; i++ {
+		data, err := r.Read(uint64(i))
+		require.NoError(t, err)
+		assert.Equal(t, testRecord(i), data)
+	}
+	require.NoError(t, r.Close())
+
+	// A segment torn while it was created is dropped.
+	require.NoError(t, os.WriteFile(segmentName(dir, 11), []byte("PGR"), 0o644))
+	r, err = OpenReader(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), r.Next())
+	require.NoError(t, r.Close())
+	l, err = Open(dir, nil)
+	require.NoError(t, err)
+	seq, err = l.Append(testRecord(11))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), seq)
+	require.NoError(t, l.Close())
+}
+
+func TestCorruption(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l, err := Open(dir, &Options{MaxRecordSize: 16})
+	require.NoError(t, err)
+	_, err = l.Append(make([]byte, 17))
+	assert.ErrorIs(t, err, ErrRecordTooLarge)
+	for i := 0; i < 3; i++ {
+		_, err = l.Append(testRecord(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, l.Close())
+
+	// Flip a byte in the data of the second record.
+	name := segmentName(dir, 0)
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	data[headerSize+frameSize+len(testRecord(0))+frameSize+2] ^= 0xff
+	require.NoError(t, os.WriteFile(name, data, 0o644))
+
+	r, err := OpenReader(dir)
+	require.NoError(t, err)
+	_, err = r.Read(0)
+	require.NoError(t, err)
+	_, err = r.Read(1)
+	assert.ErrorIs(t, err, ErrCorrupt)
+	count := 0
+	for range r.All() {
+		count++
+	}
+	assert.Equal(t, 1, count)
+	require.NoError(t, r.Close())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000003.seg"), []byte("not a segment header"), 0o644))
+	_, err = Open(dir, nil)
+	assert.ErrorIs(t, err, ErrInvalidSegment)
+}
@@ -0,0 +1,176 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package recordlog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A segment file starts with a header holding the magic bytes, the format
+// version and the sequence number of its first record. Each record follows
+// as its length and CRC-32C checksum, both big endian uint32 values, and its
+// data. A sealed segment ends with a footer holding the footer marker, the
+// checksum of the rest of the footer, the number of records, the offset of
+// each record and a trailer holding the offset of the footer and the index
+// magic bytes.
+const (
+	headerSize  = 16
+	frameSize   = 8
+	trailerSize = 12
+	version     = 1
+
+	footerMarker = ^uint32(0)
+	extension    = ".seg"
+)
+
+var (
+	segmentMagic = [4]byte{'P', 'G', 'R', 'L'}
+	indexMagic   = [4]byte{'P', 'G', 'I', 'X'}
+	castagnoli   = crc32.MakeTable(crc32.Castagnoli)
+)
+
+func segmentName(dir string, base uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", base, extension))
+}
+
+// segmentBases returns the sorted sequence numbers of the segments in dir,
+// taken from their names.
+func segmentBases(names []string) []uint64 {
+	var bases []uint64
+	for _, name := range names {
+		if !strings.HasSuffix(name, extension) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, extension), 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	return bases
+}
+
+func appendHeader(b []byte, base uint64) []byte {
+	b = append(b, segmentMagic[:]...)
+	b = append(b, version, 0, 0, 0)
+	return binary.BigEndian.AppendUint64(b, base)
+}
+
+func parseHeader(data []byte) (uint64, error) {
+	if len(data) < headerSize || [4]byte(data[:4]) != segmentMagic || data[4] != version {
+		return 0, ErrInvalidSegment
+	}
+	return binary.BigEndian.Uint64(data[8:headerSize]), nil
+}
+
+func appendFrame(b []byte, record []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(record)))
+	b = binary.BigEndian.AppendUint32(b, crc32.Checksum(record, castagnoli))
+	return append(b, record...)
+}
+
+// record returns the data of the record at offset, checking its checksum.
+func record(data []byte, offset int64) ([]byte, error) {
+	if offset < headerSize || int64(len(data))-offset < frameSize {
+		return nil, ErrCorrupt
+	}
+	frame := data[offset:]
+	size := binary.BigEndian.Uint32(frame)
+	if size == footerMarker || uint64(len(frame)-frameSize) < uint64(size) {
+		return nil, ErrCorrupt
+	}
+	end := frameSize + int(size)
+	if crc32.Checksum(frame[frameSize:end], castagnoli) != binary.BigEndian.Uint32(frame[4:]) {
+		return nil, ErrCorrupt
+	}
+	return frame[frameSize:end:end], nil
+}
+
+// scan returns the offsets of the valid records of a segment without a
+// footer, and the offset where the first invalid or torn record starts.
+func scan(data []byte, maxRecordSize int) ([]int64, int64) {
+	var offsets []int64
+	offset := int64(headerSize)
+	for int64(len(data))-offset >= frameSize {
+		size := binary.BigEndian.Uint32(data[offset:])
+		if size == footerMarker || uint64(size) > uint64(maxRecordSize) {
+			break
+		}
+		if _, err := record(data, offset); err != nil {
+			break
+		}
+		offsets = append(offsets, offset)
+		offset += frameSize + int64(size)
+	}
+	return offsets, offset
+}
+
+func appendFooter(b []byte, offset int64, offsets []int64) []byte {
+	b = binary.BigEndian.AppendUint32(b, footerMarker)
+	start := len(b)
+	b = append(b, 0, 0, 0, 0)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(offsets)))
+	for _, o := range offsets {
+		b = binary.BigEndian.AppendUint64(b, uint64(o))
+	}
+	binary.BigEndian.PutUint32(b[start:], crc32.Checksum(b[start+4:], castagnoli))
+	b = binary.BigEndian.AppendUint64(b, uint64(offset))
+	return append(b, indexMagic[:]...)
+}
+
+// footer returns the record offsets stored in the footer of a sealed
+// segment, and false if the segment has no valid footer.
+func footer(data []byte) ([]int64, bool) {
+	if len(data) < headerSize+trailerSize+12 {
+		return nil, false
+	}
+	trailer := data[len(data)-trailerSize:]
+	if [4]byte(trailer[8:]) != indexMagic {
+		return nil, false
+	}
+	offset := binary.BigEndian.Uint64(trailer)
+	if offset < headerSize || offset > uint64(len(data)-trailerSize-12) {
+		return nil, false
+	}
+	index := data[offset : len(data)-trailerSize]
+	if binary.BigEndian.Uint32(index) != footerMarker {
+		return nil, false
+	}
+	count := binary.BigEndian.Uint32(index[8:])
+	if uint64(len(index)-12) != uint64(count)*8 {
+		return nil, false
+	}
+	if crc32.Checksum(index[8:], castagnoli) != binary.BigEndian.Uint32(index[4:]) {
+		return nil, false
+	}
+	offsets := make([]int64, count)
+	for i := range offsets {
+		o := binary.BigEndian.Uint64(index[12+8*i:])
+		if o < headerSize || o >= offset {
+			return nil, false
+		}
+		offsets[i] = int64(o)
+	}
+	return offsets, true
+}
@@ -0,0 +1,531 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package reflection encodes Go values that have no generated code, such as
+// plain structs, with polyglot using reflection.
+//
+// Booleans, integers, floats, strings and byte slices are written as the
+// polyglot value of the same kind, with int8 and int16 widened to int32 and
+// int, uint and uintptr to 64 bits. Byte arrays are written as bytes, and
+// other arrays and slices as slices. Structs are written like messages, as
+// their exported fields in declaration order, and pointers as nil or the
+// value they point to.
+//
+// Map keys can be of any comparable type other than pointers, interfaces and
+// channels, including structs, arrays and named types. Struct keys must only
+// have exported fields, so that distinct keys have distinct encodings. Keys
+// are encoded like any other value, and entries are written in the order of
+// the bytes of their keys, so equal maps always have the same encoding.
+package reflection
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrInvalidKey      = errors.New("unsupported map key type")
+	ErrDecodeNil       = errors.New("cannot decode into a nil pointer")
+	ErrOverflow        = errors.New("value overflows its type")
+	ErrInvalidLength   = errors.New("invalid array length")
+)
+
+var (
+	codecs sync.Map
+)
+
+// codec encodes and decodes the values of a single type. Decoding sets an
+// addressable value.
+type codec struct {
+	kind   polyglot.Kind
+	encode func(b *polyglot.Buffer, v reflect.Value)
+	decode func(d *polyglot.BufferDecoder, v reflect.Value) error
+}
+
+// Kind returns the polyglot kind values of type t are encoded as, which is
+// the kind of the elements of slices and of the keys and values of maps.
+func Kind(t reflect.Type) (polyglot.Kind, error) {
+	c, err := codecOf(t)
+	if err != nil {
+		return 0, err
+	}
+	return c.kind, nil
+}
+
+// Encode writes value to b. Nothing is written if the type of value is not
+// supported.
+func Encode(b *polyglot.Buffer, value interface{}) error {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		polyglot.Encoder(b).Nil()
+		return nil
+	}
+	c, err := codecOf(v.Type())
+	if err != nil {
+		return err
+	}
+	c.encode(b, v)
+	return nil
+}
+
+// Decode reads a value written by Encode into the value value points to.
+func Decode(b []byte, value interface{}) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ErrDecodeNil
+	}
+	c, err := codecOf(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return c.decode(polyglot.Decoder(b), v.Elem())
+}
+
+// codecOf returns the cached codec of t, building it and the codecs of the
+// types it contains if needed.
+func codecOf(t reflect.Type) (*codec, error) {
+	if c, ok := codecs.Load(t); ok {
+		return c.(*codec), nil
+	}
+	building := make(map[reflect.Type]*codec)
+	c, err := build(t, building)
+	if err != nil {
+		return nil, err
+	}
+	for t, c := range building {
+		codecs.LoadOrStore(t, c)
+	}
+	return c, nil
+}
+
+// build returns the codec of t. Codecs are added to building before the
+// codecs of the types they contain are built, so recursive types refer to
+// the codec being built.
+func build(t reflect.Type, building map[reflect.Type]*codec) (*codec, error) {
+	if c, ok := codecs.Load(t); ok {
+		return c.(*codec), nil
+	}
+	if c, ok := building[t]; ok {
+		return c, nil
+	}
+	c := new(codec)
+	building[t] = c
+
+	switch t.Kind() {
+	case reflect.Bool:
+		c.kind = polyglot.BoolKind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Bool(v.Bool())
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Bool()
+			v.SetBool(value)
+			return err
+		}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		c.kind = polyglot.Int32Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Int32(int32(v.Int()))
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Int32()
+			if err != nil {
+				return err
+			}
+			return setInt(v, int64(value))
+		}
+	case reflect.Int, reflect.Int64:
+		c.kind = polyglot.Int64Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Int64(v.Int())
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Int64()
+			if err != nil {
+				return err
+			}
+			return setInt(v, value)
+		}
+	case reflect.Uint8:
+		c.kind = polyglot.Uint8Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Uint8(uint8(v.Uint()))
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Uint8()
+			v.SetUint(uint64(value))
+			return err
+		}
+	case reflect.Uint16:
+		c.kind = polyglot.Uint16Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Uint16(uint16(v.Uint()))
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Uint16()
+			v.SetUint(uint64(value))
+			return err
+		}
+	case reflect.Uint32:
+		c.kind = polyglot.Uint32Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Uint32(uint32(v.Uint()))
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Uint32()
+			v.SetUint(uint64(value))
+			return err
+		}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		c.kind = polyglot.Uint64Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Uint64(v.Uint())
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Uint64()
+			if err != nil {
+				return err
+			}
+			if v.OverflowUint(value) {
+				return fmt.Errorf("%w: %d does not fit in %s", ErrOverflow, value, v.Type())
+			}
+			v.SetUint(value)
+			return nil
+		}
+	case reflect.Float32:
+		c.kind = polyglot.Float32Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Float32(float32(v.Float()))
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Float32()
+			v.SetFloat(float64(value))
+			return err
+		}
+	case reflect.Float64:
+		c.kind = polyglot.Float64Kind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).Float64(v.Float())
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.Float64()
+			v.SetFloat(value)
+			return err
+		}
+	case reflect.String:
+		c.kind = polyglot.StringKind
+		c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+			polyglot.Encoder(b).String(v.String())
+		}
+		c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+			value, err := d.String()
+			v.SetString(value)
+			return err
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			buildBytes(c)
+			break
+		}
+		if err := buildSlice(c, t, building); err != nil {
+			return nil, err
+		}
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			buildByteArray(c, t)
+			break
+		}
+		if err := buildArray(c, t, building); err != nil {
+			return nil, err
+		}
+	case reflect.Map:
+		if err := buildMap(c, t, building); err != nil {
+			return nil, err
+		}
+	case reflect.Struct:
+		if err := buildStruct(c, t, building); err != nil {
+			return nil, err
+		}
+	case reflect.Pointer:
+		if err := buildPointer(c, t, building); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+	return c, nil
+}
+
+func setInt(v reflect.Value, value int64) error {
+	if v.OverflowInt(value) {
+		return fmt.Errorf("%w: %d does not fit in %s", ErrOverflow, value, v.Type())
+	}
+	v.SetInt(value)
+	return nil
+}
+
+func buildBytes(c *codec) {
+	c.kind = polyglot.BytesKind
+	c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+		polyglot.Encoder(b).Bytes(v.Bytes())
+	}
+	c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+		value, err := d.Bytes(nil)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(value)
+		return nil
+	}
+}
+
+func buildByteArray(c *codec, t reflect.Type) {
+	c.kind = polyglot.BytesKind
+	c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+		value := make([]byte, v.Len())
+		for i := range value {
+			value[i] = byte(v.Index(i).Uint())
+		}
+		polyglot.Encoder(b).Bytes(value)
+	}
+	c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+		value, err := d.Bytes(nil)
+		if err != nil {
+			return err
+		}
+		if len(value) != t.Len() {
+			return fmt.Errorf("%w: %d bytes for %s", ErrInvalidLength, len(value), t)
+		}
+		for i, e := range value {
+			v.Index(i).SetUint(uint64(e))
+		}
+		return nil
+	}
+}
+
+func buildSlice(c *codec, t reflect.Type, building map[reflect.Type]*codec) error {
+	c.kind = polyglot.SliceKind
+	elem, err := build(t.Elem(), building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+		polyglot.Encoder(b).Slice(uint32(v.Len()), elem.kind)
+		for i := 0; i < v.Len(); i++ {
+			elem.encode(b, v.Index(i))
+		}
+	}
+	c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+		size, err := d.Slice(elem.kind)
+		if err != nil {
+			return err
+		}
+		if v.Len() != int(size) {
+			v.Set(reflect.MakeSlice(t, int(size), int(size)))
+		}
+		for i := 0; i < int(size); i++ {
+			if err = elem.decode(d, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func buildArray(c *codec, t reflect.Type, building map[reflect.Type]*codec) error {
+	c.kind = polyglot.SliceKind
+	elem, err := build(t.Elem(), building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+		polyglot.Encoder(b).Slice(uint32(v.Len()), elem.kind)
+		for i := 0; i < v.Len(); i++ {
+			elem.encode(b, v.Index(i))
+		}
+	}
+	c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+		size, err := d.Slice(elem.kind)
+		if err != nil {
+			return err
+		}
+		if int(size) != t.Len() {
+			return fmt.Errorf("%w: %d elements for %s", ErrInvalidLength, size, t)
+		}
+		for i := 0; i < t.Len(); i++ {
+			if err = elem.decode(d, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// keyable reports whether values of t can be map keys with distinct
+// encodings for distinct keys.
+func keyable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Map, reflect.Slice:
+		return false
+	case reflect.Array:
+		return keyable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); !field.IsExported() || !keyable(field.Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// entry is a map key along with the position of its encoding in a scratch
+// buffer.
+type entry struct {
+	key        reflect.Value
+	start, end int
+}
+
+func buildMap(c *codec, t reflect.Type, building map[reflect.Type]*codec) error {
+	c.kind = polyglot.MapKind
+	if !keyable(t.Key()) {
+		return fmt.Errorf("%w: %s", ErrInvalidKey, t.Key())
+	}
+	key, err := build(t.Key(), building)
+	if err != nil {
+		return err
+	}
+	value, err := build(t.Elem(), building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+		polyglot.Encoder(b).Map(uint32(v.Len()), key.kind, value.kind)
+		if v.Len() == 0 {
+			return
+		}
+
+		keys := polyglot.GetBuffer()
+		defer polyglot.PutBuffer(keys)
+		entries := make([]entry, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			start := keys.Len()
+			key.encode(keys, iter.Key())
+			entries = append(entries, entry{key: iter.Key(), start: start, end: keys.Len()})
+		}
+		encoded := keys.Bytes()
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(encoded[entries[i].start:entries[i].end], encoded[entries[j].start:entries[j].end]) < 0
+		})
+		for _, e := range entries {
+			b.Write(encoded[e.start:e.end])
+			value.encode(b, v.MapIndex(e.key))
+		}
+	}
+	c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+		size, err := d.Map(key.kind, value.kind)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(t, int(size))
+		for i := uint32(0); i < size; i++ {
+			k := reflect.New(t.Key()).Elem()
+			if err = key.decode(d, k); err != nil {
+				return err
+			}
+			e := reflect.New(t.Elem()).Elem()
+			if err = value.decode(d, e); err != nil {
+				return err
+			}
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+		return nil
+	}
+	return nil
+}
+
+func buildStruct(c *codec, t reflect.Type, building map[reflect.Type]*codec) error {
+	c.kind = polyglot.AnyKind
+	var indexes []int
+	var fields []*codec
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		f, err := build(field.Type, building)
+		if err != nil {
+			return fmt.Errorf("field %s of %s: %w", field.Name, t, err)
+		}
+		indexes = append(indexes, i)
+		fields = append(fields, f)
+	}
+	c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+		for i, f := range fields {
+			f.encode(b, v.Field(indexes[i]))
+		}
+	}
+	c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+		for i, f := range fields {
+			if err := f.decode(d, v.Field(indexes[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func buildPointer(c *codec, t reflect.Type, building map[reflect.Type]*codec) error {
+	elem, err := build(t.Elem(), building)
+	if err != nil {
+		return err
+	}
+	// The kind of a recursive type is set before the types it contains are
+	// built, so it is known here even if elem is still being built.
+	c.kind = elem.kind
+	c.encode = func(b *polyglot.Buffer, v reflect.Value) {
+		if v.IsNil() {
+			polyglot.Encoder(b).Nil()
+			return
+		}
+		elem.encode(b, v.Elem())
+	}
+	c.decode = func(d *polyglot.BufferDecoder, v reflect.Value) error {
+		if d.Nil() {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return elem.decode(d, v.Elem())
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package reflection
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"reflect"
+	"testing"
+)
+
+type level uint32
+
+type point struct {
+	X, Y int32
+}
+
+type version [3]uint16
+
+type id [4]byte
+
+type node struct {
+	Name     string
+	Level    level
+	Next     *node
+	Children []*node
+	internal int
+}
+
+type record struct {
+	Flag    bool
+	Small   int8
+	Int     int
+	Byte    uint8
+	Short   uint16
+	Uint    uint
+	F32     float32
+	F64     float64
+	Data    []byte
+	ID      id
+	Tags    []string
+	Version version
+	Points  map[point]string
+	Levels  map[level][]float64
+	IDs     map[id]bool
+	Nested  map[version]map[string]int64
+	Root    *node
+	Empty   *point
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := record{
+		Flag:    true,
+		Small:   -8,
+		Int:     -1 << 40,
+		Byte:    255,
+		Short:   65535,
+		Uint:    1 << 50,
+		F32:     1.5,
+		F64:     -2.25,
+		Data:    []byte("data"),
+		ID:      id{1, 2, 3, 4},
+		Tags:    []string{"a", "b"},
+		Version: version{1, 2, 3},
+		Points:  map[point]string{{1, 2}: "a", {-1, 0}: "b"},
+		Levels:  map[level][]float64{3: {1, 2}, 1: nil},
+		IDs:     map[id]bool{{9}: true},
+		Nested:  map[version]map[string]int64{{1}: {"x": 1}},
+		Root: &node{
+			Name:     "root",
+			Level:    2,
+			Next:     &node{Name: "next"},
+			Children: []*node{{Name: "child"}, nil},
+			internal: 1,
+		},
+	}
+
+	b := polyglot.NewBuffer()
+	require.NoError(t, Encode(b, in))
+
+	var out record
+	require.NoError(t, Decode(b.Bytes(), &out))
+
+	in.Root.internal = 0
+	assert.Equal(t, in, out)
+}
+
+func TestDeterministicMaps(t *testing.T) {
+	t.Parallel()
+
+	m := make(map[point]int32)
+	for i := int32(0); i < 100; i++ {
+		m[point{i, -i}] = i
+	}
+	first := polyglot.NewBuffer()
+	require.NoError(t, Encode(first, m))
+	for i := 0; i < 10; i++ {
+		b := polyglot.NewBuffer()
+		require.NoError(t, Encode(b, m))
+		assert.Equal(t, first.Bytes(), b.Bytes())
+	}
+
+	d := polyglot.Decoder(first.Bytes())
+	size, err := d.Map(polyglot.AnyKind, polyglot.Int32Kind)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(100), size)
+}
+
+func TestKind(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []struct {
+		value interface{}
+		kind  polyglot.Kind
+	}{
+		{level(0), polyglot.Uint32Kind},
+		{int16(0), polyglot.Int32Kind},
+		{"", polyglot.StringKind},
+		{id{}, polyglot.BytesKind},
+		{version{}, polyglot.SliceKind},
+		{point{}, polyglot.AnyKind},
+		{&point{}, polyglot.AnyKind},
+		{map[string]bool(nil), polyglot.MapKind},
+	} {
+		kind, err := Kind(reflect.TypeOf(c.value))
+		require.NoError(t, err)
+		assert.Equal(t, c.kind, kind, "%T", c.value)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	b := polyglot.NewBuffer()
+	assert.ErrorIs(t, Encode(b, map[*point]bool{}), ErrInvalidKey)
+	assert.ErrorIs(t, Encode(b, map[struct {
+		X int
+		y int
+	}]bool{}), ErrInvalidKey)
+	assert.ErrorIs(t, Encode(b, map[interface{}]bool{}), ErrInvalidKey)
+	assert.ErrorIs(t, Encode(b, struct{ C chan int }{}), ErrUnsupportedType)
+	assert.ErrorIs(t, Encode(b, []interface{}{}), ErrUnsupportedType)
+	assert.Zero(t, b.Len())
+
+	var p point
+	assert.ErrorIs(t, Decode(nil, p), ErrDecodeNil)
+	assert.ErrorIs(t, Decode(nil, (*point)(nil)), ErrDecodeNil)
+
+	require.NoError(t, Encode(b, int32(1000)))
+	var small int8
+	assert.ErrorIs(t, Decode(b.Bytes(), &small), ErrOverflow)
+
+	b.Reset()
+	require.NoError(t, Encode(b, []byte{1, 2}))
+	var array id
+	assert.ErrorIs(t, Decode(b.Bytes(), &array), ErrInvalidLength)
+
+	b.Reset()
+	require.NoError(t, Encode(b, []uint16{1, 2}))
+	var v version
+	assert.ErrorIs(t, Decode(b.Bytes(), &v), ErrInvalidLength)
+
+	b.Reset()
+	require.NoError(t, Encode(b, nil))
+	var n *node
+	require.NoError(t, Decode(b.Bytes(), &n))
+	assert.Nil(t, n)
+}
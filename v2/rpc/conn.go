@@ -0,0 +1,415 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rpc
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// MaxFrameSize is the largest frame, in bytes, accepted from a connection.
+const MaxFrameSize = 16 << 20
+
+var (
+	ErrFrameTooLarge = errors.New("frame is too large")
+	ErrInvalidFrame  = errors.New("invalid frame")
+	ErrClosed        = errors.New("transport is closed")
+)
+
+type frameType uint8
+
+const (
+	frameRequest frameType = iota + 1
+	frameResponse
+	// frameStreamOpen starts a stream for a method.
+	frameStreamOpen
+	frameStreamMessage
+	// frameStreamCloseSend is sent by a client that has finished sending.
+	frameStreamCloseSend
+	// frameStreamEnd is sent by the server when the method returns. Its
+	// payload is empty or the error returned by the method.
+	frameStreamEnd
+	// frameStreamWindow grants the peer credit to send more messages.
+	frameStreamWindow
+	// frameStreamCancel is sent by a client whose context is done.
+	frameStreamCancel
+)
+
+// frame is the unit exchanged over a connection. Each frame is prefixed with
+// its length as a 4-byte big-endian integer, and its body is the polyglot
+// encoding of the id, type, method and payload.
+type frame struct {
+	id      uint32
+	typ     frameType
+	method  string
+	payload []byte
+}
+
+func writeFrame(w io.Writer, f frame) error {
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	b.Write([]byte{0, 0, 0, 0})
+	polyglot.Encoder(b).Uint32(f.id).Uint8(uint8(f.typ)).String(f.method).Bytes(f.payload)
+	data := b.Bytes()
+	if len(data)-4 > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+	_, err := w.Write(data)
+	return err
+}
+
+func readFrame(r io.Reader) (f frame, err error) {
+	var header [4]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return f, ErrFrameTooLarge
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	d := polyglot.Decoder(data)
+	if f.id, err = d.Uint32(); err != nil {
+		return f, fmt.Errorf("%w: %w", ErrInvalidFrame, err)
+	}
+	var typ uint8
+	if typ, err = d.Uint8(); err != nil {
+		return f, fmt.Errorf("%w: %w", ErrInvalidFrame, err)
+	}
+	f.typ = frameType(typ)
+	if f.method, err = d.String(); err != nil {
+		return f, fmt.Errorf("%w: %w", ErrInvalidFrame, err)
+	}
+	if f.payload, err = d.Bytes(nil); err != nil {
+		return f, fmt.Errorf("%w: %w", ErrInvalidFrame, err)
+	}
+	return
+}
+
+// frameWriter serializes writes of whole frames to a connection.
+type frameWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *frameWriter) write(f frame) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return writeFrame(w.w, f)
+}
+
+// ConnTransport is a Transport and StreamTransport over a single net.Conn.
+// Calls and streams are multiplexed over the connection, so it is safe for
+// concurrent use.
+type ConnTransport struct {
+	conn net.Conn
+	w    *frameWriter
+
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]chan frame
+	streams map[uint32]*stream
+	err     error
+	closed  chan struct{}
+}
+
+func NewConnTransport(conn net.Conn) *ConnTransport {
+	t := &ConnTransport{
+		conn:    conn,
+		w:       &frameWriter{w: conn},
+		pending: make(map[uint32]chan frame),
+		streams: make(map[uint32]*stream),
+		closed:  make(chan struct{}),
+	}
+	go t.read()
+	return t
+}
+
+func (t *ConnTransport) Invoke(ctx context.Context, method string, request []byte) ([]byte, error) {
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return nil, t.err
+	}
+	t.nextID++
+	id := t.nextID
+	ch := make(chan frame, 1)
+	t.pending[id] = ch
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}()
+
+	if err := t.w.write(frame{id: id, typ: frameRequest, method: method, payload: request}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case f := <-ch:
+		return f.payload, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-t.closed:
+		return nil, t.err
+	}
+}
+
+// NewStream opens a stream for method. The stream is cancelled on the
+// server when ctx is done.
+func (t *ConnTransport) NewStream(ctx context.Context, method string) (ClientStream, error) {
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return nil, t.err
+	}
+	t.nextID++
+	s := &clientStream{stream: newStream(ctx, t.nextID, t.w)}
+	t.streams[s.id] = s.stream
+	t.mu.Unlock()
+
+	if err := t.w.write(frame{id: s.id, typ: frameStreamOpen, method: method}); err != nil {
+		t.removeStream(s.id)
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-s.closed:
+		case <-ctx.Done():
+			t.removeStream(s.id)
+			s.finishRecv(ctx.Err())
+			s.close()
+			_ = t.w.write(frame{id: s.id, typ: frameStreamCancel})
+		}
+	}()
+	return s, nil
+}
+
+// Close closes the underlying connection. Pending calls and open streams
+// return ErrClosed.
+func (t *ConnTransport) Close() error {
+	err := t.conn.Close()
+	t.fail(ErrClosed)
+	return err
+}
+
+func (t *ConnTransport) read() {
+	r := bufio.NewReader(t.conn)
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				err = ErrClosed
+			}
+			t.fail(err)
+			return
+		}
+		t.mu.Lock()
+		ch, pending := t.pending[f.id]
+		s := t.streams[f.id]
+		t.mu.Unlock()
+
+		switch f.typ {
+		case frameResponse:
+			if pending {
+				ch <- f
+			}
+		case frameStreamMessage:
+			if s != nil {
+				s.deliver(f.payload)
+			}
+		case frameStreamWindow:
+			if s != nil {
+				s.grant(f.payload)
+			}
+		case frameStreamEnd:
+			if s != nil {
+				t.removeStream(f.id)
+				err = DecodeError(f.payload)
+				if err == nil {
+					err = io.EOF
+				}
+				s.finishRecv(err)
+				s.close()
+			}
+		}
+	}
+}
+
+func (t *ConnTransport) removeStream(id uint32) {
+	t.mu.Lock()
+	delete(t.streams, id)
+	t.mu.Unlock()
+}
+
+func (t *ConnTransport) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = err
+		close(t.closed)
+		for id, s := range t.streams {
+			s.finishRecv(err)
+			s.close()
+			delete(t.streams, id)
+		}
+	}
+}
+
+type clientStream struct {
+	*stream
+}
+
+func (s *clientStream) CloseSend() error {
+	if !s.closeSend() {
+		return nil
+	}
+	return s.w.write(frame{id: s.id, typ: frameStreamCloseSend})
+}
+
+// ServeConn serves calls and streams from conn until it is closed. Each
+// call and stream is handled in its own goroutine, and requests for
+// unknown methods are answered with an error.
+func (s *Server) ServeConn(conn net.Conn) error {
+	defer conn.Close()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &frameWriter{w: conn}
+	var mu sync.Mutex
+	streams := make(map[uint32]*serverStream)
+
+	r := bufio.NewReader(conn)
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		switch f.typ {
+		case frameRequest:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b := polyglot.GetBuffer()
+				defer polyglot.PutBuffer(b)
+				if err := s.Handle(ctx, f.method, f.payload, b); err != nil {
+					EncodeError(b, err)
+				}
+				_ = w.write(frame{id: f.id, typ: frameResponse, payload: b.Bytes()})
+			}()
+		case frameStreamOpen:
+			handler, ok := s.stream(f.method)
+			streamCtx, streamCancel := context.WithCancel(ctx)
+			st := &serverStream{stream: newStream(streamCtx, f.id, w), cancel: streamCancel}
+			if ok {
+				mu.Lock()
+				streams[f.id] = st
+				mu.Unlock()
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer streamCancel()
+				err := fmt.Errorf("%w: %s", ErrUnknownMethod, f.method)
+				if ok {
+					err = handler(st)
+					mu.Lock()
+					delete(streams, f.id)
+					mu.Unlock()
+				}
+				st.end(err)
+			}()
+		default:
+			mu.Lock()
+			st := streams[f.id]
+			mu.Unlock()
+			if st == nil {
+				continue
+			}
+			switch f.typ {
+			case frameStreamMessage:
+				st.deliver(f.payload)
+			case frameStreamWindow:
+				st.grant(f.payload)
+			case frameStreamCloseSend:
+				st.finishRecv(io.EOF)
+			case frameStreamCancel:
+				st.cancel()
+			}
+		}
+	}
+}
+
+type serverStream struct {
+	*stream
+	cancel context.CancelFunc
+}
+
+// end sends the result of the method to the client.
+func (s *serverStream) end(err error) {
+	s.closeSend()
+	s.close()
+	var payload []byte
+	if err != nil {
+		b := polyglot.GetBuffer()
+		defer polyglot.PutBuffer(b)
+		EncodeError(b, err)
+		payload = b.Bytes()
+	}
+	_ = s.w.write(frame{id: s.id, typ: frameStreamEnd, payload: payload})
+}
+
+// Serve accepts connections from l and serves each of them with ServeConn
+// until l is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			_ = s.ServeConn(conn)
+		}()
+	}
+}
@@ -0,0 +1,120 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rpc
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ContentType is the media type of polyglot-encoded HTTP bodies.
+const ContentType = "application/x-polyglot"
+
+var (
+	ErrUnexpectedStatus = errors.New("unexpected status")
+)
+
+// HTTPTransport is a Transport that POSTs each request to the URL formed by
+// appending the full method name to a base URL.
+type HTTPTransport struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPTransport returns a transport for the server at baseURL. If client
+// is nil, http.DefaultClient is used.
+func NewHTTPTransport(baseURL string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPTransport{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+func (t *HTTPTransport) Invoke(ctx context.Context, method string, request []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+method, bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", ContentType)
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, MaxFrameSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s: %s", ErrUnexpectedStatus, res.Status, strings.TrimSpace(string(body)))
+	}
+	if len(body) > MaxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+	return body, nil
+}
+
+// ServeHTTP makes the server an http.Handler. The request path is the full
+// method name, so the server should be mounted at the root of a mux or
+// behind http.StripPrefix. Errors returned by methods are written with a
+// 200 status as the polyglot error kind.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != ContentType {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	request, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxFrameSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	if err = s.Handle(r.Context(), r.URL.Path, request, b); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b.Bytes())
+}
@@ -0,0 +1,183 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package rpc runs the services generated by the polyglot Go generator over
+// pluggable transports. Requests and responses are polyglot-encoded messages,
+// and errors returned by a server travel to clients as the polyglot error
+// kind in place of the response.
+package rpc
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUnknownMethod    = errors.New("unknown method")
+	ErrDuplicateService = errors.New("service is already registered")
+)
+
+// Message is implemented by every generated message.
+type Message interface {
+	Encode(b *polyglot.Buffer)
+	Decode(b []byte) error
+}
+
+// Transport carries encoded requests to a server and returns the encoded
+// responses. The method is the full method name, "/<service>/<method>".
+type Transport interface {
+	Invoke(ctx context.Context, method string, request []byte) ([]byte, error)
+}
+
+// Handler decodes a request for a method of impl, calls it, and encodes its
+// response.
+type Handler func(impl interface{}, ctx context.Context, request []byte, response *polyglot.Buffer) error
+
+type MethodDesc struct {
+	Name    string
+	Handler Handler
+}
+
+// StreamHandler runs a streaming method of impl on stream.
+type StreamHandler func(impl interface{}, stream ServerStream) error
+
+type StreamDesc struct {
+	Name    string
+	Handler StreamHandler
+}
+
+// ServiceDesc describes a generated service. Name is the full name of the
+// service in its proto package.
+type ServiceDesc struct {
+	Name    string
+	Methods []MethodDesc
+	Streams []StreamDesc
+}
+
+// FullMethod returns the full name of a method of the service.
+func (d *ServiceDesc) FullMethod(name string) string {
+	return fmt.Sprintf("/%s/%s", d.Name, name)
+}
+
+// Invoke encodes request, sends it for method over t and decodes the
+// response into response. Errors returned by the server are decoded and
+// returned as polyglot.Error values.
+func Invoke(ctx context.Context, t Transport, method string, request Message, response Message) error {
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	request.Encode(b)
+
+	data, err := t.Invoke(ctx, method, b.Bytes())
+	if err != nil {
+		return err
+	}
+	if err = DecodeError(data); err != nil {
+		return err
+	}
+	return response.Decode(data)
+}
+
+// EncodeError replaces the contents of b with err, encoded as the polyglot
+// error kind.
+func EncodeError(b *polyglot.Buffer, err error) {
+	b.Reset()
+	polyglot.Encoder(b).Error(err)
+}
+
+// DecodeError returns the error encoded in data if data holds an error kind
+// instead of a message, and nil otherwise. Generated messages never start
+// with the error kind, so the two cannot be confused.
+func DecodeError(data []byte) error {
+	if len(data) == 0 || data[0] != polyglot.ErrorRawKind {
+		return nil
+	}
+	value, err := polyglot.Decoder(data).Error()
+	if err != nil {
+		return err
+	}
+	return value
+}
+
+// Server dispatches requests to the methods of registered services.
+type Server struct {
+	mu      sync.RWMutex
+	methods map[string]func(ctx context.Context, request []byte, response *polyglot.Buffer) error
+	streams map[string]func(stream ServerStream) error
+}
+
+func NewServer() *Server {
+	return &Server{
+		methods: make(map[string]func(ctx context.Context, request []byte, response *polyglot.Buffer) error),
+		streams: make(map[string]func(stream ServerStream) error),
+	}
+}
+
+// Register adds the methods of a service implemented by impl. The generated
+// Register<Service>Server functions call it with a typed implementation.
+func (s *Server) Register(desc *ServiceDesc, impl interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range desc.Methods {
+		if _, ok := s.methods[desc.FullMethod(m.Name)]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateService, desc.Name)
+		}
+	}
+	for _, m := range desc.Streams {
+		if _, ok := s.streams[desc.FullMethod(m.Name)]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateService, desc.Name)
+		}
+	}
+	for _, m := range desc.Methods {
+		handler := m.Handler
+		s.methods[desc.FullMethod(m.Name)] = func(ctx context.Context, request []byte, response *polyglot.Buffer) error {
+			return handler(impl, ctx, request, response)
+		}
+	}
+	for _, m := range desc.Streams {
+		handler := m.Handler
+		s.streams[desc.FullMethod(m.Name)] = func(stream ServerStream) error {
+			return handler(impl, stream)
+		}
+	}
+	return nil
+}
+
+// Handle calls method with an encoded request and writes the encoded
+// response, or the error returned by the method, to response. It only
+// returns an error if the method is not registered.
+func (s *Server) Handle(ctx context.Context, method string, request []byte, response *polyglot.Buffer) error {
+	s.mu.RLock()
+	handler, ok := s.methods[method]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownMethod, method)
+	}
+	if err := handler(ctx, request, response); err != nil {
+		EncodeError(response, err)
+	}
+	return nil
+}
+
+func (s *Server) stream(method string) (func(stream ServerStream) error, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	handler, ok := s.streams[method]
+	return handler, ok
+}
@@ -0,0 +1,428 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rpc
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testMessage struct {
+	Value string
+}
+
+func (x *testMessage) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(x.Value)
+}
+
+func (x *testMessage) Decode(b []byte) (err error) {
+	x.Value, err = polyglot.Decoder(b).String()
+	return
+}
+
+type testServer interface {
+	Upper(ctx context.Context, req *testMessage) (*testMessage, error)
+}
+
+type testImpl struct{}
+
+func (testImpl) Upper(_ context.Context, req *testMessage) (*testMessage, error) {
+	if req.Value == "" {
+		return nil, errors.New("value is required")
+	}
+	return &testMessage{Value: strings.ToUpper(req.Value)}, nil
+}
+
+// Split sends each character of the request as a message, and fails on
+// a '!'.
+func (testImpl) Split(req *testMessage, stream ServerStream) error {
+	for _, r := range req.Value {
+		if r == '!' {
+			return errors.New("cannot split '!'")
+		}
+		if err := stream.SendMsg(&testMessage{Value: string(r)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Join concatenates the messages sent by the client.
+func (testImpl) Join(stream ServerStream) error {
+	var values []string
+	for {
+		req := new(testMessage)
+		err := stream.RecvMsg(req)
+		if errors.Is(err, io.EOF) {
+			return stream.SendMsg(&testMessage{Value: strings.Join(values, " ")})
+		}
+		if err != nil {
+			return err
+		}
+		values = append(values, req.Value)
+	}
+}
+
+// Echo sends every message back to the client until the client closes the
+// stream, and blocks until its context is done if asked to.
+func (testImpl) Echo(stream ServerStream) error {
+	for {
+		req := new(testMessage)
+		if err := stream.RecvMsg(req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if req.Value == "block" {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}
+		if err := stream.SendMsg(req); err != nil {
+			return err
+		}
+	}
+}
+
+var testServiceDesc = &ServiceDesc{
+	Name: "test.Strings",
+	Methods: []MethodDesc{
+		{
+			Name: "Upper",
+			Handler: func(impl interface{}, ctx context.Context, request []byte, response *polyglot.Buffer) error {
+				req := new(testMessage)
+				if err := req.Decode(request); err != nil {
+					return err
+				}
+				res, err := impl.(testServer).Upper(ctx, req)
+				if err != nil {
+					return err
+				}
+				res.Encode(response)
+				return nil
+			},
+		},
+	},
+	Streams: []StreamDesc{
+		{
+			Name: "Split",
+			Handler: func(impl interface{}, stream ServerStream) error {
+				req := new(testMessage)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return impl.(testImpl).Split(req, stream)
+			},
+		},
+		{
+			Name: "Join",
+			Handler: func(impl interface{}, stream ServerStream) error {
+				return impl.(testImpl).Join(stream)
+			},
+		},
+		{
+			Name: "Echo",
+			Handler: func(impl interface{}, stream ServerStream) error {
+				return impl.(testImpl).Echo(stream)
+			},
+		},
+	},
+}
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer()
+	require.NoError(t, s.Register(testServiceDesc, testImpl{}))
+	return s
+}
+
+func testTransport(t *testing.T, transport Transport) {
+	var wg sync.WaitGroup
+	for _, value := range []string{"a", "polyglot", "rpc", "concurrent"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := new(testMessage)
+			err := Invoke(context.Background(), transport, "/test.Strings/Upper", &testMessage{Value: value}, res)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.ToUpper(value), res.Value)
+		}()
+	}
+	wg.Wait()
+
+	err := Invoke(context.Background(), transport, "/test.Strings/Upper", &testMessage{}, new(testMessage))
+	assert.EqualError(t, err, "value is required")
+	assert.IsType(t, polyglot.Error(""), err)
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+	assert.ErrorIs(t, s.Register(testServiceDesc, testImpl{}), ErrDuplicateService)
+	assert.Equal(t, "/test.Strings/Upper", testServiceDesc.FullMethod("Upper"))
+
+	b := polyglot.NewBuffer()
+	assert.ErrorIs(t, s.Handle(context.Background(), "/test.Strings/Lower", nil, b), ErrUnknownMethod)
+	assert.Zero(t, b.Len())
+}
+
+func TestConnTransport(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+	client, server := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServeConn(server)
+	}()
+
+	transport := NewConnTransport(client)
+	testTransport(t, transport)
+
+	err := Invoke(context.Background(), transport, "/test.Strings/Lower", &testMessage{Value: "a"}, new(testMessage))
+	assert.EqualError(t, err, "unknown method: /test.Strings/Lower")
+
+	require.NoError(t, transport.Close())
+	assert.NoError(t, <-done)
+
+	err = Invoke(context.Background(), transport, "/test.Strings/Upper", &testMessage{Value: "a"}, new(testMessage))
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestConnTransportCancel(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer server.Close()
+	transport := NewConnTransport(client)
+	defer transport.Close()
+
+	go func() {
+		// Read the request but never answer it.
+		_, _ = readFrame(server)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := transport.Invoke(ctx, "/test.Strings/Upper", nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFrameTooLarge(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		_, _ = client.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}()
+	_, err := readFrame(server)
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+}
+
+func TestHTTPTransport(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(newTestServer(t))
+	defer ts.Close()
+
+	transport := NewHTTPTransport(ts.URL, ts.Client())
+	testTransport(t, transport)
+
+	err := Invoke(context.Background(), transport, "/test.Strings/Lower", &testMessage{Value: "a"}, new(testMessage))
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+
+	res, err := ts.Client().Get(ts.URL + "/test.Strings/Upper")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, err = ts.Client().Post(ts.URL+"/test.Strings/Upper", "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+}
+
+func newTestStream(t *testing.T, ctx context.Context, transport *ConnTransport, method string) ClientStream {
+	stream, err := NewStream(ctx, transport, method)
+	require.NoError(t, err)
+	return stream
+}
+
+func TestStreams(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+	client, server := net.Pipe()
+	go func() {
+		_ = s.ServeConn(server)
+	}()
+	transport := NewConnTransport(client)
+	defer transport.Close()
+	ctx := context.Background()
+
+	// Server streaming, with enough messages to need more credit.
+	value := strings.Repeat("abcdefghij", 10)
+	stream := newTestStream(t, ctx, transport, "/test.Strings/Split")
+	require.NoError(t, stream.SendMsg(&testMessage{Value: value}))
+	require.NoError(t, stream.CloseSend())
+	var received strings.Builder
+	for {
+		res := new(testMessage)
+		err := stream.RecvMsg(res)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		received.WriteString(res.Value)
+	}
+	assert.Equal(t, value, received.String())
+	assert.ErrorIs(t, stream.SendMsg(&testMessage{}), ErrSendClosed)
+
+	// Errors returned by the method end the stream after the messages
+	// sent before them.
+	stream = newTestStream(t, ctx, transport, "/test.Strings/Split")
+	require.NoError(t, stream.SendMsg(&testMessage{Value: "a!"}))
+	res := new(testMessage)
+	require.NoError(t, stream.RecvMsg(res))
+	assert.Equal(t, "a", res.Value)
+	err := stream.RecvMsg(res)
+	assert.EqualError(t, err, "cannot split '!'")
+	assert.IsType(t, polyglot.Error(""), err)
+
+	// Client streaming.
+	stream = newTestStream(t, ctx, transport, "/test.Strings/Join")
+	for _, v := range []string{"polyglot", "client", "stream"} {
+		require.NoError(t, stream.SendMsg(&testMessage{Value: v}))
+	}
+	require.NoError(t, stream.CloseSend())
+	require.NoError(t, stream.RecvMsg(res))
+	assert.Equal(t, "polyglot client stream", res.Value)
+	assert.ErrorIs(t, stream.RecvMsg(res), io.EOF)
+
+	// Bidirectional streaming.
+	stream = newTestStream(t, ctx, transport, "/test.Strings/Echo")
+	for _, v := range []string{"ping", "pong"} {
+		require.NoError(t, stream.SendMsg(&testMessage{Value: v}))
+		require.NoError(t, stream.RecvMsg(res))
+		assert.Equal(t, v, res.Value)
+	}
+	require.NoError(t, stream.CloseSend())
+	assert.ErrorIs(t, stream.RecvMsg(res), io.EOF)
+
+	stream = newTestStream(t, ctx, transport, "/test.Strings/Unknown")
+	assert.EqualError(t, stream.RecvMsg(res), "unknown method: /test.Strings/Unknown")
+
+	_, err = NewStream(ctx, NewHTTPTransport("http://localhost", nil), "/test.Strings/Echo")
+	assert.ErrorIs(t, err, ErrStreamingUnsupported)
+}
+
+func TestStreamCancel(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer()
+	cancelled := make(chan error, 1)
+	require.NoError(t, s.Register(&ServiceDesc{
+		Name: "test.Block",
+		Streams: []StreamDesc{{
+			Name: "Wait",
+			Handler: func(_ interface{}, stream ServerStream) error {
+				<-stream.Context().Done()
+				cancelled <- stream.Context().Err()
+				return nil
+			},
+		}},
+	}, nil))
+	client, server := net.Pipe()
+	go func() {
+		_ = s.ServeConn(server)
+	}()
+	transport := NewConnTransport(client)
+	defer transport.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newTestStream(t, ctx, transport, "/test.Block/Wait")
+	cancel()
+	assert.ErrorIs(t, stream.RecvMsg(new(testMessage)), context.Canceled)
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+}
+
+func TestStreamFlowControl(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer()
+	start := make(chan struct{})
+	require.NoError(t, s.Register(&ServiceDesc{
+		Name: "test.Slow",
+		Streams: []StreamDesc{{
+			Name: "Read",
+			Handler: func(_ interface{}, stream ServerStream) error {
+				<-start
+				for {
+					if err := stream.RecvMsg(new(testMessage)); err != nil {
+						if errors.Is(err, io.EOF) {
+							return nil
+						}
+						return err
+					}
+				}
+			},
+		}},
+	}, nil))
+	client, server := net.Pipe()
+	go func() {
+		_ = s.ServeConn(server)
+	}()
+	transport := NewConnTransport(client)
+	defer transport.Close()
+
+	stream := newTestStream(t, context.Background(), transport, "/test.Slow/Read")
+	var sent atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 3*streamWindow; i++ {
+			if err := stream.SendMsg(&testMessage{Value: "x"}); err != nil {
+				done <- err
+				return
+			}
+			sent.Add(1)
+		}
+		done <- stream.CloseSend()
+	}()
+
+	// The sender stops once the window is used up, until the server reads.
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, streamWindow, sent.Load())
+
+	close(start)
+	require.NoError(t, <-done)
+	assert.EqualValues(t, 3*streamWindow, sent.Load())
+	assert.ErrorIs(t, stream.RecvMsg(new(testMessage)), io.EOF)
+}
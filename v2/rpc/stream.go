@@ -0,0 +1,241 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rpc
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// streamWindow is the number of messages a peer may send on a stream before
+// the receiver grants it more credit. Credit is returned as the receiver
+// consumes messages, so a slow reader applies backpressure to the sender.
+const streamWindow = 32
+
+var (
+	ErrStreamingUnsupported = errors.New("transport does not support streaming")
+	ErrSendClosed           = errors.New("stream is closed for sending")
+	ErrFlowControl          = errors.New("peer exceeded the stream window")
+)
+
+// Stream is the untyped side of a streaming call. The stream handles
+// generated for streaming methods wrap it with typed Send and Recv methods.
+type Stream interface {
+	Context() context.Context
+	SendMsg(m Message) error
+	// RecvMsg returns io.EOF once the peer has finished sending, or the
+	// error the server method returned.
+	RecvMsg(m Message) error
+}
+
+type ClientStream interface {
+	Stream
+	// CloseSend tells the server that the client has finished sending.
+	CloseSend() error
+}
+
+type ServerStream interface {
+	Stream
+}
+
+// StreamTransport is a Transport that also carries streaming calls.
+type StreamTransport interface {
+	Transport
+	NewStream(ctx context.Context, method string) (ClientStream, error)
+}
+
+// NewStream opens a stream for method over t, which must implement
+// StreamTransport.
+func NewStream(ctx context.Context, t Transport, method string) (ClientStream, error) {
+	st, ok := t.(StreamTransport)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+	return st.NewStream(ctx, method)
+}
+
+// stream is one end of a streaming call over a connection.
+type stream struct {
+	ctx context.Context
+	id  uint32
+	w   *frameWriter
+
+	messages chan []byte
+
+	// recvDone is closed, with recvErr set, once the peer stops sending.
+	recvDone chan struct{}
+	recvOnce sync.Once
+	recvErr  error
+
+	// closed is closed once the call is over in both directions.
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	credit     uint32
+	consumed   uint32
+	sendClosed bool
+	creditCh   chan struct{}
+}
+
+func newStream(ctx context.Context, id uint32, w *frameWriter) *stream {
+	return &stream{
+		ctx:      ctx,
+		id:       id,
+		w:        w,
+		messages: make(chan []byte, streamWindow),
+		recvDone: make(chan struct{}),
+		closed:   make(chan struct{}),
+		credit:   streamWindow,
+		creditCh: make(chan struct{}, 1),
+	}
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stream) SendMsg(m Message) error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	m.Encode(b)
+	return s.w.write(frame{id: s.id, typ: frameStreamMessage, payload: b.Bytes()})
+}
+
+// acquire takes one message of credit, waiting for the peer to grant more
+// if the window is exhausted.
+func (s *stream) acquire() error {
+	for {
+		s.mu.Lock()
+		if s.sendClosed {
+			s.mu.Unlock()
+			return ErrSendClosed
+		}
+		if s.credit > 0 {
+			s.credit--
+			s.mu.Unlock()
+			return nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.creditCh:
+		case <-s.closed:
+			return io.EOF
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+}
+
+func (s *stream) RecvMsg(m Message) error {
+	select {
+	case data := <-s.messages:
+		return s.consume(data, m)
+	case <-s.recvDone:
+		// Messages are queued before the stream finishes, so drain them
+		// before reporting the end of the stream.
+		select {
+		case data := <-s.messages:
+			return s.consume(data, m)
+		default:
+			return s.recvErr
+		}
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// consume decodes a received message and returns credit to the peer once
+// half of the window has been consumed.
+func (s *stream) consume(data []byte, m Message) error {
+	s.mu.Lock()
+	s.consumed++
+	var grant uint32
+	if s.consumed >= streamWindow/2 {
+		grant, s.consumed = s.consumed, 0
+	}
+	s.mu.Unlock()
+
+	if grant > 0 {
+		b := polyglot.GetBuffer()
+		polyglot.Encoder(b).Uint32(grant)
+		err := s.w.write(frame{id: s.id, typ: frameStreamWindow, payload: b.Bytes()})
+		polyglot.PutBuffer(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.Decode(data)
+}
+
+// closeSend stops further sends, and returns false if they were already
+// stopped.
+func (s *stream) closeSend() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendClosed {
+		return false
+	}
+	s.sendClosed = true
+	return true
+}
+
+// deliver queues a message from the peer. It never blocks, since it is
+// called from the goroutine reading the connection.
+func (s *stream) deliver(data []byte) {
+	select {
+	case s.messages <- data:
+	default:
+		s.finishRecv(ErrFlowControl)
+	}
+}
+
+func (s *stream) grant(data []byte) {
+	n, err := polyglot.Decoder(data).Uint32()
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.credit += n
+	s.mu.Unlock()
+	select {
+	case s.creditCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *stream) finishRecv(err error) {
+	s.recvOnce.Do(func() {
+		s.recvErr = err
+		close(s.recvDone)
+	})
+}
+
+func (s *stream) close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+}
@@ -0,0 +1,122 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package schema prefixes encoded polyglot buffers with a header carrying
+// the schema fingerprint of the message they hold, so a reader built from a
+// different version of the .proto file reports a mismatch instead of
+// decoding the buffer into the wrong fields.
+//
+// The header is a version byte followed by the fingerprint as a big endian
+// uint64. The version byte has its high bit set, which no encoded polyglot
+// value starts with, so HasHeader can tell buffers with and without a header
+// apart.
+package schema
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Version is the first byte of the header.
+	Version = byte(0x81)
+
+	// HeaderSize is the size of the header in bytes.
+	HeaderSize = 9
+)
+
+var (
+	ErrInvalidHeader       = errors.New("invalid schema header")
+	ErrFingerprintMismatch = errors.New("schema fingerprint mismatch")
+)
+
+// MismatchError is returned when the fingerprint in a header is not the
+// expected one. It unwraps to ErrFingerprintMismatch.
+type MismatchError struct {
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("%s: expected %016x, got %016x", ErrFingerprintMismatch, e.Expected, e.Actual)
+}
+
+func (e *MismatchError) Unwrap() error {
+	return ErrFingerprintMismatch
+}
+
+// Message is implemented by generated messages.
+type Message interface {
+	Encode(b *polyglot.Buffer)
+	Decode(b []byte) error
+	Fingerprint() uint64
+}
+
+// HasHeader reports whether src starts with a header.
+func HasHeader(src []byte) bool {
+	return len(src) > 0 && src[0] == Version
+}
+
+// Seal appends a header carrying fingerprint to dst, followed by payload.
+func Seal(dst []byte, fingerprint uint64, payload []byte) []byte {
+	dst = append(dst, Version)
+	dst = binary.BigEndian.AppendUint64(dst, fingerprint)
+	return append(dst, payload...)
+}
+
+// Peek returns the fingerprint in the header of src and the payload after
+// it, which shares the memory of src.
+func Peek(src []byte) (uint64, []byte, error) {
+	if len(src) < HeaderSize || src[0] != Version {
+		return 0, nil, ErrInvalidHeader
+	}
+	return binary.BigEndian.Uint64(src[1:HeaderSize]), src[HeaderSize:], nil
+}
+
+// Open checks that the header of src carries fingerprint and returns the
+// payload after it, which shares the memory of src.
+func Open(src []byte, fingerprint uint64) ([]byte, error) {
+	actual, payload, err := Peek(src)
+	if err != nil {
+		return nil, err
+	}
+	if actual != fingerprint {
+		return nil, &MismatchError{Expected: fingerprint, Actual: actual}
+	}
+	return payload, nil
+}
+
+// SealMessage appends a header carrying the fingerprint of m to dst,
+// followed by the encoding of m.
+func SealMessage(dst []byte, m Message) []byte {
+	b := polyglot.GetBuffer()
+	defer polyglot.PutBuffer(b)
+	m.Encode(b)
+	return Seal(dst, m.Fingerprint(), b.Bytes())
+}
+
+// OpenMessage checks that the header of src carries the fingerprint of m
+// and decodes the payload after it into m.
+func OpenMessage(src []byte, m Message) error {
+	payload, err := Open(src, m.Fingerprint())
+	if err != nil {
+		return err
+	}
+	return m.Decode(payload)
+}
@@ -0,0 +1,89 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package schema
+
+import (
+	"github.com/loopholelabs/polyglot/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"errors"
+	"testing"
+)
+
+type testMessage struct {
+	name        string
+	fingerprint uint64
+}
+
+func (m *testMessage) Encode(b *polyglot.Buffer) {
+	polyglot.Encoder(b).String(m.name)
+}
+
+func (m *testMessage) Decode(b []byte) (err error) {
+	m.name, err = polyglot.Decoder(b).String()
+	return
+}
+
+func (m *testMessage) Fingerprint() uint64 {
+	return m.fingerprint
+}
+
+func TestHeader(t *testing.T) {
+	t.Parallel()
+
+	sealed := Seal([]byte("prefix"), 0x0102030405060708, []byte("payload"))
+	require.Equal(t, []byte("prefix"), sealed[:6])
+	sealed = sealed[6:]
+	assert.True(t, HasHeader(sealed))
+	assert.Len(t, sealed, HeaderSize+len("payload"))
+
+	fingerprint, payload, err := Peek(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x0102030405060708), fingerprint)
+	assert.Equal(t, []byte("payload"), payload)
+
+	payload, err = Open(sealed, 0x0102030405060708)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), payload)
+
+	_, err = Open(sealed, 1)
+	assert.ErrorIs(t, err, ErrFingerprintMismatch)
+	var mismatch *MismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, uint64(1), mismatch.Expected)
+	assert.Equal(t, uint64(0x0102030405060708), mismatch.Actual)
+
+	_, err = Open(sealed[:HeaderSize-1], 0x0102030405060708)
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+}
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	sealed := SealMessage(nil, &testMessage{name: "polyglot", fingerprint: 42})
+	decoded := &testMessage{fingerprint: 42}
+	require.NoError(t, OpenMessage(sealed, decoded))
+	assert.Equal(t, "polyglot", decoded.name)
+
+	assert.ErrorIs(t, OpenMessage(sealed, &testMessage{fingerprint: 43}), ErrFingerprintMismatch)
+
+	b := polyglot.NewBuffer()
+	(&testMessage{name: "polyglot"}).Encode(b)
+	assert.False(t, HasHeader(b.Bytes()))
+	assert.ErrorIs(t, OpenMessage(b.Bytes(), decoded), ErrInvalidHeader)
+}
@@ -0,0 +1,112 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"iter"
+)
+
+// paddedUint32Size is the size of a uint32 written as a fixed-width varint,
+// padded with continuation bits so that it can be patched in place.
+const paddedUint32Size = 1 + VarIntLen32
+
+// SliceWriter encodes a slice whose length is not known when it starts, for
+// example one produced by an iterator, a channel or a database cursor. The
+// length is reserved when the slice starts and written by End, so the
+// result decodes like any other slice.
+type SliceWriter struct {
+	b      *Buffer
+	offset int
+	size   uint32
+}
+
+// StartSlice starts a slice of kind. Encode each element with the encoder
+// returned by Next, then call End.
+func (e *BufferEncoder) StartSlice(kind Kind) *SliceWriter {
+	b := (*Buffer)(e)
+	b.Grow(2 + paddedUint32Size)
+	b.b[b.offset] = SliceRawKind
+	b.b[b.offset+1] = byte(kind)
+	w := &SliceWriter{
+		b:      b,
+		offset: b.offset + 2,
+	}
+	b.offset += 2 + paddedUint32Size
+	return w
+}
+
+// Next counts one more element and returns the encoder to write it with.
+func (w *SliceWriter) Next() *BufferEncoder {
+	w.size++
+	return Encoder(w.b)
+}
+
+// Len returns the number of elements started so far.
+func (w *SliceWriter) Len() uint32 {
+	return w.size
+}
+
+// End writes the length of the slice.
+func (w *SliceWriter) End() *BufferEncoder {
+	b := w.b.b[w.offset : w.offset+paddedUint32Size]
+	b[0] = Uint32RawKind
+	size := w.size
+	for i := 1; i < paddedUint32Size-1; i++ {
+		b[i] = byte(size&(continuation-1)) | continuation
+		size >>= 7
+	}
+	b[paddedUint32Size-1] = byte(size)
+	return Encoder(w.b)
+}
+
+// EncodeSliceSeq encodes the values of seq as a slice of kind, using encode
+// to write each value. Methods of BufferEncoder can be passed as encode, for
+// example (*BufferEncoder).String.
+func EncodeSliceSeq[T any](e *BufferEncoder, kind Kind, seq iter.Seq[T], encode func(*BufferEncoder, T) *BufferEncoder) *BufferEncoder {
+	w := e.StartSlice(kind)
+	for v := range seq {
+		encode(w.Next(), v)
+	}
+	return w.End()
+}
+
+// DecodeSliceSeq decodes a slice of kind one element at a time, using
+// decode to read each element. Methods of BufferDecoder can be passed as
+// decode, for example (*BufferDecoder).String. The sequence stops after the
+// first error, which it yields with the zero value of T. Iterating the
+// sequence consumes the slice from d, so it can only be iterated once.
+func DecodeSliceSeq[T any](d *BufferDecoder, kind Kind, decode func(*BufferDecoder) (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		size, err := d.Slice(kind)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		for i := uint32(0); i < size; i++ {
+			v, err := decode(d)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package polyglot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"slices"
+	"testing"
+)
+
+func TestSliceWriter(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []uint32{0, 1, 127, 128, 300, 1 << 14, 1<<14 + 1} {
+		p := NewBuffer()
+		w := Encoder(p).StartSlice(Uint32Kind)
+		for i := uint32(0); i < size; i++ {
+			w.Next().Uint32(i)
+		}
+		assert.Equal(t, size, w.Len())
+		w.End().String("after")
+
+		d := Decoder(p.Bytes())
+		n, err := d.Slice(Uint32Kind)
+		require.NoError(t, err)
+		require.Equal(t, size, n)
+		for i := uint32(0); i < n; i++ {
+			v, err := d.Uint32()
+			require.NoError(t, err)
+			require.Equal(t, i, v)
+		}
+		s, err := d.String()
+		require.NoError(t, err)
+		assert.Equal(t, "after", s)
+		assert.Empty(t, *d)
+	}
+
+	// Slices can be nested, and the buffer can grow while they are open.
+	p := NewBufferSize(1)
+	outer := Encoder(p).StartSlice(SliceKind)
+	for i := 0; i < 3; i++ {
+		inner := outer.Next().StartSlice(StringKind)
+		for j := 0; j < i; j++ {
+			inner.Next().String("nested")
+		}
+		inner.End()
+	}
+	outer.End()
+
+	d := Decoder(p.Bytes())
+	n, err := d.Slice(SliceKind)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), n)
+	for i := uint32(0); i < n; i++ {
+		m, err := d.Slice(StringKind)
+		require.NoError(t, err)
+		assert.Equal(t, i, m)
+		for j := uint32(0); j < m; j++ {
+			_, err = d.String()
+			require.NoError(t, err)
+		}
+	}
+	assert.Empty(t, *d)
+}
+
+func TestSliceSeq(t *testing.T) {
+	t.Parallel()
+
+	values := []string{"polyglot", "streaming", "slices"}
+	p := NewBuffer()
+	EncodeSliceSeq(Encoder(p), StringKind, slices.Values(values), (*BufferEncoder).String)
+
+	expected := NewBuffer()
+	Encoder(expected).Slice(uint32(len(values)), StringKind)
+	for _, v := range values {
+		Encoder(expected).String(v)
+	}
+
+	for _, data := range [][]byte{p.Bytes(), expected.Bytes()} {
+		var decoded []string
+		for v, err := range DecodeSliceSeq(Decoder(data), StringKind, (*BufferDecoder).String) {
+			require.NoError(t, err)
+			decoded = append(decoded, v)
+		}
+		assert.Equal(t, values, decoded)
+	}
+
+	d := Decoder(expected.Bytes())
+	for v, err := range DecodeSliceSeq(d, StringKind, (*BufferDecoder).String) {
+		require.NoError(t, err)
+		assert.Equal(t, values[0], v)
+		break
+	}
+	v, err := d.String()
+	require.NoError(t, err)
+	assert.Equal(t, values[1], v)
+
+	var errs []error
+	for _, err := range DecodeSliceSeq(Decoder(expected.Bytes()), StringKind, (*BufferDecoder).Bool) {
+		errs = append(errs, err)
+	}
+	assert.Equal(t, []error{ErrInvalidBool}, errs)
+
+	errs = errs[:0]
+	for _, err := range DecodeSliceSeq(Decoder(expected.Bytes()), BoolKind, (*BufferDecoder).Bool) {
+		errs = append(errs, err)
+	}
+	assert.Equal(t, []error{ErrInvalidSlice}, errs)
+}
@@ -0,0 +1,85 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package validation holds the errors returned by the Validate methods that
+// the Go generator produces from (polyglot.field).rules options.
+package validation
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalid = errors.New("validation failed")
+)
+
+// Validator is implemented by every generated message.
+type Validator interface {
+	Validate() error
+}
+
+// Error describes the field that failed validation. Field is the path to
+// the field from the message Validate was called on, using proto field
+// names, for example "addresses[home].city".
+type Error struct {
+	Field  string
+	Reason string
+}
+
+func NewError(field string, reason string) *Error {
+	return &Error{
+		Field:  field,
+		Reason: reason,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+func (e *Error) Is(err error) bool {
+	return err == ErrInvalid
+}
+
+// Prefix prepends the name of a message field to the path of a validation
+// error returned by the message in that field.
+func Prefix(field string, err error) error {
+	return prefix(field, err)
+}
+
+// PrefixIndex prepends the name and index of a repeated field to the path of
+// a validation error returned by one of its items.
+func PrefixIndex(field string, index int, err error) error {
+	return prefix(fmt.Sprintf("%s[%d]", field, index), err)
+}
+
+// PrefixKey prepends the name and key of a map field to the path of a
+// validation error returned by one of its values.
+func PrefixKey(field string, key interface{}, err error) error {
+	return prefix(fmt.Sprintf("%s[%v]", field, key), err)
+}
+
+func prefix(path string, err error) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		return err
+	}
+	if e.Field == "" {
+		return NewError(path, e.Reason)
+	}
+	return NewError(path+"."+e.Field, e.Reason)
+}
@@ -0,0 +1,50 @@
+/*
+	Copyright 2023 Loophole Labs
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		   http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package validation
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	err := error(NewError("name", "is required"))
+	assert.EqualError(t, err, "invalid name: is required")
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	err = Prefix("inner", err)
+	assert.EqualError(t, err, "invalid inner.name: is required")
+
+	err = PrefixIndex("children", 2, err)
+	assert.EqualError(t, err, "invalid children[2].inner.name: is required")
+
+	err = PrefixKey("groups", "admins", err)
+	assert.EqualError(t, err, "invalid groups[admins].children[2].inner.name: is required")
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, "groups[admins].children[2].inner.name", e.Field)
+	assert.Equal(t, "is required", e.Reason)
+
+	assert.Equal(t, io.EOF, Prefix("inner", io.EOF))
+	assert.NotErrorIs(t, io.EOF, ErrInvalid)
+}